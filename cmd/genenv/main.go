@@ -0,0 +1,15 @@
+// Command genenv writes the example env file, listing every setting of the
+// service, to stdout.
+package main
+
+import (
+	"log"
+	"os"
+	"stress-relief-ai-chat-back/internal/config"
+)
+
+func main() {
+	if err := config.WriteExampleEnv(os.Stdout); err != nil {
+		log.Fatalf("Error writing example env: %s", err)
+	}
+}
@@ -2,95 +2,577 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/joho/godotenv"
+	"github.com/jackc/pgx/v5/pgxpool"
+	goredis "github.com/redis/go-redis/v9"
 	"log"
 	"os"
 	"os/signal"
-	"strconv"
+	"runtime/debug"
+	"stress-relief-ai-chat-back/internal/adapters/auth"
+	"stress-relief-ai-chat-back/internal/adapters/cache"
+	"stress-relief-ai-chat-back/internal/adapters/encryption"
+	"stress-relief-ai-chat-back/internal/adapters/fake"
+	"stress-relief-ai-chat-back/internal/adapters/file"
 	"stress-relief-ai-chat-back/internal/adapters/http"
+	"stress-relief-ai-chat-back/internal/adapters/instrumented"
+	"stress-relief-ai-chat-back/internal/adapters/memory"
+	"stress-relief-ai-chat-back/internal/adapters/noop"
 	"stress-relief-ai-chat-back/internal/adapters/openai"
+	"stress-relief-ai-chat-back/internal/adapters/otel"
+	"stress-relief-ai-chat-back/internal/adapters/postgres"
+	"stress-relief-ai-chat-back/internal/adapters/prometheus"
+	"stress-relief-ai-chat-back/internal/adapters/ratelimit"
+	"stress-relief-ai-chat-back/internal/adapters/redis"
+	"stress-relief-ai-chat-back/internal/adapters/safety"
+	"stress-relief-ai-chat-back/internal/adapters/sqlite"
+	"stress-relief-ai-chat-back/internal/adapters/supabase"
+	supabaseaudit "stress-relief-ai-chat-back/internal/adapters/supabase/audit"
+	"stress-relief-ai-chat-back/internal/adapters/supabase/exports"
+	"stress-relief-ai-chat-back/internal/adapters/supabase/identity"
+	"stress-relief-ai-chat-back/internal/adapters/supabase/safetyevents"
+	"stress-relief-ai-chat-back/internal/adapters/supabase/tombstones"
+	"stress-relief-ai-chat-back/internal/adapters/supabase/usage"
 	"stress-relief-ai-chat-back/internal/adapters/supabase/users"
 	"stress-relief-ai-chat-back/internal/adapters/zap"
+	"stress-relief-ai-chat-back/internal/app/account"
+	"stress-relief-ai-chat-back/internal/app/admin"
 	"stress-relief-ai-chat-back/internal/app/chat"
+	"stress-relief-ai-chat-back/internal/app/export"
+	"stress-relief-ai-chat-back/internal/app/health"
+	"stress-relief-ai-chat-back/internal/app/quota"
+	"stress-relief-ai-chat-back/internal/app/retention"
+	"stress-relief-ai-chat-back/internal/app/session"
+	"stress-relief-ai-chat-back/internal/app/shutdown"
+	"stress-relief-ai-chat-back/internal/config"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"strings"
 	"syscall"
 	"time"
 )
 
+// Set at build time with -ldflags "-X main.version=... -X main.commit=...".
+var (
+	version = "dev"
+	commit  = ""
+)
+
 func main() {
-	if os.Getenv("RAILWAY_ENVIRONMENT_NAME") == "" {
-		if err := godotenv.Load(); err != nil {
-			log.Printf("Warning: .env file not found")
+	// Deferred first, so the other deferred cleanups run before exiting. A
+	// panic still exits with its own status, as the code is zero then.
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
 		}
-	} else {
+	}()
+
+	// The .env file is only meant for local development
+	opts := config.Options{EnvFile: ".env"}
+	if os.Getenv("RAILWAY_ENVIRONMENT_NAME") != "" {
 		log.Println("Running in Railway environment: ", os.Getenv("RAILWAY_ENVIRONMENT"))
+		opts.EnvFile = ""
 	}
-
-	fmt.Println("ENV DUMP:")
-	for _, e := range os.Environ() {
-		fmt.Println(e)
+	// Report the values that can't be read along with the invalid ones
+	cfg, err := config.Load(opts)
+	if cfg != nil {
+		err = errors.Join(err, cfg.Validate())
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%s", err)
 	}
 
-	logger, err := zap.NewLogger("development")
+	// Secrets are masked wherever they would appear in a log line
+	samplingInitial, samplingThereafter, _ := cfg.Log.SamplingRates()
+	logger, err := zap.NewLoggerFromConfig(zap.Config{
+		Level:              cfg.Log.Level,
+		Encoding:           cfg.Log.Encoding,
+		Development:        cfg.Log.Development,
+		SamplingInitial:    samplingInitial,
+		SamplingThereafter: samplingThereafter,
+		OutputPaths:        cfg.Log.OutputPaths,
+		ErrorOutputPaths:   cfg.Log.ErrorOutputPaths,
+		RedactKeys:         cfg.Log.RedactKeys,
+		RedactValues:       cfg.Secrets(),
+	})
 	if err != nil {
 		log.Fatalf("Error initializing logger: %s", err)
 	}
 
+	// Metrics are exposed at /metrics on their own port unless disabled
+	var (
+		metrics           ports.Metrics = noop.NewMetrics()
+		prometheusMetrics *prometheus.Metrics
+	)
+	if cfg.Metrics.Enabled {
+		prometheusMetrics = prometheus.NewMetrics()
+		metrics = prometheusMetrics
+	}
+
+	// Traces are only exported when an exporter is configured, trace context
+	// is propagated regardless
+	tracer, err := otel.NewTracer(context.Background(), otel.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		ServiceName: "stress-relief-ai-chat-back",
+	})
+	if err != nil {
+		logger.Fatal(context.Background(), "could not create tracer", "error", err.Error())
+	}
+
 	// Initialize adapters
-	openaiAdapter := openai.NewOpenAIAdapter(os.Getenv("OPENAI_API_KEY"),
-		os.Getenv("OPENAI_ASSISTANT_ID"),
-		logger)
+	var openaiAdapter ports.ChatHandler
+	switch cfg.LLM.Provider {
+	case "openai":
+		openaiAdapter, err = openai.NewOpenAIAdapter(cfg.LLM.OpenAIAPIKey, cfg.LLM.OpenAIAssistantID, logger, metrics, tracer)
+		if err != nil {
+			logger.Fatal(context.Background(), "could not create OpenAI adapter", "error", err.Error())
+		}
+	case "fake":
+		var fixtures *fake.Fixtures
+		if path := cfg.LLM.FakeFixtures; path != "" {
+			fixtures, err = fake.LoadFixtures(path)
+			if err != nil {
+				logger.Fatal(context.Background(), "could not load fake LLM fixtures", "error", err.Error())
+			}
+		}
+		openaiAdapter, err = fake.NewChatHandler(fixtures, logger)
+		if err != nil {
+			logger.Fatal(context.Background(), "could not create fake LLM", "error", err.Error())
+		}
+		logger.Warn(context.Background(), "Using the fake LLM, replies are scripted")
+	}
+
+	// Create the client shared by the Supabase adapters
+	var supabaseClient *supabase.Client
+	if cfg.Supabase.URL != "" {
+		supabaseClient, err = supabase.NewClient(cfg.Supabase.APIKey, cfg.Supabase.URL, cfg.Supabase.Timeout, logger, metrics, tracer)
+		if err != nil {
+			logger.Fatal(context.Background(), "could not create Supabase client", "error", err.Error())
+		}
+	}
 
 	// Create user storage
-	userAPIHandler, err := users.NewUserAPIHandler(os.Getenv("SUPABASE_API_KEY"), os.Getenv("SUPABASE_URL"), logger)
+	var (
+		userAPIHandler      ports.UserDataAPIHandler
+		tombstoneRepository ports.TombstoneRepository
+		usageRepository     ports.UsageRepository
+		safetyEvents        ports.SafetyEventRepository
+		pool                *pgxpool.Pool
+		db                  *sql.DB
+	)
+	switch cfg.Storage.Driver {
+	case "supabase":
+		userAPIHandler, err = users.NewUserAPIHandler(supabaseClient, logger)
+		if err == nil {
+			tombstoneRepository, err = tombstones.NewTombstoneRepository(supabaseClient, logger)
+		}
+		if err == nil {
+			usageRepository, err = usage.NewUsageRepository(supabaseClient, logger)
+		}
+		if err == nil {
+			safetyEvents, err = safetyevents.NewSafetyEventRepository(supabaseClient, logger)
+		}
+	case "postgres":
+		pool, err = postgres.NewPool(context.Background(), cfg.Storage.DatabaseURL)
+		if err != nil {
+			logger.Fatal(context.Background(), "could not connect to database", "error", err.Error())
+		}
+		defer pool.Close()
+		if cfg.Storage.AutoMigrate {
+			if err := postgres.Migrate(context.Background(), pool, logger); err != nil {
+				logger.Fatal(context.Background(), "could not migrate database", "error", err.Error())
+			}
+		}
+		userAPIHandler, err = postgres.NewUserDataRepository(pool, logger)
+		if err == nil {
+			tombstoneRepository, err = postgres.NewTombstoneRepository(pool, logger)
+		}
+		if err == nil {
+			usageRepository, err = postgres.NewUsageRepository(pool, logger)
+		}
+		if err == nil {
+			safetyEvents, err = postgres.NewSafetyEventRepository(pool, logger)
+		}
+	case "sqlite":
+		db, err = sqlite.Open(context.Background(), cfg.Storage.SQLitePath)
+		if err != nil {
+			logger.Fatal(context.Background(), "could not open database", "error", err.Error())
+		}
+		defer db.Close()
+		if err := sqlite.Migrate(context.Background(), db, logger); err != nil {
+			logger.Fatal(context.Background(), "could not migrate database", "error", err.Error())
+		}
+		userAPIHandler, err = sqlite.NewUserDataRepository(db, logger)
+		if err == nil {
+			tombstoneRepository, err = sqlite.NewTombstoneRepository(db, logger)
+		}
+		if err == nil {
+			usageRepository, err = sqlite.NewUsageRepository(db, logger)
+		}
+		if err == nil {
+			safetyEvents, err = sqlite.NewSafetyEventRepository(db, logger)
+		}
+	}
 	if err != nil {
 		logger.Fatal(context.Background(), "could not create user storage", "error", err.Error())
 	}
+	// The Supabase client records its own calls
+	if cfg.Storage.Driver != "supabase" {
+		userAPIHandler, err = instrumented.NewUserDataHandler(userAPIHandler, cfg.Storage.Driver, metrics)
+		if err == nil {
+			tombstoneRepository, err = instrumented.NewTombstoneRepository(tombstoneRepository, cfg.Storage.Driver, metrics)
+		}
+		if err == nil {
+			usageRepository, err = instrumented.NewUsageRepository(usageRepository, cfg.Storage.Driver, metrics)
+		}
+		if err == nil {
+			safetyEvents, err = instrumented.NewSafetyEventRepository(safetyEvents, cfg.Storage.Driver, metrics)
+		}
+		if err != nil {
+			logger.Fatal(context.Background(), "could not instrument user storage", "error", err.Error())
+		}
+	}
+
+	// Cache user data lookups. The cache sits below encryption, so a shared
+	// cache only ever holds encrypted thread ids. A zero TTL disables the
+	// cache.
+	var (
+		userDataCache ports.CacheStatsReporter
+		redisClient   *goredis.Client
+	)
+	if cfg.UserCache.TTL > 0 {
+		var store ports.Cache
+		switch cfg.UserCache.Store {
+		case "memory":
+			store = memory.NewCache(cfg.UserCache.Size)
+		case "redis":
+			redisClient, err = redis.NewClient(context.Background(), cfg.UserCache.RedisURL)
+			if err != nil {
+				logger.Fatal(context.Background(), "could not connect to Redis", "error", err.Error())
+			}
+			defer redisClient.Close()
+			store, err = redis.NewCache(redisClient, "stress-relief:")
+			if err == nil {
+				store, err = instrumented.NewCache(store, "redis", metrics)
+			}
+			if err != nil {
+				logger.Fatal(context.Background(), "could not create user data cache", "error", err.Error())
+			}
+		}
+		userAPIHandler, err = cache.NewUserDataHandler(userAPIHandler, store, cache.Config{
+			TTL:         cfg.UserCache.TTL,
+			NegativeTTL: cfg.UserCache.NegativeTTL,
+		}, logger)
+		if err != nil {
+			logger.Fatal(context.Background(), "could not create user data cache", "error", err.Error())
+		}
+		userDataCache, _ = userAPIHandler.(ports.CacheStatsReporter)
+		if prometheusMetrics != nil && userDataCache != nil {
+			prometheusMetrics.RegisterCacheStats("user_data", userDataCache)
+		}
+	}
+
+	// Encrypt the stored user data, if keys are configured
+	if cfg.Encryption.Keys != "" {
+		keyring, err := encryption.ParseKeyring(cfg.Encryption.Keys, cfg.Encryption.ActiveKeyID)
+		if err != nil {
+			logger.Fatal(context.Background(), "could not parse encryption keys", "error", err.Error())
+		}
+		userAPIHandler, err = encryption.NewUserDataHandler(userAPIHandler, keyring, logger)
+		if err != nil {
+			logger.Fatal(context.Background(), "could not create encrypted user storage", "error", err.Error())
+		}
+	} else {
+		logger.Warn(context.Background(), "ENCRYPTION_KEYS not set, user data is stored in plaintext")
+	}
+
+	// Create access token verifier. Without a Supabase project only guest
+	// sessions can authenticate.
+	var authVerifiers []ports.AuthPort
+	if supabaseURL := strings.TrimSuffix(cfg.Supabase.URL, "/"); supabaseURL != "" {
+		supabaseVerifier, err := auth.NewSupabaseVerifier(auth.Config{
+			JWTSecret: cfg.Supabase.JWTSecret,
+			JWKSURL:   supabaseURL + "/auth/v1/.well-known/jwks.json",
+			Issuer:    supabaseURL + "/auth/v1",
+			Audience:  "authenticated",
+			Roles:     []string{"authenticated"},
+			ClockSkew: 30 * time.Second,
+		}, logger)
+		if err != nil {
+			logger.Fatal(context.Background(), "could not create auth verifier", "error", err.Error())
+		}
+		authVerifiers = append(authVerifiers, supabaseVerifier)
+	}
+
+	// Create audit log, stored alongside the user data unless configured
+	// otherwise
+	var auditLogger ports.AuditLogger
+	switch cfg.AuditStore() {
+	case "supabase":
+		auditLogger, err = supabaseaudit.NewAuditLogger(supabaseClient, logger)
+	case "postgres":
+		auditLogger, err = postgres.NewAuditLogger(pool, logger)
+	case "sqlite":
+		auditLogger, err = sqlite.NewAuditLogger(db, logger)
+	case "file":
+		auditLogger, err = file.NewAuditLogger(cfg.Audit.Path, logger)
+	}
+	if err == nil && (cfg.AuditStore() == "postgres" || cfg.AuditStore() == "sqlite") {
+		auditLogger, err = instrumented.NewAuditLogger(auditLogger, cfg.AuditStore(), metrics)
+	}
+	if err != nil {
+		logger.Fatal(context.Background(), "could not create audit log", "error", err.Error())
+	}
+
+	// Create export storage, alongside the user data unless configured
+	// otherwise
+	var exportRepository ports.ExportRepository
+	switch cfg.ExportStore() {
+	case "memory":
+		exportRepository = memory.NewExportRepository(int64(cfg.Export.MaxTotalSize) << 20)
+	case "supabase":
+		exportRepository, err = exports.NewExportRepository(supabaseClient, logger)
+	case "postgres":
+		exportRepository, err = postgres.NewExportRepository(pool, logger)
+	case "sqlite":
+		exportRepository, err = sqlite.NewExportRepository(db, logger)
+	}
+	if err == nil && (cfg.ExportStore() == "postgres" || cfg.ExportStore() == "sqlite") {
+		exportRepository, err = instrumented.NewExportRepository(exportRepository, cfg.ExportStore(), metrics)
+	}
+	if err != nil {
+		logger.Fatal(context.Background(), "could not create export storage", "error", err.Error())
+	}
+
+	// Identities are managed by Supabase Auth, when it is used
+	identityProvider := memory.NewIdentityProvider()
+	if supabaseClient != nil {
+		identityProvider, err = identity.NewIdentityProvider(supabaseClient, logger)
+		if err != nil {
+			logger.Fatal(context.Background(), "could not create identity provider", "error", err.Error())
+		}
+	}
+
+	// Dependencies checked by the readiness probe
+	var healthCheckers []ports.HealthChecker
+	if checker, ok := openaiAdapter.(ports.HealthChecker); ok {
+		healthCheckers = append(healthCheckers, checker)
+	}
+	if supabaseClient != nil {
+		healthCheckers = append(healthCheckers, supabaseClient)
+	}
+	if pool != nil {
+		checker, err := postgres.NewHealthChecker(pool)
+		if err != nil {
+			logger.Fatal(context.Background(), "could not create database health checker", "error", err.Error())
+		}
+		healthCheckers = append(healthCheckers, checker)
+	}
+	if db != nil {
+		checker, err := sqlite.NewHealthChecker(db)
+		if err != nil {
+			logger.Fatal(context.Background(), "could not create database health checker", "error", err.Error())
+		}
+		healthCheckers = append(healthCheckers, checker)
+	}
+	if redisClient != nil {
+		checker, err := redis.NewHealthChecker(redisClient)
+		if err != nil {
+			logger.Fatal(context.Background(), "could not create Redis health checker", "error", err.Error())
+		}
+		healthCheckers = append(healthCheckers, checker)
+	}
 
 	// Initialize application services
-	chatService := chat.NewChatService(openaiAdapter, logger, userAPIHandler)
+	healthService := health.NewHealthService(health.Config{
+		Version:  version,
+		Commit:   buildCommit(),
+		CacheTTL: cfg.Health.CheckTTL,
+		Timeout:  cfg.Health.CheckTimeout,
+	}, healthCheckers, logger)
+	quotaService := quota.NewQuotaService(cfg.Quota.FreeDailyMessages, logger, usageRepository, userAPIHandler)
+	chatService := chat.NewChatService(openaiAdapter, logger, userAPIHandler, quotaService, auditLogger, tombstoneRepository, metrics, tracer,
+		safety.NewKeywordClassifier(), safetyEvents)
+
+	exportService := export.NewExportService(export.Config{
+		MaxPerUser: cfg.Export.MaxPerUser,
+		MaxSize:    int64(cfg.Export.MaxSize) << 20,
+	}, auditLogger, openaiAdapter, exportRepository, logger, userAPIHandler)
+	adminService := admin.NewAdminService(openaiAdapter, logger, quotaService, safetyEvents, userAPIHandler)
+
+	accountService := account.NewAccountService(cfg.Account.DeletionReceiptSecret, auditLogger, openaiAdapter, exportService,
+		identityProvider, logger, quotaService, safetyEvents, tombstoneRepository, userAPIHandler)
+
+	retentionService := retention.NewRetentionService(retention.Config{
+		Retention: time.Duration(cfg.Retention.Days) * 24 * time.Hour,
+		Interval:  cfg.Retention.Interval,
+		DryRun:    cfg.Retention.DryRun,
+	}, auditLogger, openaiAdapter, logger, userAPIHandler)
+
+	// Guest sessions are only enabled when a secret to sign their tokens is set
+	var sessionService ports.SessionService
+	if cfg.Guest.TokenSecret != "" {
+		guestTokens, err := auth.NewGuestTokenProvider(cfg.Guest.TokenSecret, cfg.Guest.TokenTTL, logger)
+		if err != nil {
+			logger.Fatal(context.Background(), "could not create guest token provider", "error", err.Error())
+		}
+		authVerifiers = append(authVerifiers, guestTokens)
+		sessionService = session.NewSessionService(openaiAdapter, guestTokens, logger, tombstoneRepository, userAPIHandler)
+	}
+	// Deleted accounts and linked guest sessions are rejected
+	authVerifier, err := auth.NewTombstoneCheck(auth.NewChain(authVerifiers...), tombstoneRepository, logger)
+	if err != nil {
+		logger.Fatal(context.Background(), "could not create auth verifier", "error", err.Error())
+	}
+
+	// Rate limits are only shared across instances by a shared store
+	rateLimitStore := ratelimit.NewMemoryStore()
+	if cfg.RateLimits.Store == "postgres" {
+		rateLimitStore, err = postgres.NewRateLimitStore(pool, logger)
+		if err == nil {
+			rateLimitStore, err = instrumented.NewRateLimitStore(rateLimitStore, "postgres", metrics)
+		}
+		if err != nil {
+			logger.Fatal(context.Background(), "could not create rate limit store", "error", err.Error())
+		}
+	}
 
 	// Setup HTTP server
-	server := http.New()
+	server := http.New(logger, http.ServerConfig{
+		ProxyHeader:    cfg.Server.ProxyHeader,
+		TrustedProxies: cfg.Server.TrustedProxies,
+	})
 	server.Use(cors.New())
 
 	// Initialize HTTP handlers
-	httpHandler := http.NewHandler(chatService, logger)
+	httpHandler := http.NewHandler(http.Services{
+		Account:        accountService,
+		Admin:          adminService,
+		Audit:          auditLogger,
+		Auth:           authVerifier,
+		Chat:           chatService,
+		Export:         exportService,
+		Health:         healthService,
+		Metrics:        metrics,
+		Quota:          quotaService,
+		RateLimitStore: rateLimitStore,
+		Retention:      retentionService,
+		Session:        sessionService,
+		Tracer:         tracer,
+		UserDataCache:  userDataCache,
+		LogLevel:       logger,
+	}, logger, http.Config{
+		AdminAPIKey:       cfg.Server.AdminAPIKey,
+		AdminRole:         cfg.Server.AdminRole,
+		AuthWebhookSecret: cfg.Server.AuthWebhookSecret,
+		ExportWait:        5 * time.Second,
+		LegacySunset:      cfg.API.LegacySunset,
+		RateLimits: map[string]domain.RateLimit{
+			http.RouteGroupMessages: cfg.RateLimits.Messages,
+			http.RouteGroupAdmin:    cfg.RateLimits.Admin,
+			http.RouteGroupMe:       cfg.RateLimits.Me,
+			http.RouteGroupPublic:   cfg.RateLimits.Public,
+			http.RouteGroupSessions: cfg.RateLimits.Sessions,
+		},
+		V1Deprecation: v1Deprecation(cfg.API),
+	})
 	httpHandler.SetupRoutes(server.App)
 
 	go func() {
-		port, err := strconv.Atoi(os.Getenv("PORT"))
-		if err != nil {
-			logger.Fatal(context.Background(), "could not parse port", "error", err.Error())
-		}
-		fmt.Println("Listening on port", port)
-		err = server.Listen(fmt.Sprintf(":%d", port))
+		fmt.Println("Listening on port", cfg.Server.Port)
+		err := server.Listen(fmt.Sprintf(":%d", cfg.Server.Port))
 		if err != nil {
 			panic(fmt.Sprintf("http server error: %s", err))
 		}
 	}()
 
-	gracefulShutdown(server)
-}
+	// Metrics are kept off the public listener, for the scraper only
+	var metricsServer *fiber.App
+	if prometheusMetrics != nil {
+		metricsServer = fiber.New(fiber.Config{DisableStartupMessage: true})
+		metricsServer.Get("/metrics", adaptor.HTTPHandler(prometheusMetrics.Handler()))
+		go func() {
+			err := metricsServer.Listen(fmt.Sprintf(":%d", cfg.Metrics.Port))
+			if err != nil {
+				panic(fmt.Sprintf("metrics server error: %s", err))
+			}
+		}()
+	}
+	// Start the data retention scheduler
+	retentionCtx, stopRetention := context.WithCancel(context.Background())
+	go retentionService.Run(retentionCtx)
 
-func gracefulShutdown(fiberServer *http.FiberServer) {
-	// Create context that listens for the interrupt signal from the OS.
+	// Wait for the interrupt signal
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer stop()
-
-	// Listen for the interrupt signal.
 	<-ctx.Done()
-
+	stop()
 	log.Println("shutting down gracefully, press Ctrl+C again to force")
 
-	// The context is used to inform the server it has 5 seconds to finish
-	// the request it is currently handling
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	runCanceller, _ := openaiAdapter.(ports.RunCanceller)
+	shutdownService := shutdown.NewShutdownService(shutdown.Config{
+		ReadinessDelay: cfg.Shutdown.ReadinessDelay,
+		DrainTimeout:   cfg.Shutdown.DrainTimeout,
+		CancelTimeout:  cfg.Shutdown.CancelTimeout,
+	}, chatService, healthService, logger, runCanceller, server)
+	report := shutdownService.Shutdown(context.Background())
+	stopRetention()
+	if metricsServer != nil {
+		if err := metricsServer.ShutdownWithTimeout(5 * time.Second); err != nil {
+			log.Printf("Error shutting down metrics server: %v", err)
+		}
+	}
+
+	// Flush the spans not exported yet
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	if err := fiberServer.ShutdownWithContext(ctx); err != nil {
-		log.Printf("Server forced to shutdown with error: %v", err)
+	if err := tracer.Shutdown(ctx); err != nil {
+		log.Printf("Error shutting down tracer: %v", err)
 	}
 
+	// Orchestrators and alerts tell an unclean shutdown by the exit status
+	if !report.Clean() {
+		logger.Error(context.Background(), "Server exiting abandoning work",
+			"abandoned_messages", report.AbandonedMessages, "cancelled_runs", len(report.CancelledRuns),
+			"errors", len(report.Errors))
+		_ = logger.Close()
+		exitCode = 1
+		return
+	}
 	log.Println("Server exiting")
 }
+
+// buildCommit returns the commit the binary was built from: the one set at
+// build time, the one recorded by the Go toolchain, or the one deployed by
+// Railway.
+func buildCommit() string {
+	if commit != "" {
+		return commit
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	if v := os.Getenv("RAILWAY_GIT_COMMIT_SHA"); v != "" {
+		return v
+	}
+	return "unknown"
+}
+
+// v1Deprecation returns the deprecation of the v1 routes, or nil if they are
+// not deprecated.
+func v1Deprecation(c config.API) *http.Deprecation {
+	if c.V1DeprecatedAt.IsZero() {
+		return nil
+	}
+	return &http.Deprecation{At: c.V1DeprecatedAt, Sunset: c.V1Sunset}
+}
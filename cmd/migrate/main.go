@@ -0,0 +1,35 @@
+// Command migrate applies the PostgreSQL schema migrations to the database at
+// DATABASE_URL.
+package main
+
+import (
+	"context"
+	"log"
+	"stress-relief-ai-chat-back/internal/adapters/postgres"
+	"stress-relief-ai-chat-back/internal/adapters/zap"
+	"stress-relief-ai-chat-back/internal/config"
+)
+
+func main() {
+	cfg, err := config.Load(config.Options{EnvFile: ".env"})
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%s", err)
+	}
+
+	logger, err := zap.NewLogger("production")
+	if err != nil {
+		log.Fatalf("Error initializing logger: %s", err)
+	}
+	ctx := context.Background()
+
+	pool, err := postgres.NewPool(ctx, cfg.Storage.DatabaseURL)
+	if err != nil {
+		logger.Fatal(ctx, "could not connect to database", "error", err.Error())
+	}
+	defer pool.Close()
+
+	if err := postgres.Migrate(ctx, pool, logger); err != nil {
+		logger.Fatal(ctx, "migration failed", "error", err.Error())
+	}
+	logger.Info(ctx, "database is up to date")
+}
@@ -0,0 +1,72 @@
+// Command rotatekeys re-encrypts the stored user data with the active
+// encryption key. It can run while the service is up, as long as the service
+// has both the old and the new keys in its keyring.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"stress-relief-ai-chat-back/internal/adapters/encryption"
+	"stress-relief-ai-chat-back/internal/adapters/noop"
+	"stress-relief-ai-chat-back/internal/adapters/postgres"
+	"stress-relief-ai-chat-back/internal/adapters/sqlite"
+	"stress-relief-ai-chat-back/internal/adapters/supabase"
+	"stress-relief-ai-chat-back/internal/adapters/supabase/users"
+	"stress-relief-ai-chat-back/internal/adapters/zap"
+	"stress-relief-ai-chat-back/internal/config"
+	"stress-relief-ai-chat-back/internal/ports"
+)
+
+func main() {
+	cfg, err := config.Load(config.Options{EnvFile: ".env"})
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%s", err)
+	}
+
+	logger, err := zap.NewLogger("production")
+	if err != nil {
+		log.Fatalf("Error initializing logger: %s", err)
+	}
+	ctx := context.Background()
+
+	keyring, err := encryption.ParseKeyring(cfg.Encryption.Keys, cfg.Encryption.ActiveKeyID)
+	if err != nil {
+		logger.Fatal(ctx, "could not parse encryption keys", "error", err.Error())
+	}
+
+	var userAPIHandler ports.UserDataAPIHandler
+	switch driver := cfg.Storage.Driver; driver {
+	case "supabase":
+		client, cerr := supabase.NewClient(cfg.Supabase.APIKey, cfg.Supabase.URL, cfg.Supabase.Timeout, logger, noop.NewMetrics(), noop.NewTracer())
+		if cerr != nil {
+			logger.Fatal(ctx, "could not create Supabase client", "error", cerr.Error())
+		}
+		userAPIHandler, err = users.NewUserAPIHandler(client, logger)
+	case "postgres":
+		pool, perr := postgres.NewPool(ctx, cfg.Storage.DatabaseURL)
+		if perr != nil {
+			logger.Fatal(ctx, "could not connect to database", "error", perr.Error())
+		}
+		defer pool.Close()
+		userAPIHandler, err = postgres.NewUserDataRepository(pool, logger)
+	case "sqlite":
+		db, oerr := sqlite.Open(ctx, cfg.Storage.SQLitePath)
+		if oerr != nil {
+			logger.Fatal(ctx, "could not open database", "error", oerr.Error())
+		}
+		defer db.Close()
+		userAPIHandler, err = sqlite.NewUserDataRepository(db, logger)
+	default:
+		err = fmt.Errorf("unknown storage driver %q", driver)
+	}
+	if err != nil {
+		logger.Fatal(ctx, "could not create user storage", "error", err.Error())
+	}
+
+	rotated, err := encryption.Rotate(ctx, userAPIHandler, keyring, logger)
+	if err != nil {
+		logger.Fatal(ctx, "key rotation failed", "rotated", rotated, "error", err.Error())
+	}
+	logger.Info(ctx, "key rotation finished", "active_key_id", keyring.ActiveKeyID(), "rotated", rotated)
+}
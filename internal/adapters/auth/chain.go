@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+)
+
+// errUnrecognized is wrapped by the errors of the verifiers of this package
+// for tokens they don't issue, such as guest tokens for the Supabase verifier,
+// so the chain reports the error of the verifier the token is meant for.
+var errUnrecognized = errors.New("token not recognized")
+
+type chain []ports.AuthPort
+
+// NewChain creates an AuthPort that accepts a token if any of verifiers does,
+// trying them in order. A rejected token is answered with the error of the
+// first verifier that recognized it, or of the last one if none did.
+func NewChain(verifiers ...ports.AuthPort) ports.AuthPort {
+	return chain(verifiers)
+}
+
+func (ch chain) Authenticate(ctx context.Context, token string) (*domain.Principal, error) {
+	err := fmt.Errorf("%w: no verifier configured", domain.ErrUnauthorized)
+	var recognized error
+	for _, v := range ch {
+		var principal *domain.Principal
+		principal, err = v.Authenticate(ctx, token)
+		if err == nil {
+			return principal, nil
+		}
+		if recognized == nil && !errors.Is(err, errUnrecognized) {
+			recognized = err
+		}
+	}
+	if recognized != nil {
+		return nil, recognized
+	}
+	return nil, err
+}
+
+// unverifiedIssuer returns the "iss" claim of token without verifying it, to
+// tell which verifier the token is meant for. It returns an empty string if
+// the token can't be parsed.
+func unverifiedIssuer(token string) string {
+	var c jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &c); err != nil {
+		return ""
+	}
+	return c.Issuer
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/golang-jwt/jwt/v4"
+	"stress-relief-ai-chat-back/internal/domain"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChainReportsErrorOfRecognizingVerifier(t *testing.T) {
+	server := newJWKSServer(t)
+	private, public := rsaKey(t, "key")
+	server.setKeys(public)
+	supabase := newTestVerifier(t, server.URL)
+	guests, err := NewGuestTokenProvider("guest-secret", time.Hour, testLogger{})
+	if err != nil {
+		t.Fatalf("NewGuestTokenProvider: %v", err)
+	}
+	ch := NewChain(supabase, guests)
+	ctx := context.Background()
+
+	guestToken, _, err := guests.Issue(ctx, "guest", "client")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if principal, err := ch.Authenticate(ctx, guestToken); err != nil || principal.Role != domain.RoleGuest {
+		t.Fatalf("Authenticate a guest token: got %+v, %v", principal, err)
+	}
+
+	expired := validClaims()
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+	_, err = ch.Authenticate(ctx, sign(t, jwt.SigningMethodHS256, "", []byte(testSecret), expired))
+	if !errors.Is(err, domain.ErrUnauthorized) || !strings.Contains(err.Error(), "expired") {
+		t.Fatalf("Authenticate an expired Supabase token: got %v, want the expiration error", err)
+	}
+
+	// The key set was not fetched yet
+	server.setFailing(true)
+	_, err = ch.Authenticate(ctx, sign(t, jwt.SigningMethodRS256, "key", private, validClaims()))
+	if !errors.Is(err, domain.ErrUnavailable) {
+		t.Fatalf("Authenticate while the JWKS endpoint fails: got %v, want ErrUnavailable", err)
+	}
+
+	_, err = ch.Authenticate(ctx, "not a token")
+	if !errors.Is(err, domain.ErrUnauthorized) {
+		t.Fatalf("Authenticate a malformed token: got %v, want ErrUnauthorized", err)
+	}
+}
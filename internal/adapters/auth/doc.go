@@ -0,0 +1,3 @@
+// Package auth provides an implementation of the ports.AuthPort interface that
+// verifies access tokens issued by Supabase Auth.
+package auth
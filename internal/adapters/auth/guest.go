@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+// guestIssuer is the "iss" claim of the guest session tokens issued by this
+// service, which keeps them apart from Supabase tokens.
+const guestIssuer = "stress-relief-ai-chat-back/guest"
+
+// guestClaims are the claims of guest session tokens.
+type guestClaims struct {
+	claims
+	// Client is a keyed hash of the client that created the session, so the
+	// token doesn't carry its IP address.
+	Client string `json:"client,omitempty"`
+}
+
+type guestTokens struct {
+	secret []byte
+	ttl    time.Duration
+	logger ports.Logger
+	parser *jwt.Parser
+	now    func() time.Time
+}
+
+// NewGuestTokenProvider creates a GuestTokenProvider that issues HS256 tokens
+// signed with secret and valid for ttl.
+func NewGuestTokenProvider(secret string, ttl time.Duration, logger ports.Logger) (ports.GuestTokenProvider, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("secret can't be empty")
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("ttl must be positive")
+	}
+	if logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	return &guestTokens{
+		secret: []byte(secret),
+		ttl:    ttl,
+		logger: logger,
+		parser: jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()})),
+		now:    time.Now,
+	}, nil
+}
+
+func (g *guestTokens) Issue(ctx context.Context, userID, clientKey string) (string, time.Time, error) {
+	if userID == "" {
+		return "", time.Time{}, fmt.Errorf("userID can't be empty")
+	}
+	now := g.now()
+	expiresAt := now.Add(g.ttl)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, guestClaims{
+		claims: claims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    guestIssuer,
+				Subject:   userID,
+				Audience:  jwt.ClaimStrings{domain.RoleGuest},
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(expiresAt),
+			},
+			Role:        domain.RoleGuest,
+			IsAnonymous: true,
+		},
+		Client: g.clientHash(clientKey),
+	})
+	signed, err := token.SignedString(g.secret)
+	if err != nil {
+		g.logger.Error(ctx, "Error signing guest token", "error", err)
+		return "", time.Time{}, fmt.Errorf("error signing guest token: %w", err)
+	}
+	return signed, expiresAt, nil
+}
+
+func (g *guestTokens) Authenticate(ctx context.Context, token string) (*domain.Principal, error) {
+	if issuer := unverifiedIssuer(token); issuer != guestIssuer {
+		return nil, fmt.Errorf("%w: %w: not a guest token", domain.ErrUnauthorized, errUnrecognized)
+	}
+
+	var c guestClaims
+	_, err := g.parser.ParseWithClaims(token, &c, func(t *jwt.Token) (interface{}, error) {
+		return g.secret, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrUnauthorized, err.Error())
+	}
+	if c.Issuer != guestIssuer || c.Role != domain.RoleGuest || c.Subject == "" {
+		return nil, fmt.Errorf("%w: not a guest token", domain.ErrUnauthorized)
+	}
+
+	principal := &domain.Principal{
+		UserID:      c.Subject,
+		Role:        domain.RoleGuest,
+		IsAnonymous: true,
+		ExpiresAt:   c.ExpiresAt.Time,
+	}
+	if c.Client != "" {
+		principal.QuotaKey = "guest:" + c.Client
+	}
+	return principal, nil
+}
+
+// clientHash returns a keyed hash of clientKey, or an empty string when there
+// is no client key.
+func (g *guestTokens) clientHash(clientKey string) string {
+	if clientKey == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write([]byte("client:" + clientKey))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"stress-relief-ai-chat-back/internal/domain"
+	"sync"
+	"time"
+)
+
+// minRefreshInterval bounds how often the key set is fetched again because of
+// an unknown key id, so tokens with made up kids can't be used to hammer the
+// JWKS endpoint.
+const minRefreshInterval = 30 * time.Second
+
+// failureBackoff is how long after a failed fetch the key set is not fetched
+// again, so lookups fail fast instead of each waiting for an unavailable JWKS
+// endpoint.
+const failureBackoff = 10 * time.Second
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// jwks caches the public keys of a JSON Web Key Set. The set is fetched again
+// when the cache expires or when a token is signed with an unknown key, which
+// is what happens after the signing keys are rotated.
+type jwks struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+	// failure is the error of the last fetch, at failedAt, if it failed
+	failure  error
+	failedAt time.Time
+	// refreshing is the fetch in progress, if any, which concurrent lookups
+	// wait for instead of fetching the set again
+	refreshing *jwksRefresh
+
+	now func() time.Time
+}
+
+// jwksRefresh is a fetch of the key set. err is set before done is closed.
+type jwksRefresh struct {
+	done chan struct{}
+	err  error
+}
+
+func newJWKS(url string, ttl time.Duration, client *http.Client) *jwks {
+	return &jwks{
+		url:    url,
+		ttl:    ttl,
+		client: client,
+		now:    time.Now,
+	}
+}
+
+// key returns the public key identified by kid. It returns an error wrapping
+// domain.ErrUnavailable if the key set can't be fetched.
+func (j *jwks) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	j.mu.Lock()
+	now := j.now()
+	since := now.Sub(j.fetchedAt)
+	key, ok := j.keys[kid]
+	failure := j.failure
+	if now.Sub(j.failedAt) >= failureBackoff {
+		failure = nil
+	}
+	j.mu.Unlock()
+
+	if ok && since < j.ttl {
+		return key, nil
+	}
+
+	// Refresh when the cache expired, or when the kid is unknown and the keys
+	// may have been rotated since the last fetch
+	if since >= j.ttl || (!ok && since >= minRefreshInterval) {
+		err := failure
+		if err == nil {
+			err = j.refresh(ctx)
+		}
+		if err != nil {
+			if ok {
+				// Keep using the stale key rather than failing every request
+				// while the JWKS endpoint is unavailable
+				return key, nil
+			}
+			return nil, fmt.Errorf("%w: could not fetch signing keys: %s", domain.ErrUnavailable, err.Error())
+		}
+		j.mu.Lock()
+		key, ok = j.keys[kid]
+		j.mu.Unlock()
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+// refresh fetches the key set, or waits for the fetch already in progress.
+// The lock is not held during the fetch, so lookups of cached keys are never
+// blocked by a slow JWKS endpoint.
+func (j *jwks) refresh(ctx context.Context) error {
+	j.mu.Lock()
+	r := j.refreshing
+	if r == nil {
+		r = &jwksRefresh{done: make(chan struct{})}
+		j.refreshing = r
+		// The fetch is shared by all the waiting lookups, so it must not be
+		// canceled along with the request that started it
+		go func() {
+			keys, err := j.fetch(context.WithoutCancel(ctx))
+			j.mu.Lock()
+			if err == nil {
+				j.keys, j.fetchedAt, j.failure = keys, j.now(), nil
+			} else {
+				j.failure, j.failedAt = err, j.now()
+			}
+			j.refreshing = nil
+			j.mu.Unlock()
+			r.err = err
+			close(r.done)
+		}()
+	}
+	j.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (j *jwks) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	res, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching jwks: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error response from jwks endpoint: %s", res.Status)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading jwks: %w", err)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(body, &set); err != nil {
+		return nil, fmt.Errorf("error unmarshalling jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			// Skip keys we can't use instead of rejecting the whole set
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"net/http"
+	"slices"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+// Config holds the settings used to verify Supabase access tokens.
+type Config struct {
+	// JWTSecret is the project's JWT secret, used to verify HS256 tokens.
+	// Leave empty to reject symmetrically signed tokens.
+	JWTSecret string
+	// JWKSURL is the URL of the project's JSON Web Key Set, used to verify
+	// RS256 and ES256 tokens. Leave empty to reject asymmetrically signed
+	// tokens.
+	JWKSURL string
+	// Issuer is the expected "iss" claim, usually <project url>/auth/v1.
+	// It is not checked when empty.
+	Issuer string
+	// Audience is the expected "aud" claim. It is not checked when empty.
+	Audience string
+	// Roles are the accepted values of the "role" claim. Any role is accepted
+	// when empty.
+	Roles []string
+	// ClockSkew is the tolerance applied when checking exp, nbf and iat.
+	ClockSkew time.Duration
+	// JWKSCacheTTL is how long fetched signing keys are trusted before the
+	// key set is fetched again.
+	JWKSCacheTTL time.Duration
+}
+
+type claims struct {
+	jwt.RegisteredClaims
+	Email       string      `json:"email"`
+	Role        string      `json:"role"`
+	SessionID   string      `json:"session_id"`
+	IsAnonymous bool        `json:"is_anonymous"`
+	AppMetadata appMetadata `json:"app_metadata"`
+}
+
+// appMetadata holds the claims only the service role can set on a Supabase
+// user. Application roles are read from either "role" or "roles".
+type appMetadata struct {
+	Role  string   `json:"role"`
+	Roles []string `json:"roles"`
+}
+
+func (m appMetadata) appRoles() []string {
+	roles := slices.Clone(m.Roles)
+	if m.Role != "" && !slices.Contains(roles, m.Role) {
+		roles = append(roles, m.Role)
+	}
+	return roles
+}
+
+type verifier struct {
+	config Config
+	keys   *jwks
+	logger ports.Logger
+	parser *jwt.Parser
+	now    func() time.Time
+}
+
+// NewSupabaseVerifier creates an AuthPort that verifies Supabase access tokens
+// signed either with the project's JWT secret or with one of the keys of its
+// JWKS.
+func NewSupabaseVerifier(config Config, logger ports.Logger) (ports.AuthPort, error) {
+	if config.JWTSecret == "" && config.JWKSURL == "" {
+		return nil, fmt.Errorf("either JWTSecret or JWKSURL must be set")
+	}
+	if logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	if config.JWKSCacheTTL <= 0 {
+		config.JWKSCacheTTL = 10 * time.Minute
+	}
+
+	var methods []string
+	if config.JWTSecret != "" {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	v := &verifier{
+		config: config,
+		logger: logger,
+		now:    time.Now,
+	}
+	if config.JWKSURL != "" {
+		methods = append(methods, jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg())
+		v.keys = newJWKS(config.JWKSURL, config.JWKSCacheTTL, &http.Client{Timeout: 5 * time.Second})
+	}
+	v.parser = jwt.NewParser(jwt.WithValidMethods(methods), jwt.WithoutClaimsValidation())
+
+	return v, nil
+}
+
+func (v *verifier) Authenticate(ctx context.Context, token string) (*domain.Principal, error) {
+	if token == "" {
+		return nil, fmt.Errorf("%w: missing token", domain.ErrUnauthorized)
+	}
+	if issuer := unverifiedIssuer(token); issuer == guestIssuer || (v.config.Issuer != "" && issuer != v.config.Issuer) {
+		return nil, fmt.Errorf("%w: %w: unexpected issuer %q", domain.ErrUnauthorized, errUnrecognized, issuer)
+	}
+
+	var c claims
+	_, err := v.parser.ParseWithClaims(token, &c, func(t *jwt.Token) (interface{}, error) {
+		switch t.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			return []byte(v.config.JWTSecret), nil
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+			kid, _ := t.Header["kid"].(string)
+			if kid == "" {
+				return nil, errors.New("token has no kid header")
+			}
+			return v.keys.key(ctx, kid)
+		default:
+			return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+		}
+	})
+	if errors.Is(err, domain.ErrUnavailable) {
+		v.logger.Warn(ctx, "Could not verify token", "error", err.Error())
+		return nil, fmt.Errorf("%w: %s", domain.ErrUnavailable, err.Error())
+	}
+	if err != nil {
+		v.logger.Debug(ctx, "Could not verify token", "error", err.Error())
+		return nil, fmt.Errorf("%w: %s", domain.ErrUnauthorized, err.Error())
+	}
+
+	if err := v.validate(&c); err != nil {
+		v.logger.Debug(ctx, "Invalid token claims", "error", err.Error())
+		return nil, fmt.Errorf("%w: %s", domain.ErrUnauthorized, err.Error())
+	}
+
+	return &domain.Principal{
+		UserID:      c.Subject,
+		Email:       c.Email,
+		Role:        c.Role,
+		AppRoles:    c.AppMetadata.appRoles(),
+		SessionID:   c.SessionID,
+		IsAnonymous: c.IsAnonymous,
+		ExpiresAt:   c.ExpiresAt.Time,
+	}, nil
+}
+
+// validate checks the registered claims of a token whose signature has already
+// been verified.
+func (v *verifier) validate(c *claims) error {
+	now := v.now()
+	skew := v.config.ClockSkew
+
+	if c.Subject == "" {
+		return errors.New("token has no subject")
+	}
+	if c.ExpiresAt == nil {
+		return errors.New("token has no expiration")
+	}
+	if now.After(c.ExpiresAt.Add(skew)) {
+		return errors.New("token is expired")
+	}
+	if c.NotBefore != nil && now.Before(c.NotBefore.Add(-skew)) {
+		return errors.New("token is not valid yet")
+	}
+	if c.IssuedAt != nil && now.Before(c.IssuedAt.Add(-skew)) {
+		return errors.New("token was issued in the future")
+	}
+	if v.config.Issuer != "" && c.Issuer != v.config.Issuer {
+		return fmt.Errorf("unexpected issuer %q", c.Issuer)
+	}
+	if v.config.Audience != "" && !c.VerifyAudience(v.config.Audience, true) {
+		return errors.New("unexpected audience")
+	}
+	if len(v.config.Roles) > 0 && !slices.Contains(v.config.Roles, c.Role) {
+		return fmt.Errorf("role %q is not allowed", c.Role)
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/golang-jwt/jwt/v4"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"stress-relief-ai-chat-back/internal/domain"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testSecret   = "test-jwt-secret"
+	testIssuer   = "https://project.supabase.co/auth/v1"
+	testAudience = "authenticated"
+)
+
+type testLogger struct{}
+
+func (testLogger) Close() error                                  { return nil }
+func (testLogger) Debug(context.Context, string, ...interface{}) {}
+func (testLogger) Info(context.Context, string, ...interface{})  {}
+func (testLogger) Warn(context.Context, string, ...interface{})  {}
+func (testLogger) Error(context.Context, string, ...interface{}) {}
+func (testLogger) Fatal(context.Context, string, ...interface{}) {}
+
+// jwksServer serves the public keys it holds as a JSON Web Key Set, counting
+// the fetches. It answers with an error while failing is set.
+type jwksServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	keys    []jsonWebKey
+	fetches int
+	failing bool
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	t.Helper()
+	s := &jwksServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.fetches++
+		if s.failing {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) setKeys(keys ...jsonWebKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *jwksServer) setFailing(failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failing = failing
+}
+
+func (s *jwksServer) fetchCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetches
+}
+
+func encodeBigInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func rsaKey(t *testing.T, kid string) (*rsa.PrivateKey, jsonWebKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return key, jsonWebKey{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		N:   encodeBigInt(key.N),
+		E:   encodeBigInt(big.NewInt(int64(key.E))),
+	}
+}
+
+func ecKey(t *testing.T, kid string) (*ecdsa.PrivateKey, jsonWebKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return key, jsonWebKey{
+		Kty: "EC",
+		Kid: kid,
+		Crv: "P-256",
+		X:   encodeBigInt(key.X),
+		Y:   encodeBigInt(key.Y),
+	}
+}
+
+// validClaims returns the claims of a token accepted by the test verifier.
+func validClaims() claims {
+	now := time.Now()
+	return claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    testIssuer,
+			Subject:   "user",
+			Audience:  jwt.ClaimStrings{testAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+		Role: "authenticated",
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, c jwt.Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, c)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	return signed
+}
+
+func newTestVerifier(t *testing.T, jwksURL string) *verifier {
+	t.Helper()
+	v, err := NewSupabaseVerifier(Config{
+		JWTSecret: testSecret,
+		JWKSURL:   jwksURL,
+		Issuer:    testIssuer,
+		Audience:  testAudience,
+		Roles:     []string{"authenticated"},
+		ClockSkew: 30 * time.Second,
+	}, testLogger{})
+	if err != nil {
+		t.Fatalf("NewSupabaseVerifier: %v", err)
+	}
+	return v.(*verifier)
+}
+
+func TestSupabaseVerifierSigningMethods(t *testing.T) {
+	server := newJWKSServer(t)
+	rsaPrivate, rsaPublic := rsaKey(t, "rsa")
+	ecPrivate, ecPublic := ecKey(t, "ec")
+	server.setKeys(rsaPublic, ecPublic)
+	v := newTestVerifier(t, server.URL)
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"HS256", sign(t, jwt.SigningMethodHS256, "", []byte(testSecret), validClaims()), true},
+		{"RS256", sign(t, jwt.SigningMethodRS256, "rsa", rsaPrivate, validClaims()), true},
+		{"ES256", sign(t, jwt.SigningMethodES256, "ec", ecPrivate, validClaims()), true},
+		{"HS256 with another secret", sign(t, jwt.SigningMethodHS256, "", []byte("other"), validClaims()), false},
+		{"RS256 signed with another key", sign(t, jwt.SigningMethodRS256, "rsa", otherRSA(t), validClaims()), false},
+		{"RS256 without kid", sign(t, jwt.SigningMethodRS256, "", rsaPrivate, validClaims()), false},
+		{"HS512", sign(t, jwt.SigningMethodHS512, "", []byte(testSecret), validClaims()), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := v.Authenticate(context.Background(), tt.token)
+			if tt.ok {
+				if err != nil || principal.UserID != "user" {
+					t.Fatalf("Authenticate: got %+v, %v, want user", principal, err)
+				}
+				return
+			}
+			if !errors.Is(err, domain.ErrUnauthorized) {
+				t.Fatalf("Authenticate: got %v, want ErrUnauthorized", err)
+			}
+		})
+	}
+}
+
+func otherRSA(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, _ := rsaKey(t, "")
+	return key
+}
+
+func TestSupabaseVerifierClaims(t *testing.T) {
+	v := newTestVerifier(t, "")
+
+	tests := []struct {
+		name   string
+		change func(c *claims)
+		ok     bool
+	}{
+		{"valid", func(c *claims) {}, true},
+		{"expired", func(c *claims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) }, false},
+		{"expired within the clock skew", func(c *claims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-10 * time.Second)) }, true},
+		{"no expiration", func(c *claims) { c.ExpiresAt = nil }, false},
+		{"not valid yet", func(c *claims) { c.NotBefore = jwt.NewNumericDate(time.Now().Add(time.Minute)) }, false},
+		{"issued in the future", func(c *claims) { c.IssuedAt = jwt.NewNumericDate(time.Now().Add(time.Minute)) }, false},
+		{"other audience", func(c *claims) { c.Audience = jwt.ClaimStrings{"other"} }, false},
+		{"other issuer", func(c *claims) { c.Issuer = "https://other.supabase.co/auth/v1" }, false},
+		{"other role", func(c *claims) { c.Role = "service_role" }, false},
+		{"no subject", func(c *claims) { c.Subject = "" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validClaims()
+			tt.change(&c)
+			token := sign(t, jwt.SigningMethodHS256, "", []byte(testSecret), c)
+			_, err := v.Authenticate(context.Background(), token)
+			if tt.ok && err != nil {
+				t.Fatalf("Authenticate: got %v, want no error", err)
+			}
+			if !tt.ok && !errors.Is(err, domain.ErrUnauthorized) {
+				t.Fatalf("Authenticate: got %v, want ErrUnauthorized", err)
+			}
+		})
+	}
+}
+
+func TestSupabaseVerifierKeyRotation(t *testing.T) {
+	server := newJWKSServer(t)
+	oldPrivate, oldPublic := rsaKey(t, "old")
+	newPrivate, newPublic := rsaKey(t, "new")
+	server.setKeys(oldPublic)
+	v := newTestVerifier(t, server.URL)
+	now := time.Now()
+	v.keys.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := v.Authenticate(ctx, sign(t, jwt.SigningMethodRS256, "old", oldPrivate, validClaims())); err != nil {
+		t.Fatalf("Authenticate with the old key: %v", err)
+	}
+
+	// The keys are rotated, and the new kid is only looked up once the key
+	// set may be fetched again
+	server.setKeys(oldPublic, newPublic)
+	newToken := sign(t, jwt.SigningMethodRS256, "new", newPrivate, validClaims())
+	if _, err := v.Authenticate(ctx, newToken); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Fatalf("Authenticate with the new key right after a fetch: got %v, want ErrUnauthorized", err)
+	}
+	if got := server.fetchCount(); got != 1 {
+		t.Fatalf("fetches right after a fetch: got %d, want 1", got)
+	}
+
+	now = now.Add(minRefreshInterval)
+	if _, err := v.Authenticate(ctx, newToken); err != nil {
+		t.Fatalf("Authenticate with the new key: %v", err)
+	}
+	if got := server.fetchCount(); got != 2 {
+		t.Fatalf("fetches: got %d, want 2", got)
+	}
+}
+
+func TestSupabaseVerifierRefreshFailure(t *testing.T) {
+	server := newJWKSServer(t)
+	private, public := rsaKey(t, "key")
+	server.setKeys(public)
+	server.setFailing(true)
+	v := newTestVerifier(t, server.URL)
+	now := time.Now()
+	v.keys.now = func() time.Time { return now }
+	ctx := context.Background()
+	token := sign(t, jwt.SigningMethodRS256, "key", private, validClaims())
+
+	for i := 0; i < 2; i++ {
+		if _, err := v.Authenticate(ctx, token); !errors.Is(err, domain.ErrUnavailable) {
+			t.Fatalf("Authenticate #%d while the JWKS endpoint fails: got %v, want ErrUnavailable", i+1, err)
+		}
+	}
+	if got := server.fetchCount(); got != 1 {
+		t.Fatalf("fetches within the backoff: got %d, want 1", got)
+	}
+
+	server.setFailing(false)
+	now = now.Add(failureBackoff)
+	if _, err := v.Authenticate(ctx, token); err != nil {
+		t.Fatalf("Authenticate after the backoff: %v", err)
+	}
+
+	// Once the cache expires, a failing endpoint doesn't reject the tokens
+	// signed with the keys already fetched
+	server.setFailing(true)
+	now = now.Add(v.config.JWKSCacheTTL)
+	if _, err := v.Authenticate(ctx, token); err != nil {
+		t.Fatalf("Authenticate with a stale key: %v", err)
+	}
+}
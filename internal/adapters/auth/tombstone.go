@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+)
+
+type tombstoneCheck struct {
+	next       ports.AuthPort
+	tombstones ports.TombstoneRepository
+	logger     ports.Logger
+}
+
+// NewTombstoneCheck creates an AuthPort that rejects the principals accepted
+// by next whose user has a tombstone: deleted accounts, and guest sessions
+// linked to an account. It is the one place tombstones are checked for the
+// requests of users, whatever route they are sent to.
+func NewTombstoneCheck(next ports.AuthPort, tombstones ports.TombstoneRepository, logger ports.Logger) (ports.AuthPort, error) {
+	r := &tombstoneCheck{
+		next:       next,
+		tombstones: tombstones,
+		logger:     logger,
+	}
+	if r.next == nil {
+		return nil, fmt.Errorf("next can't be nil")
+	}
+	if r.tombstones == nil {
+		return nil, fmt.Errorf("tombstones can't be nil")
+	}
+	if r.logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	return r, nil
+}
+
+func (r *tombstoneCheck) Authenticate(ctx context.Context, token string) (*domain.Principal, error) {
+	principal, err := r.next.Authenticate(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := r.tombstones.Exists(ctx, domain.TombstoneKey(principal.UserID))
+	if err != nil {
+		r.logger.Warn(ctx, "Error checking tombstone", "error", err)
+		return nil, fmt.Errorf("%w: error checking tombstone: %s", domain.ErrUnavailable, err.Error())
+	}
+	if !deleted {
+		return principal, nil
+	}
+	if principal.Role == domain.RoleGuest {
+		return nil, fmt.Errorf("%w: guest session was revoked", domain.ErrUnauthorized)
+	}
+	return nil, domain.ErrAccountDeleted
+}
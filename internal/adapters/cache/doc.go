@@ -0,0 +1,3 @@
+// Package cache provides read-through caching decorators for the repository
+// ports, storing entries in a ports.Cache.
+package cache
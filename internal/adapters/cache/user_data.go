@@ -0,0 +1,205 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// notFound is the cached value of a user without user_data.
+var notFound = []byte("null")
+
+// generationStripes is how many generation counters the users are spread
+// over. Users sharing a counter only skip filling the cache more often.
+const generationStripes = 64
+
+// generation counts the invalidations of the users of a stripe.
+type generation struct {
+	mu    sync.Mutex
+	count uint64
+}
+
+// Config holds the settings of the user data cache.
+type Config struct {
+	// TTL is how long a user_data row is cached. With a cache that is not
+	// shared, it also bounds how long an instance can miss a write made by
+	// another one. With a shared cache, it bounds how long a read on another
+	// instance that raced the write can keep the row from before it cached.
+	TTL time.Duration
+	// NegativeTTL is how long a missing user_data row is cached. Negative
+	// caching is disabled when zero.
+	NegativeTTL time.Duration
+}
+
+type userDataHandler struct {
+	cache  ports.Cache
+	config Config
+	logger ports.Logger
+	next   ports.UserDataAPIHandler
+
+	// generations keep a read that started before a write on this instance
+	// from filling the cache after the write invalidated it.
+	generations [generationStripes]generation
+
+	hits          atomic.Uint64
+	negativeHits  atomic.Uint64
+	misses        atomic.Uint64
+	invalidations atomic.Uint64
+	errors        atomic.Uint64
+}
+
+// NewUserDataHandler wraps next so GetByID is answered from cache when
+// possible. Writes invalidate the entry of the user instead of updating it, as
+// updates only carry the fields to change, and reads that started before a
+// write don't fill the cache after it. Lists are not cached.
+//
+// The returned handler also implements ports.CacheStatsReporter.
+func NewUserDataHandler(next ports.UserDataAPIHandler, cache ports.Cache, config Config, logger ports.Logger) (ports.UserDataAPIHandler, error) {
+	h := &userDataHandler{
+		cache:  cache,
+		config: config,
+		logger: logger,
+		next:   next,
+	}
+	if h.next == nil {
+		return nil, fmt.Errorf("next can't be nil")
+	}
+	if h.cache == nil {
+		return nil, fmt.Errorf("cache can't be nil")
+	}
+	if h.config.TTL <= 0 {
+		return nil, fmt.Errorf("TTL must be positive")
+	}
+	if h.config.NegativeTTL < 0 {
+		return nil, fmt.Errorf("NegativeTTL can't be negative")
+	}
+	if h.logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	return h, nil
+}
+
+func (h *userDataHandler) GetByID(ctx context.Context, userID string) (*domain.UserData, error) {
+	key := userDataKey(userID)
+
+	data, ok, err := h.cache.Get(ctx, key)
+	if err != nil {
+		h.errors.Add(1)
+		h.logger.Warn(ctx, "Error reading user_data from cache", "user_id", userID, "error", err)
+	}
+	if ok {
+		if string(data) == string(notFound) {
+			h.negativeHits.Add(1)
+			return nil, fmt.Errorf("%w: user_data not found", domain.ErrNotFound)
+		}
+		var userData domain.UserData
+		if err := json.Unmarshal(data, &userData); err == nil {
+			h.hits.Add(1)
+			return &userData, nil
+		}
+		// A corrupt entry is treated as a miss and overwritten below
+		h.errors.Add(1)
+	}
+	h.misses.Add(1)
+
+	gen := h.generation(userID)
+	gen.mu.Lock()
+	count := gen.count
+	gen.mu.Unlock()
+
+	userData, err := h.next.GetByID(ctx, userID)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		if h.config.NegativeTTL > 0 {
+			h.fill(ctx, gen, count, key, notFound, h.config.NegativeTTL)
+		}
+		return nil, err
+	case err != nil:
+		return nil, err
+	}
+
+	if data, err := json.Marshal(userData); err == nil {
+		h.fill(ctx, gen, count, key, data, h.config.TTL)
+	}
+	return userData, nil
+}
+
+func (h *userDataHandler) Insert(ctx context.Context, userID string, userData *domain.UserData) error {
+	defer h.invalidate(ctx, userID)
+	return h.next.Insert(ctx, userID, userData)
+}
+
+func (h *userDataHandler) Update(ctx context.Context, userID string, userData *domain.UserData) error {
+	defer h.invalidate(ctx, userID)
+	return h.next.Update(ctx, userID, userData)
+}
+
+func (h *userDataHandler) Patch(ctx context.Context, userID string, patch domain.UserDataPatch) error {
+	defer h.invalidate(ctx, userID)
+	return h.next.Patch(ctx, userID, patch)
+}
+
+func (h *userDataHandler) Delete(ctx context.Context, userID string) error {
+	defer h.invalidate(ctx, userID)
+	return h.next.Delete(ctx, userID)
+}
+
+func (h *userDataHandler) List(ctx context.Context, filter domain.UserDataFilter) ([]domain.UserData, error) {
+	return h.next.List(ctx, filter)
+}
+
+func (h *userDataHandler) CacheStats() domain.CacheStats {
+	return domain.CacheStats{
+		Hits:          h.hits.Load(),
+		NegativeHits:  h.negativeHits.Load(),
+		Misses:        h.misses.Load(),
+		Invalidations: h.invalidations.Load(),
+		Errors:        h.errors.Load(),
+	}
+}
+
+// fill caches what was read from the store, unless the user was invalidated
+// since the read started, i.e. since the generation was at count.
+func (h *userDataHandler) fill(ctx context.Context, gen *generation, count uint64, key string, data []byte, ttl time.Duration) {
+	gen.mu.Lock()
+	defer gen.mu.Unlock()
+	if gen.count != count {
+		return
+	}
+	if err := h.cache.Set(ctx, key, data, ttl); err != nil {
+		h.errors.Add(1)
+		h.logger.Warn(ctx, "Error writing user_data to cache", "key", key, "error", err)
+	}
+}
+
+// invalidate drops the cached entry of a user. It also runs when the write
+// fails, as the store may have applied it anyway.
+func (h *userDataHandler) invalidate(ctx context.Context, userID string) {
+	h.invalidations.Add(1)
+	gen := h.generation(userID)
+	gen.mu.Lock()
+	defer gen.mu.Unlock()
+	gen.count++
+	if err := h.cache.Delete(ctx, userDataKey(userID)); err != nil {
+		h.errors.Add(1)
+		h.logger.Error(ctx, "Error invalidating cached user_data", "user_id", userID, "error", err)
+	}
+}
+
+// generation returns the invalidation counter of the stripe of a user.
+func (h *userDataHandler) generation(userID string) *generation {
+	f := fnv.New32a()
+	f.Write([]byte(userID))
+	return &h.generations[f.Sum32()%generationStripes]
+}
+
+func userDataKey(userID string) string {
+	return "user_data:" + userID
+}
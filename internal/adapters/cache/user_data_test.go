@@ -0,0 +1,178 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/adapters/memory"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"testing"
+	"time"
+)
+
+type testLogger struct{}
+
+func (testLogger) Close() error                                  { return nil }
+func (testLogger) Debug(context.Context, string, ...interface{}) {}
+func (testLogger) Info(context.Context, string, ...interface{})  {}
+func (testLogger) Warn(context.Context, string, ...interface{})  {}
+func (testLogger) Error(context.Context, string, ...interface{}) {}
+func (testLogger) Fatal(context.Context, string, ...interface{}) {}
+
+// countingStorage holds the user data of a single user, counting the reads.
+// Only the methods used by the tests are implemented.
+type countingStorage struct {
+	ports.UserDataAPIHandler
+	entry *domain.UserData
+	reads int
+	// afterRead, if set, runs after a read, before its result is returned.
+	afterRead func()
+}
+
+func (s *countingStorage) GetByID(_ context.Context, _ string) (*domain.UserData, error) {
+	s.reads++
+	var entry *domain.UserData
+	if s.entry != nil {
+		copied := *s.entry
+		entry = &copied
+	}
+	if s.afterRead != nil {
+		s.afterRead()
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("%w: user_data not found", domain.ErrNotFound)
+	}
+	return entry, nil
+}
+
+func (s *countingStorage) Insert(_ context.Context, _ string, userData *domain.UserData) error {
+	copied := *userData
+	s.entry = &copied
+	return nil
+}
+
+func (s *countingStorage) Patch(_ context.Context, _ string, patch domain.UserDataPatch) error {
+	if s.entry == nil {
+		return fmt.Errorf("%w: user_data not found", domain.ErrNotFound)
+	}
+	if patch.ThreadID != nil {
+		s.entry.ThreadID = patch.ThreadID
+	}
+	return nil
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func newTestHandler(t *testing.T, storage *countingStorage) *userDataHandler {
+	t.Helper()
+	h, err := NewUserDataHandler(storage, memory.NewCache(10), Config{TTL: time.Minute, NegativeTTL: time.Minute}, testLogger{})
+	if err != nil {
+		t.Fatalf("NewUserDataHandler: %v", err)
+	}
+	return h.(*userDataHandler)
+}
+
+func threadID(t *testing.T, h ports.UserDataAPIHandler) string {
+	t.Helper()
+	userData, err := h.GetByID(context.Background(), "user")
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if userData.ThreadID == nil {
+		return ""
+	}
+	return *userData.ThreadID
+}
+
+func TestUserDataHandlerCachesReads(t *testing.T) {
+	storage := &countingStorage{entry: &domain.UserData{ThreadID: ptr("thread")}}
+	h := newTestHandler(t, storage)
+
+	for i := 0; i < 2; i++ {
+		if got := threadID(t, h); got != "thread" {
+			t.Fatalf("GetByID #%d: got thread %q, want thread", i+1, got)
+		}
+	}
+	if storage.reads != 1 {
+		t.Fatalf("reads: got %d, want 1", storage.reads)
+	}
+
+	// The cached entry is decoded anew on each hit
+	userData, _ := h.GetByID(context.Background(), "user")
+	*userData.ThreadID = "changed"
+	if got := threadID(t, h); got != "thread" {
+		t.Fatalf("GetByID after changing a result: got thread %q, want thread", got)
+	}
+
+	if stats := h.CacheStats(); stats.Hits != 3 || stats.Misses != 1 {
+		t.Fatalf("CacheStats: got %+v, want 3 hits and 1 miss", stats)
+	}
+}
+
+func TestUserDataHandlerCachesMissingEntries(t *testing.T) {
+	storage := &countingStorage{}
+	h := newTestHandler(t, storage)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := h.GetByID(ctx, "user"); !errors.Is(err, domain.ErrNotFound) {
+			t.Fatalf("GetByID #%d: got %v, want ErrNotFound", i+1, err)
+		}
+	}
+	if storage.reads != 1 {
+		t.Fatalf("reads: got %d, want 1", storage.reads)
+	}
+
+	if err := h.Insert(ctx, "user", &domain.UserData{ThreadID: ptr("thread")}); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if got := threadID(t, h); got != "thread" {
+		t.Fatalf("GetByID after Insert: got thread %q, want thread", got)
+	}
+}
+
+func TestUserDataHandlerInvalidatesOnWrite(t *testing.T) {
+	storage := &countingStorage{entry: &domain.UserData{ThreadID: ptr("old")}}
+	h := newTestHandler(t, storage)
+	ctx := context.Background()
+
+	threadID(t, h)
+	if err := h.Patch(ctx, "user", domain.UserDataPatch{ThreadID: ptr("new")}); err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+	if got := threadID(t, h); got != "new" {
+		t.Fatalf("GetByID after Patch: got thread %q, want new", got)
+	}
+	if storage.reads != 2 {
+		t.Fatalf("reads: got %d, want 2", storage.reads)
+	}
+}
+
+// TestUserDataHandlerReadRacingWrite checks that a read that started before a
+// write doesn't fill the cache with what it read once the write invalidated
+// it.
+func TestUserDataHandlerReadRacingWrite(t *testing.T) {
+	storage := &countingStorage{entry: &domain.UserData{ThreadID: ptr("old")}}
+	h := newTestHandler(t, storage)
+	ctx := context.Background()
+
+	storage.afterRead = func() {
+		storage.afterRead = nil
+		if err := h.Patch(ctx, "user", domain.UserDataPatch{ThreadID: ptr("new")}); err != nil {
+			t.Fatalf("Patch: %v", err)
+		}
+	}
+	if got := threadID(t, h); got != "old" {
+		t.Fatalf("GetByID racing Patch: got thread %q, want old", got)
+	}
+
+	if got := threadID(t, h); got != "new" {
+		t.Fatalf("GetByID after Patch: got thread %q, want new", got)
+	}
+	if storage.reads != 2 {
+		t.Fatalf("reads: got %d, want 2", storage.reads)
+	}
+}
@@ -0,0 +1,11 @@
+// Package encryption provides an envelope encryption layer for the data stored
+// through ports.UserDataAPIHandler, so the underlying storage only ever sees
+// ciphertext.
+//
+// Every entry is encrypted with its own random data key using AES-256-GCM. The
+// data key is in turn encrypted with a key encryption key from the Keyring and
+// stored next to the ciphertext, together with the id of that key. Rotating
+// the key encryption key only requires re-encrypting the entries with the new
+// active key, which can happen while the service is running as long as the
+// old key stays in the keyring until it is done.
+package encryption
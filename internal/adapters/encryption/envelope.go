@@ -0,0 +1,105 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// envelopePrefix marks encrypted values, so values written before encryption
+// was enabled can still be read.
+const envelopePrefix = "enc:v1:"
+
+// seal encrypts plaintext with a new data key, which is wrapped with the key
+// encryption key kek. aad binds the ciphertext to its context, e.g. the row it
+// is stored in.
+func seal(kek []byte, plaintext, aad string) (string, error) {
+	dek := make([]byte, keySize)
+	if _, err := rand.Read(dek); err != nil {
+		return "", fmt.Errorf("could not generate data key: %w", err)
+	}
+
+	wrapped, err := gcmSeal(kek, dek, []byte(aad))
+	if err != nil {
+		return "", fmt.Errorf("could not wrap data key: %w", err)
+	}
+	ciphertext, err := gcmSeal(dek, []byte(plaintext), []byte(aad))
+	if err != nil {
+		return "", fmt.Errorf("could not encrypt: %w", err)
+	}
+
+	return envelopePrefix + base64.RawURLEncoding.EncodeToString(wrapped) + "." +
+		base64.RawURLEncoding.EncodeToString(ciphertext), nil
+}
+
+// open decrypts a value produced by seal.
+func open(kek []byte, value, aad string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, envelopePrefix)
+	if !ok {
+		return "", errors.New("value is not encrypted")
+	}
+	encodedDEK, encodedCiphertext, ok := strings.Cut(encoded, ".")
+	if !ok {
+		return "", errors.New("malformed envelope")
+	}
+	wrapped, err := base64.RawURLEncoding.DecodeString(encodedDEK)
+	if err != nil {
+		return "", fmt.Errorf("malformed data key: %w", err)
+	}
+	ciphertext, err := base64.RawURLEncoding.DecodeString(encodedCiphertext)
+	if err != nil {
+		return "", fmt.Errorf("malformed ciphertext: %w", err)
+	}
+
+	dek, err := gcmOpen(kek, wrapped, []byte(aad))
+	if err != nil {
+		return "", fmt.Errorf("could not unwrap data key: %w", err)
+	}
+	plaintext, err := gcmOpen(dek, ciphertext, []byte(aad))
+	if err != nil {
+		return "", fmt.Errorf("could not decrypt: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+func isSealed(value string) bool {
+	return strings.HasPrefix(value, envelopePrefix)
+}
+
+// gcmSeal encrypts plaintext with AES-GCM, prepending the random nonce to the
+// ciphertext.
+func gcmSeal(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func gcmOpen(key, ciphertext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,60 @@
+package encryption
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	kek := bytes.Repeat([]byte{1}, keySize)
+	for _, plaintext := range []string{"thread_abc123", ""} {
+		sealed, err := seal(kek, plaintext, "user_1")
+		if err != nil {
+			t.Fatalf("seal(%q): %v", plaintext, err)
+		}
+		if !isSealed(sealed) || strings.Contains(sealed, "thread_abc123") {
+			t.Fatalf("seal(%q): got %q, want an envelope without the plaintext", plaintext, sealed)
+		}
+		opened, err := open(kek, sealed, "user_1")
+		if err != nil {
+			t.Fatalf("open(seal(%q)): %v", plaintext, err)
+		}
+		if opened != plaintext {
+			t.Fatalf("open(seal(%q)): got %q", plaintext, opened)
+		}
+	}
+
+	// Every value gets its own data key and nonce
+	first, _ := seal(kek, "thread", "user_1")
+	second, _ := seal(kek, "thread", "user_1")
+	if first == second {
+		t.Fatalf("seal returned the same envelope twice: %q", first)
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	kek := bytes.Repeat([]byte{1}, keySize)
+	sealed, err := seal(kek, "thread", "user_1")
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	encoded := strings.TrimPrefix(sealed, envelopePrefix)
+
+	for name, tc := range map[string]struct {
+		kek   []byte
+		value string
+		aad   string
+	}{
+		"other key":         {kek: bytes.Repeat([]byte{2}, keySize), value: sealed, aad: "user_1"},
+		"other row":         {kek: kek, value: sealed, aad: "user_2"},
+		"plaintext":         {kek: kek, value: "thread", aad: "user_1"},
+		"missing separator": {kek: kek, value: envelopePrefix + strings.Replace(encoded, ".", "", 1), aad: "user_1"},
+		"bad encoding":      {kek: kek, value: envelopePrefix + "!!!." + "!!!", aad: "user_1"},
+		"truncated":         {kek: kek, value: sealed[:len(sealed)-4], aad: "user_1"},
+	} {
+		if got, err := open(tc.kek, tc.value, tc.aad); err == nil {
+			t.Errorf("open with %s: got %q, want an error", name, got)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// keySize is the size of the keys, for AES-256.
+const keySize = 32
+
+// Keyring holds the key encryption keys, by key id. New data is always
+// encrypted with the active key, the other keys are only used to decrypt data
+// encrypted before a rotation.
+type Keyring struct {
+	keys   map[string][]byte
+	active string
+}
+
+// ParseKeyring parses keys in the form "<id>:<base64 key>,<id>:<base64 key>"
+// into a Keyring whose active key is active. Keys must be 32 bytes long.
+func ParseKeyring(keys, active string) (*Keyring, error) {
+	k := &Keyring{
+		keys:   make(map[string][]byte),
+		active: active,
+	}
+	for _, entry := range strings.Split(keys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid key entry, expected <id>:<base64 key>")
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", id, err)
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("invalid key %q: must be %d bytes long", id, keySize)
+		}
+		if _, ok := k.keys[id]; ok {
+			return nil, fmt.Errorf("duplicate key %q", id)
+		}
+		k.keys[id] = key
+	}
+
+	if _, ok := k.keys[active]; !ok {
+		return nil, fmt.Errorf("active key %q is not in the keyring", active)
+	}
+	return k, nil
+}
+
+// ActiveKeyID returns the id of the key new data is encrypted with.
+func (k *Keyring) ActiveKeyID() string {
+	return k.active
+}
+
+func (k *Keyring) key(id string) ([]byte, error) {
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", id)
+	}
+	return key, nil
+}
@@ -0,0 +1,52 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+// testKey returns a base64 encoded key filled with b.
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, keySize))
+}
+
+func TestParseKeyring(t *testing.T) {
+	keyring, err := ParseKeyring(" k1:"+testKey(1)+", ,k2:"+testKey(2)+",", "k2")
+	if err != nil {
+		t.Fatalf("ParseKeyring: %v", err)
+	}
+	if got := keyring.ActiveKeyID(); got != "k2" {
+		t.Fatalf("ActiveKeyID: got %q, want k2", got)
+	}
+	for id, b := range map[string]byte{"k1": 1, "k2": 2} {
+		key, err := keyring.key(id)
+		if err != nil || !bytes.Equal(key, bytes.Repeat([]byte{b}, keySize)) {
+			t.Fatalf("key(%q): got %v, %v", id, key, err)
+		}
+	}
+	if _, err := keyring.key("k3"); err == nil {
+		t.Fatalf("key of an unknown id: got no error")
+	}
+}
+
+func TestParseKeyringRejects(t *testing.T) {
+	short := base64.StdEncoding.EncodeToString([]byte("too short"))
+	for name, tc := range map[string]struct {
+		keys   string
+		active string
+	}{
+		"no keys":          {keys: "", active: "k1"},
+		"missing id":       {keys: ":" + testKey(1), active: "k1"},
+		"missing key":      {keys: "k1", active: "k1"},
+		"invalid base64":   {keys: "k1:not base64!", active: "k1"},
+		"short key":        {keys: "k1:" + short, active: "k1"},
+		"duplicate id":     {keys: "k1:" + testKey(1) + ",k1:" + testKey(2), active: "k1"},
+		"unknown active":   {keys: "k1:" + testKey(1), active: "k2"},
+		"empty active key": {keys: "k1:" + testKey(1), active: ""},
+	} {
+		if _, err := ParseKeyring(tc.keys, tc.active); err == nil {
+			t.Errorf("ParseKeyring with %s: got no error", name)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package encryption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+)
+
+// rotateBatchSize is how many entries are re-encrypted per page.
+const rotateBatchSize = 100
+
+// Rotate re-encrypts with the active key of keyring every entry of storage
+// that is stored in plaintext or encrypted with another key. storage is the
+// unencrypted handler, as passed to NewUserDataHandler. It returns the number
+// of entries re-encrypted.
+//
+// Only the thread id and its key id are written, and only if the entry still
+// holds the ciphertext that was listed, so that rotating while the service is
+// up doesn't undo the changes made to an entry since it was listed. Entries
+// changed since are skipped, as the service wrote them with the active key.
+func Rotate(ctx context.Context, storage ports.UserDataAPIHandler, keyring *Keyring, logger ports.Logger) (int, error) {
+	h, err := newUserDataHandler(storage, keyring, logger)
+	if err != nil {
+		return 0, err
+	}
+
+	rotated, skipped := 0, 0
+	filter := domain.UserDataFilter{
+		NotEncryptedWith: keyring.ActiveKeyID(),
+		Limit:            rotateBatchSize,
+	}
+	for {
+		page, err := storage.List(ctx, filter)
+		if err != nil {
+			return rotated, fmt.Errorf("could not list entries to rotate: %w", err)
+		}
+
+		for i := range page {
+			err := h.reseal(ctx, &page[i])
+			if errors.Is(err, domain.ErrNotFound) {
+				// Deleted or changed since it was listed
+				skipped++
+				continue
+			}
+			if err != nil {
+				return rotated, fmt.Errorf("could not re-encrypt entry of user %s: %w", page[i].UserID, err)
+			}
+			rotated++
+		}
+		logger.Info(ctx, "Re-encrypted user_data entries", "rotated", rotated, "skipped", skipped)
+
+		if len(page) < rotateBatchSize {
+			return rotated, nil
+		}
+		filter.AfterUserID = page[len(page)-1].UserID
+	}
+}
+
+// reseal encrypts the thread id of the stored entry with the active key, if
+// the entry still holds it.
+func (h *userDataHandler) reseal(ctx context.Context, stored *domain.UserData) error {
+	if stored.ThreadID == nil {
+		return nil
+	}
+	plaintext := *stored
+	if err := h.decrypt(&plaintext); err != nil {
+		return err
+	}
+	threadID, keyID, err := h.seal(stored.UserID, *plaintext.ThreadID)
+	if err != nil {
+		return err
+	}
+	return h.next.Patch(ctx, stored.UserID, domain.UserDataPatch{
+		ThreadID: &threadID,
+		KeyID:    &keyID,
+		If:       &domain.UserDataCondition{ThreadID: *stored.ThreadID, KeyID: stored.KeyID},
+	})
+}
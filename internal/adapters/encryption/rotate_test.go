@@ -0,0 +1,106 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/domain"
+	"testing"
+	"time"
+)
+
+func TestRotate(t *testing.T) {
+	ctx := context.Background()
+	storage := newMemoryStorage()
+	old := newTestHandler(t, storage, "k1")
+
+	// More entries than a page, encrypted with the old key or in plaintext
+	const n = rotateBatchSize + 5
+	for i := 0; i < n; i++ {
+		userID := fmt.Sprintf("user_%03d", i)
+		threadID := "thread_" + userID
+		if i%2 == 0 {
+			storage.entries[userID] = domain.UserData{UserID: userID, ThreadID: &threadID}
+		} else if err := old.Insert(ctx, userID, &domain.UserData{ThreadID: &threadID}); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+	storage.entries["user_none"] = domain.UserData{UserID: "user_none", Plan: domain.PlanFree}
+
+	rotated, err := Rotate(ctx, storage, testKeyring(t, "k2"), testLogger{})
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if rotated != n {
+		t.Fatalf("Rotate: got %d entries rotated, want %d", rotated, n)
+	}
+
+	// Only the new key is needed to read the entries afterwards
+	keyring, err := ParseKeyring("k2:"+testKey(2), "k2")
+	if err != nil {
+		t.Fatalf("ParseKeyring: %v", err)
+	}
+	h, err := NewUserDataHandler(storage, keyring, testLogger{})
+	if err != nil {
+		t.Fatalf("NewUserDataHandler: %v", err)
+	}
+	for userID, stored := range storage.entries {
+		if userID == "user_none" {
+			if stored.ThreadID != nil || stored.KeyID != nil {
+				t.Fatalf("entry without thread: got %+v", stored)
+			}
+			continue
+		}
+		if stored.KeyID == nil || *stored.KeyID != "k2" {
+			t.Fatalf("entry of %s: got key id %v, want k2", userID, stored.KeyID)
+		}
+		got, err := h.GetByID(ctx, userID)
+		if err != nil || *got.ThreadID != "thread_"+userID {
+			t.Fatalf("GetByID(%s) after Rotate: got %+v, %v", userID, got, err)
+		}
+	}
+
+	if rotated, err := Rotate(ctx, storage, testKeyring(t, "k2"), testLogger{}); err != nil || rotated != 0 {
+		t.Fatalf("Rotate again: got %d, %v, want nothing to rotate", rotated, err)
+	}
+}
+
+func TestRotateKeepsConcurrentChanges(t *testing.T) {
+	ctx := context.Background()
+	storage := newMemoryStorage()
+	service := newTestHandler(t, storage, "k1")
+	for _, userID := range []string{"user_1", "user_2"} {
+		if err := service.Insert(ctx, userID, &domain.UserData{ThreadID: ptr("thread_" + userID)}); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+
+	// The service writes to the entries listed before they are rotated, with
+	// the old key as it doesn't have the new one yet
+	active := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	storage.afterList = func() {
+		storage.afterList = nil
+		if err := service.Patch(ctx, "user_1", domain.UserDataPatch{ThreadID: ptr("thread_new")}); err != nil {
+			t.Errorf("Patch: %v", err)
+		}
+		if err := service.Patch(ctx, "user_2", domain.UserDataPatch{LastActiveAt: &active}); err != nil {
+			t.Errorf("Patch: %v", err)
+		}
+	}
+
+	rotated, err := Rotate(ctx, storage, testKeyring(t, "k2"), testLogger{})
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if rotated != 1 {
+		t.Fatalf("Rotate: got %d entries rotated, want only the unchanged thread", rotated)
+	}
+
+	h := newTestHandler(t, storage, "k2")
+	if got, err := h.GetByID(ctx, "user_1"); err != nil || *got.ThreadID != "thread_new" {
+		t.Fatalf("GetByID(user_1): got %+v, %v, want the thread linked during the rotation", got, err)
+	}
+	got, err := h.GetByID(ctx, "user_2")
+	if err != nil || *got.ThreadID != "thread_user_2" || *got.KeyID != "k2" || !got.LastActiveAt.Equal(active) {
+		t.Fatalf("GetByID(user_2): got %+v, %v, want the rotated thread and the new activity", got, err)
+	}
+}
@@ -0,0 +1,190 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+)
+
+type userDataHandler struct {
+	keyring *Keyring
+	logger  ports.Logger
+	next    ports.UserDataAPIHandler
+}
+
+// NewUserDataHandler wraps next so the thread ids it stores are encrypted with
+// the active key of keyring. Entries written before encryption was enabled
+// are read as is, and encrypted the next time they are written.
+func NewUserDataHandler(next ports.UserDataAPIHandler, keyring *Keyring, logger ports.Logger) (ports.UserDataAPIHandler, error) {
+	return newUserDataHandler(next, keyring, logger)
+}
+
+func newUserDataHandler(next ports.UserDataAPIHandler, keyring *Keyring, logger ports.Logger) (*userDataHandler, error) {
+	h := &userDataHandler{
+		keyring: keyring,
+		logger:  logger,
+		next:    next,
+	}
+	if h.next == nil {
+		return nil, fmt.Errorf("next can't be nil")
+	}
+	if h.keyring == nil {
+		return nil, fmt.Errorf("keyring can't be nil")
+	}
+	if h.logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	return h, nil
+}
+
+func (h *userDataHandler) GetByID(ctx context.Context, userID string) (*domain.UserData, error) {
+	userData, err := h.next.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.decrypt(userData); err != nil {
+		h.logger.Error(ctx, "Error decrypting user_data", "user_id", userID, "error", err)
+		return nil, err
+	}
+	return userData, nil
+}
+
+func (h *userDataHandler) Insert(ctx context.Context, userID string, userData *domain.UserData) error {
+	if userData == nil {
+		return h.next.Insert(ctx, userID, nil)
+	}
+	encrypted, err := h.encrypt(userID, userData)
+	if err != nil {
+		return err
+	}
+	return h.next.Insert(ctx, userID, encrypted)
+}
+
+func (h *userDataHandler) Update(ctx context.Context, userID string, userData *domain.UserData) error {
+	if userData == nil {
+		return h.next.Update(ctx, userID, nil)
+	}
+	encrypted, err := h.encrypt(userID, userData)
+	if err != nil {
+		return err
+	}
+	return h.next.Update(ctx, userID, encrypted)
+}
+
+func (h *userDataHandler) Patch(ctx context.Context, userID string, patch domain.UserDataPatch) error {
+	if patch.If != nil {
+		stored, err := h.storedCondition(ctx, userID, *patch.If)
+		if err != nil {
+			return err
+		}
+		patch.If = stored
+	}
+	if patch.ThreadID != nil && !patch.ClearThreadID {
+		threadID, keyID, err := h.seal(userID, *patch.ThreadID)
+		if err != nil {
+			return err
+		}
+		patch.ThreadID, patch.KeyID = &threadID, &keyID
+	}
+	return h.next.Patch(ctx, userID, patch)
+}
+
+func (h *userDataHandler) Delete(ctx context.Context, userID string) error {
+	return h.next.Delete(ctx, userID)
+}
+
+func (h *userDataHandler) List(ctx context.Context, filter domain.UserDataFilter) ([]domain.UserData, error) {
+	users, err := h.next.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range users {
+		if err := h.decrypt(&users[i]); err != nil {
+			h.logger.Error(ctx, "Error decrypting user_data", "user_id", users[i].UserID, "error", err)
+			return nil, err
+		}
+	}
+	return users, nil
+}
+
+// storedCondition returns the condition on the stored entry of the user
+// equivalent to the plaintext condition cond. Thread ids are encrypted with a
+// random data key, so the stored ciphertext is read and the storage only
+// patches the entry if it still holds it.
+func (h *userDataHandler) storedCondition(ctx context.Context, userID string, cond domain.UserDataCondition) (*domain.UserDataCondition, error) {
+	stored, err := h.next.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if stored.ThreadID == nil {
+		return nil, fmt.Errorf("%w: user_data has no thread", domain.ErrNotFound)
+	}
+	plaintext := *stored
+	if err := h.decrypt(&plaintext); err != nil {
+		h.logger.Error(ctx, "Error decrypting user_data", "user_id", userID, "error", err)
+		return nil, err
+	}
+	if *plaintext.ThreadID != cond.ThreadID {
+		return nil, fmt.Errorf("%w: user_data thread changed", domain.ErrNotFound)
+	}
+	return &domain.UserDataCondition{
+		ThreadID:       *stored.ThreadID,
+		KeyID:          stored.KeyID,
+		InactiveBefore: cond.InactiveBefore,
+	}, nil
+}
+
+// encrypt returns a copy of userData with its thread id encrypted. The copy
+// leaves the caller's entry in plaintext.
+func (h *userDataHandler) encrypt(userID string, userData *domain.UserData) (*domain.UserData, error) {
+	encrypted := *userData
+	if userData.ThreadID == nil {
+		encrypted.KeyID = nil
+		return &encrypted, nil
+	}
+
+	threadID, keyID, err := h.seal(userID, *userData.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+	encrypted.ThreadID = &threadID
+	encrypted.KeyID = &keyID
+	userData.KeyID = &keyID
+	return &encrypted, nil
+}
+
+// seal encrypts the thread id of the user with the active key, and returns
+// the id of the key.
+func (h *userDataHandler) seal(userID, threadID string) (string, string, error) {
+	keyID := h.keyring.ActiveKeyID()
+	kek, err := h.keyring.key(keyID)
+	if err != nil {
+		return "", "", err
+	}
+	sealed, err := seal(kek, threadID, userID)
+	if err != nil {
+		return "", "", fmt.Errorf("error encrypting thread id: %w", err)
+	}
+	return sealed, keyID, nil
+}
+
+// decrypt decrypts the thread id of userData in place.
+func (h *userDataHandler) decrypt(userData *domain.UserData) error {
+	if userData == nil || userData.ThreadID == nil || !isSealed(*userData.ThreadID) {
+		return nil
+	}
+	if userData.KeyID == nil {
+		return fmt.Errorf("encrypted thread id has no key id")
+	}
+	kek, err := h.keyring.key(*userData.KeyID)
+	if err != nil {
+		return err
+	}
+	threadID, err := open(kek, *userData.ThreadID, userData.UserID)
+	if err != nil {
+		return fmt.Errorf("error decrypting thread id: %w", err)
+	}
+	userData.ThreadID = &threadID
+	return nil
+}
@@ -0,0 +1,242 @@
+package encryption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"stress-relief-ai-chat-back/internal/domain"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type testLogger struct{}
+
+func (testLogger) Close() error                                  { return nil }
+func (testLogger) Debug(context.Context, string, ...interface{}) {}
+func (testLogger) Info(context.Context, string, ...interface{})  {}
+func (testLogger) Warn(context.Context, string, ...interface{})  {}
+func (testLogger) Error(context.Context, string, ...interface{}) {}
+func (testLogger) Fatal(context.Context, string, ...interface{}) {}
+
+// memoryStorage stores user_data entries as the storage adapters do, applying
+// the conditions of patches.
+type memoryStorage struct {
+	mu      sync.Mutex
+	entries map[string]domain.UserData
+	// afterList is called after each List, to change entries concurrently
+	afterList func()
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{entries: make(map[string]domain.UserData)}
+}
+
+func (s *memoryStorage) GetByID(_ context.Context, userID string) (*domain.UserData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[userID]
+	if !ok {
+		return nil, fmt.Errorf("%w: user_data not found", domain.ErrNotFound)
+	}
+	return &entry, nil
+}
+
+func (s *memoryStorage) Insert(_ context.Context, userID string, userData *domain.UserData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := *userData
+	entry.UserID = userID
+	s.entries[userID] = entry
+	return nil
+}
+
+func (s *memoryStorage) Update(_ context.Context, userID string, userData *domain.UserData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[userID]; !ok {
+		return fmt.Errorf("%w: user_data not found", domain.ErrNotFound)
+	}
+	entry := *userData
+	entry.UserID = userID
+	s.entries[userID] = entry
+	return nil
+}
+
+func (s *memoryStorage) Patch(_ context.Context, userID string, patch domain.UserDataPatch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[userID]
+	if ok && patch.If != nil {
+		ok = entry.ThreadID != nil && *entry.ThreadID == patch.If.ThreadID && equal(entry.KeyID, patch.If.KeyID)
+	}
+	if !ok {
+		return fmt.Errorf("%w: user_data not found", domain.ErrNotFound)
+	}
+	if patch.ThreadID != nil {
+		entry.ThreadID, entry.KeyID = patch.ThreadID, patch.KeyID
+	}
+	if patch.LastActiveAt != nil {
+		entry.LastActiveAt = patch.LastActiveAt
+	}
+	s.entries[userID] = entry
+	return nil
+}
+
+func (s *memoryStorage) Delete(_ context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, userID)
+	return nil
+}
+
+func (s *memoryStorage) List(_ context.Context, filter domain.UserDataFilter) ([]domain.UserData, error) {
+	s.mu.Lock()
+	var users []domain.UserData
+	for _, entry := range s.entries {
+		if filter.NotEncryptedWith != "" &&
+			(entry.ThreadID == nil || equal(entry.KeyID, &filter.NotEncryptedWith)) {
+			continue
+		}
+		if entry.UserID <= filter.AfterUserID {
+			continue
+		}
+		users = append(users, entry)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].UserID < users[j].UserID
+	})
+	if filter.Limit > 0 && len(users) > filter.Limit {
+		users = users[:filter.Limit]
+	}
+	if s.afterList != nil {
+		s.afterList()
+	}
+	return users, nil
+}
+
+func equal(a, b *string) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func testKeyring(t *testing.T, active string) *Keyring {
+	t.Helper()
+	keyring, err := ParseKeyring("k1:"+testKey(1)+",k2:"+testKey(2), active)
+	if err != nil {
+		t.Fatalf("ParseKeyring: %v", err)
+	}
+	return keyring
+}
+
+func newTestHandler(t *testing.T, storage *memoryStorage, active string) *userDataHandler {
+	t.Helper()
+	h, err := newUserDataHandler(storage, testKeyring(t, active), testLogger{})
+	if err != nil {
+		t.Fatalf("newUserDataHandler: %v", err)
+	}
+	return h
+}
+
+func TestUserDataHandlerEncryptsThreadIDs(t *testing.T) {
+	ctx := context.Background()
+	storage := newMemoryStorage()
+	h := newTestHandler(t, storage, "k1")
+
+	if err := h.Insert(ctx, "user_1", &domain.UserData{ThreadID: ptr("thread_1"), Plan: domain.PlanPremium}); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	stored := storage.entries["user_1"]
+	if !isSealed(*stored.ThreadID) || stored.KeyID == nil || *stored.KeyID != "k1" {
+		t.Fatalf("stored entry: got thread id %q, key id %v", *stored.ThreadID, stored.KeyID)
+	}
+	got, err := h.GetByID(ctx, "user_1")
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if *got.ThreadID != "thread_1" || got.Plan != domain.PlanPremium {
+		t.Fatalf("GetByID: got %+v", got)
+	}
+
+	// Entries written before encryption was enabled are read as is
+	storage.entries["user_2"] = domain.UserData{UserID: "user_2", ThreadID: ptr("thread_2")}
+	if got, err := h.GetByID(ctx, "user_2"); err != nil || *got.ThreadID != "thread_2" {
+		t.Fatalf("GetByID of a plaintext entry: got %+v, %v", got, err)
+	}
+
+	// The ciphertext is bound to its row
+	moved := stored
+	moved.UserID = "user_3"
+	storage.entries["user_3"] = moved
+	if _, err := h.GetByID(ctx, "user_3"); err == nil {
+		t.Fatalf("GetByID of a ciphertext copied from another row: got no error")
+	}
+}
+
+func TestUserDataHandlerRejectsUnknownKeyID(t *testing.T) {
+	ctx := context.Background()
+	storage := newMemoryStorage()
+	if err := newTestHandler(t, storage, "k1").Insert(ctx, "user_1", &domain.UserData{ThreadID: ptr("thread_1")}); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	// A keyring without the key the entry was encrypted with can't read it
+	keyring, err := ParseKeyring("k2:"+testKey(2), "k2")
+	if err != nil {
+		t.Fatalf("ParseKeyring: %v", err)
+	}
+	h, err := NewUserDataHandler(storage, keyring, testLogger{})
+	if err != nil {
+		t.Fatalf("NewUserDataHandler: %v", err)
+	}
+	if _, err := h.GetByID(ctx, "user_1"); err == nil || !strings.Contains(err.Error(), `unknown key "k1"`) {
+		t.Fatalf("GetByID: got %v, want an unknown key error", err)
+	}
+
+	entry := storage.entries["user_1"]
+	entry.KeyID = nil
+	storage.entries["user_1"] = entry
+	if _, err := h.GetByID(ctx, "user_1"); err == nil {
+		t.Fatalf("GetByID of a ciphertext without key id: got no error")
+	}
+}
+
+func TestUserDataHandlerConditionalPatch(t *testing.T) {
+	ctx := context.Background()
+	storage := newMemoryStorage()
+	h := newTestHandler(t, storage, "k1")
+	if err := h.Insert(ctx, "user_1", &domain.UserData{ThreadID: ptr("thread_1")}); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	// The condition is on the plaintext thread id, whatever its ciphertext
+	err := h.Patch(ctx, "user_1", domain.UserDataPatch{
+		ThreadID: ptr("thread_2"),
+		If:       &domain.UserDataCondition{ThreadID: "thread_other"},
+	})
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("Patch with a stale thread id: got %v, want ErrNotFound", err)
+	}
+	err = h.Patch(ctx, "user_1", domain.UserDataPatch{
+		ThreadID: ptr("thread_2"),
+		If:       &domain.UserDataCondition{ThreadID: "thread_1"},
+	})
+	if err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+	if got, err := h.GetByID(ctx, "user_1"); err != nil || *got.ThreadID != "thread_2" {
+		t.Fatalf("GetByID after Patch: got %+v, %v", got, err)
+	}
+	if err := h.Patch(ctx, "user_2", domain.UserDataPatch{
+		ThreadID: ptr("thread_2"),
+		If:       &domain.UserDataCondition{ThreadID: "thread_1"},
+	}); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("Patch of a missing entry: got %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,249 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"sync"
+	"time"
+)
+
+// defaultReply answers messages that match no rule when the fixtures don't
+// set one.
+const defaultReply = "I'm a scripted assistant. Take a slow breath, you're doing better than you think."
+
+// turnKey identifies a rule in a thread.
+type turnKey struct {
+	threadID string
+	rule     int
+}
+
+type handler struct {
+	fixtures *Fixtures
+	logger   ports.Logger
+
+	mu sync.Mutex
+	// threads holds the messages of each thread, oldest first.
+	threads map[string][]domain.ThreadMessage
+	// turns counts how many times each rule matched in each thread, to pick
+	// its next reply.
+	turns map[turnKey]int
+	// runs holds the replies being delayed, by run id.
+	runs map[string]*fakeRun
+	// lastThread, lastMessage and lastRun number the synthetic ids.
+	lastThread  int
+	lastMessage int
+	lastRun     int
+	now         func() time.Time
+}
+
+// NewChatHandler creates a ChatHandler that answers with the replies scripted
+// in fixtures and keeps threads in memory. Nil fixtures answer every message
+// with a default reply.
+func NewChatHandler(fixtures *Fixtures, logger ports.Logger) (ports.ChatHandler, error) {
+	if fixtures == nil {
+		fixtures = &Fixtures{}
+	}
+	if logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	if err := fixtures.compile(); err != nil {
+		return nil, fmt.Errorf("invalid fixtures: %w", err)
+	}
+	if fixtures.DefaultReply == "" {
+		fixtures.DefaultReply = defaultReply
+	}
+	return &handler{
+		fixtures: fixtures,
+		logger:   logger,
+		threads:  make(map[string][]domain.ThreadMessage),
+		runs:     make(map[string]*fakeRun),
+		turns:    make(map[turnKey]int),
+		now:      time.Now,
+	}, nil
+}
+
+func (h *handler) ProcessMessage(ctx context.Context, message *domain.ChatMessage, threadID *string) (*domain.ChatResponse, error) {
+	if err := message.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid message: %s", err.Error())
+	}
+
+	reply, rule, latency, err := h.script(threadID, message.Content)
+	if latency > 0 {
+		runID, cancelled := h.startRun(threadID)
+		timer := time.NewTimer(latency)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			h.endRun(runID)
+			return nil, ctx.Err()
+		case <-cancelled:
+			timer.Stop()
+			return nil, fmt.Errorf("%w: run %s cancelled", domain.ErrUnavailable, runID)
+		case <-timer.C:
+			h.endRun(runID)
+		}
+	}
+	if err != nil {
+		h.logger.Error(ctx, "Scripted error", "error", err)
+		return nil, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var id string
+	if threadID == nil {
+		h.lastThread++
+		id = fmt.Sprintf("thread_fake_%06d", h.lastThread)
+	} else {
+		id = *threadID
+		if _, ok := h.threads[id]; !ok {
+			return nil, fmt.Errorf("could not create message: %w: thread %s", domain.ErrNotFound, id)
+		}
+	}
+	if rule >= 0 {
+		h.turns[turnKey{threadID: id, rule: rule}]++
+	}
+	h.threads[id] = append(h.threads[id],
+		h.newMessage(domain.MessageRoleUser, message.Content),
+		h.newMessage(domain.MessageRoleAssistant, reply))
+
+	return &domain.ChatResponse{
+		Content:  reply,
+		ThreadID: id,
+	}, nil
+}
+
+// script picks the reply to content in the thread from the first matching
+// rule, along with the index of the rule, or -1 for the default reply, and how
+// long to wait before answering. It returns the scripted error, if the rule
+// has one. A nil thread is a new one.
+func (h *handler) script(threadID *string, content string) (string, int, time.Duration, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i := range h.fixtures.Rules {
+		r := &h.fixtures.Rules[i]
+		match := r.re.FindStringSubmatchIndex(content)
+		if match == nil {
+			continue
+		}
+
+		latency := h.fixtures.Latency
+		if r.Latency != nil {
+			latency = *r.Latency
+		}
+		if r.Error != "" {
+			return "", -1, latency, errors.New(r.Error)
+		}
+
+		turn := 0
+		if threadID != nil {
+			turn = h.turns[turnKey{threadID: *threadID, rule: i}]
+		}
+		template := r.Replies[turn%len(r.Replies)]
+		return string(r.re.ExpandString(nil, template, content, match)), i, latency, nil
+	}
+	return h.fixtures.DefaultReply, -1, h.fixtures.Latency, nil
+}
+
+func (h *handler) ListMessages(_ context.Context, threadID string) ([]domain.ThreadMessage, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	messages, ok := h.threads[threadID]
+	if !ok {
+		return nil, fmt.Errorf("could not list messages: %w: thread %s", domain.ErrNotFound, threadID)
+	}
+	return append([]domain.ThreadMessage(nil), messages...), nil
+}
+
+func (h *handler) AppendMessages(_ context.Context, threadID string, messages []domain.ThreadMessage) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.threads[threadID]; !ok {
+		return fmt.Errorf("could not create message: %w: thread %s", domain.ErrNotFound, threadID)
+	}
+	for _, m := range messages {
+		if m.Content == "" {
+			continue
+		}
+		h.threads[threadID] = append(h.threads[threadID], h.newMessage(m.Role, m.Content))
+	}
+	return nil
+}
+
+func (h *handler) DeleteThread(_ context.Context, threadID string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.threads[threadID]; !ok {
+		return fmt.Errorf("could not delete thread: %w: thread %s", domain.ErrNotFound, threadID)
+	}
+	delete(h.threads, threadID)
+	for key := range h.turns {
+		if key.threadID == threadID {
+			delete(h.turns, key)
+		}
+	}
+	return nil
+}
+
+// newMessage creates a message with a synthetic id. It must be called with
+// the lock held.
+// fakeRun is a reply being delayed.
+type fakeRun struct {
+	ref       domain.RunRef
+	cancelled chan struct{}
+}
+
+// startRun records a reply being delayed. The returned channel is closed if
+// the run is cancelled, after which it is forgotten.
+func (h *handler) startRun(threadID *string) (string, <-chan struct{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastRun++
+	run := &fakeRun{
+		ref:       domain.RunRef{RunID: fmt.Sprintf("run_fake_%06d", h.lastRun)},
+		cancelled: make(chan struct{}),
+	}
+	if threadID != nil {
+		run.ref.ThreadID = *threadID
+	}
+	h.runs[run.ref.RunID] = run
+	return run.ref.RunID, run.cancelled
+}
+
+// endRun forgets a run that was not cancelled.
+func (h *handler) endRun(runID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.runs, runID)
+}
+
+// CancelActiveRuns cancels the replies being delayed, which then fail.
+func (h *handler) CancelActiveRuns(context.Context) ([]domain.RunRef, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	cancelled := make([]domain.RunRef, 0, len(h.runs))
+	for id, run := range h.runs {
+		close(run.cancelled)
+		delete(h.runs, id)
+		cancelled = append(cancelled, run.ref)
+	}
+	return cancelled, nil
+}
+
+func (h *handler) newMessage(role, content string) domain.ThreadMessage {
+	h.lastMessage++
+	return domain.ThreadMessage{
+		ID:        fmt.Sprintf("msg_fake_%06d", h.lastMessage),
+		Role:      role,
+		Content:   content,
+		CreatedAt: h.now().UTC(),
+	}
+}
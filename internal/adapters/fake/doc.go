@@ -0,0 +1,24 @@
+// Package fake provides a scripted ChatHandler that answers without calling
+// an LLM, for frontend development and CI.
+//
+// Replies are picked from fixtures: rules with a regular expression matched
+// against the message and the reply to answer with, or the error to fail with.
+// A fixtures file looks like:
+//
+//	latency: 300ms
+//	default_reply: "Take a deep breath, you're doing fine."
+//	rules:
+//	  - match: "(?i)\\bexam\\b"
+//	    replies:
+//	      - "Exams are stressful. Let's make a study plan."
+//	      - "You prepared for this. One question at a time."
+//	  - match: "(?i)my name is (\\w+)"
+//	    replies: ["Nice to meet you, $1."]
+//	  - match: "(?i)^simulate outage$"
+//	    error: "assistant unavailable"
+//	    latency: 2s
+//
+// When a rule has several replies they are used in turn within each thread, so
+// a conversation is deterministic for a given sequence of messages, whatever
+// the other conversations.
+package fake
@@ -0,0 +1,67 @@
+package fake
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"regexp"
+	"time"
+)
+
+// Fixtures script the replies of the fake ChatHandler.
+type Fixtures struct {
+	// Latency is how long every reply takes, unless the matching rule sets
+	// its own.
+	Latency time.Duration `yaml:"latency"`
+	// DefaultReply answers messages that match no rule.
+	DefaultReply string `yaml:"default_reply"`
+	Rules        []Rule `yaml:"rules"`
+}
+
+// Rule answers the messages matching a regular expression.
+type Rule struct {
+	Match string `yaml:"match"`
+	// Replies are used in turn each time the rule matches in a thread. They
+	// can refer to the capture groups of Match as $1 or ${name}.
+	Replies []string `yaml:"replies"`
+	// Error makes the handler fail with this message instead of replying.
+	Error   string         `yaml:"error"`
+	Latency *time.Duration `yaml:"latency"`
+
+	re *regexp.Regexp
+}
+
+// LoadFixtures reads fixtures from a YAML file.
+func LoadFixtures(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read fixtures: %w", err)
+	}
+	var f Fixtures
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("could not parse fixtures: %w", err)
+	}
+	return &f, nil
+}
+
+// compile validates the rules and compiles their expressions.
+func (f *Fixtures) compile() error {
+	if f.Latency < 0 {
+		return fmt.Errorf("latency can't be negative")
+	}
+	for i := range f.Rules {
+		r := &f.Rules[i]
+		re, err := regexp.Compile(r.Match)
+		if err != nil {
+			return fmt.Errorf("rule %d: invalid match: %w", i, err)
+		}
+		if len(r.Replies) == 0 && r.Error == "" {
+			return fmt.Errorf("rule %d: either replies or error must be set", i)
+		}
+		if r.Latency != nil && *r.Latency < 0 {
+			return fmt.Errorf("rule %d: latency can't be negative", i)
+		}
+		r.re = re
+	}
+	return nil
+}
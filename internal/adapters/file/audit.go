@@ -0,0 +1,104 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"os"
+	"slices"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"sync"
+	"time"
+)
+
+type auditLogger struct {
+	logger ports.Logger
+	path   string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewAuditLogger creates an AuditLogger that appends events as JSON lines to
+// the file at path. The file is only ever opened for appending.
+func NewAuditLogger(path string, logger ports.Logger) (ports.AuditLogger, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path can't be empty")
+	}
+	if logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open audit log: %w", err)
+	}
+	return &auditLogger{
+		logger: logger,
+		path:   path,
+		file:   f,
+	}, nil
+}
+
+func (a *auditLogger) Record(ctx context.Context, event *domain.AuditEvent) error {
+	if event == nil {
+		return fmt.Errorf("can't record nil event")
+	}
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error marshalling audit event: %w", err)
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, err := a.file.Write(line); err != nil {
+		a.logger.Error(ctx, "Error writing audit event", "error", err)
+		return fmt.Errorf("error writing audit event: %w", err)
+	}
+	if err := a.file.Sync(); err != nil {
+		return fmt.Errorf("error syncing audit log: %w", err)
+	}
+	return nil
+}
+
+func (a *auditLogger) Query(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEvent, error) {
+	f, err := os.Open(a.path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var events []domain.AuditEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e domain.AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			a.logger.Warn(ctx, "Skipping malformed audit log line", "error", err)
+			continue
+		}
+		if q.Matches(&e) {
+			events = append(events, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading audit log: %w", err)
+	}
+
+	// Events are appended in order, so the most recent ones are at the end
+	slices.Reverse(events)
+	if q.Limit > 0 && len(events) > q.Limit {
+		events = events[:q.Limit]
+	}
+	return events, nil
+}
@@ -0,0 +1,3 @@
+// Package file provides implementations of repository ports backed by files on
+// the local filesystem, meant for single node deployments and local development.
+package file
@@ -0,0 +1,188 @@
+package http
+
+import (
+	"crypto/subtle"
+	"github.com/gofiber/fiber/v2"
+	"stress-relief-ai-chat-back/internal/domain"
+	"time"
+)
+
+// adminActorAPIKey is the actor recorded for admin actions authorized with the
+// admin API key instead of a user token.
+const adminActorAPIKey = "admin-api-key"
+
+// adminMiddleware lets the request through if it carries the admin API key, or
+// a token of a user with the admin role.
+func (h *Handler) adminMiddleware(c *fiber.Ctx) error {
+	if key := c.Get("X-Admin-API-Key"); key != "" {
+		if h.config.AdminAPIKey == "" || subtle.ConstantTimeCompare([]byte(key), []byte(h.config.AdminAPIKey)) != 1 {
+			return domain.NewError(domain.ErrUnauthorized, "Invalid admin API key")
+		}
+		c.Locals("actor", adminActorAPIKey)
+		return c.Next()
+	}
+
+	principal, err := h.authenticate(c)
+	if err != nil {
+		return err
+	}
+	if !principal.HasRole(h.config.AdminRole) {
+		return domain.NewError(domain.ErrForbidden, "Admin role required")
+	}
+	c.Locals("actor", principal.UserID)
+	return c.Next()
+}
+
+func (h *Handler) handleGetUser(c *fiber.Ctx) error {
+	userID := c.Params("userID")
+	userData, err := h.services.Admin.GetUser(c.UserContext(), userID)
+	h.auditAdminAction(c, domain.AuditActionUserLookup, userID, err)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(userData)
+}
+
+func (h *Handler) handleResetThread(c *fiber.Ctx) error {
+	userID := c.Params("userID")
+	userData, err := h.services.Admin.ResetThread(c.UserContext(), userID)
+	h.auditAdminAction(c, domain.AuditActionThreadReset, userID, err)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(userData)
+}
+
+func (h *Handler) handleSetPlan(c *fiber.Ctx) error {
+	var req struct {
+		Plan domain.Plan `json:"plan" validate:"required,oneof=free premium"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return errInvalidBody
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return validationError(err)
+	}
+
+	userID := c.Params("userID")
+	userData, err := h.services.Admin.SetPlan(c.UserContext(), userID, req.Plan)
+	h.auditAdminAction(c, domain.AuditActionPlanChange, userID, err)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(userData)
+}
+
+func (h *Handler) handleResetUsage(c *fiber.Ctx) error {
+	userID := c.Params("userID")
+	err := h.services.Admin.ResetUsage(c.UserContext(), userID)
+	h.auditAdminAction(c, domain.AuditActionUsageReset, userID, err)
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) handleGetUsage(c *fiber.Ctx) error {
+	usage, err := h.services.Admin.Usage(c.UserContext())
+	h.auditAdminAction(c, domain.AuditActionUsageView, "", err)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(usage)
+}
+
+func (h *Handler) handleListSafetyEvents(c *fiber.Ctx) error {
+	events, err := h.services.Admin.SafetyEvents(c.UserContext(), c.QueryInt("limit", 50))
+	h.auditAdminAction(c, domain.AuditActionSafetyView, "", err)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(events)
+}
+
+func (h *Handler) handleGetCacheStats(c *fiber.Ctx) error {
+	stats := h.services.UserDataCache.CacheStats()
+	return c.JSON(fiber.Map{
+		"userData": fiber.Map{
+			"hits":          stats.Hits,
+			"negativeHits":  stats.NegativeHits,
+			"misses":        stats.Misses,
+			"invalidations": stats.Invalidations,
+			"errors":        stats.Errors,
+			"hitRatio":      stats.HitRatio(),
+		},
+	})
+}
+
+func (h *Handler) handleGetLogLevel(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"level": h.services.LogLevel.Level()})
+}
+
+func (h *Handler) handleSetLogLevel(c *fiber.Ctx) error {
+	var req struct {
+		Level string `json:"level" validate:"required,oneof=debug info warn error"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return errInvalidBody
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return validationError(err)
+	}
+
+	previous := h.services.LogLevel.Level()
+	err := h.services.LogLevel.SetLevel(req.Level)
+	h.auditAdminAction(c, domain.AuditActionLogLevelSet, "", err)
+	if err != nil {
+		return domain.WrapError(domain.ErrInvalidInput, "Invalid log level", err)
+	}
+	h.logger.Info(c.UserContext(), "Log level changed", "from", previous, "to", req.Level)
+
+	return c.JSON(fiber.Map{"level": h.services.LogLevel.Level()})
+}
+
+func (h *Handler) handleQueryAuditLog(c *fiber.Ctx) error {
+	q := domain.AuditQuery{
+		Actor:        c.Query("actor"),
+		Action:       c.Query("action"),
+		TargetUserID: c.Query("targetUserId"),
+		Limit:        c.QueryInt("limit", 100),
+	}
+	var err error
+	if v := c.Query("since"); v != "" {
+		if q.Since, err = time.Parse(time.RFC3339, v); err != nil {
+			return domain.NewError(domain.ErrInvalidInput, "Since must be an RFC 3339 timestamp")
+		}
+	}
+	if v := c.Query("until"); v != "" {
+		if q.Until, err = time.Parse(time.RFC3339, v); err != nil {
+			return domain.NewError(domain.ErrInvalidInput, "Until must be an RFC 3339 timestamp")
+		}
+	}
+
+	events, err := h.services.Audit.Query(c.UserContext(), q)
+	h.auditAdminAction(c, domain.AuditActionAuditLogQuery, q.TargetUserID, err)
+	if err != nil {
+		return domain.WrapUnavailable(err)
+	}
+
+	return c.JSON(events)
+}
+
+// auditAdminAction records who performed an admin action on which user, and
+// whether it succeeded.
+func (h *Handler) auditAdminAction(c *fiber.Ctx, action, targetUserID string, err error) {
+	actor, _ := c.Locals("actor").(string)
+	event := domain.NewAuditEvent(c.UserContext(), actor, action, targetUserID, err)
+	if err := h.services.Audit.Record(c.UserContext(), event); err != nil {
+		h.logger.Error(c.UserContext(), "could not record audit event", "action", action, "error", err.Error())
+	}
+}
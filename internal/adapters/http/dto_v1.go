@@ -0,0 +1,135 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"stress-relief-ai-chat-back/internal/domain"
+	"time"
+)
+
+// The DTOs of v1 are frozen: they are the responses of the API from before it
+// was versioned, which clients that can't be updated rely on.
+
+type v1ChatResponse struct {
+	Content  string `json:"content"`
+	ThreadID string `json:"threadId"`
+}
+
+type v1UserData struct {
+	UserID        string     `json:"user_id"`
+	ThreadID      *string    `json:"thread_id"`
+	Plan          string     `json:"plan,omitempty"`
+	LastActiveAt  *time.Time `json:"last_active_at,omitempty"`
+	RetentionDays *int       `json:"retention_days"`
+}
+
+type v1DataExport struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"userId"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+type v1DeletionReceipt struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	DeletedAt time.Time `json:"deletedAt"`
+	Erased    []string  `json:"erased"`
+	Retained  []string  `json:"retained"`
+	Signature string    `json:"signature"`
+}
+
+type v1GuestSession struct {
+	UserID    string    `json:"userId"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type v1SystemStatus struct {
+	Version       string               `json:"version"`
+	Commit        string               `json:"commit"`
+	StartedAt     time.Time            `json:"startedAt"`
+	UptimeSeconds int64                `json:"uptimeSeconds"`
+	Ready         bool                 `json:"ready"`
+	Dependencies  []v1DependencyStatus `json:"dependencies"`
+}
+
+type v1DependencyStatus struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	LatencyMs int64     `json:"latencyMs"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+type v1Presenter struct{}
+
+func (v1Presenter) ChatResponse(resp *domain.ChatResponse) any {
+	return v1ChatResponse{Content: resp.Content, ThreadID: resp.ThreadID}
+}
+
+func (v1Presenter) UserData(userData *domain.UserData) any {
+	return v1UserData{
+		UserID:        userData.UserID,
+		ThreadID:      userData.ThreadID,
+		Plan:          string(userData.Plan),
+		LastActiveAt:  userData.LastActiveAt,
+		RetentionDays: userData.RetentionDays,
+	}
+}
+
+func (v1Presenter) DataExport(export *domain.DataExport) any {
+	return v1DataExport{
+		ID:          export.ID,
+		UserID:      export.UserID,
+		Status:      string(export.Status),
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt,
+		Error:       export.Error,
+	}
+}
+
+func (v1Presenter) DeletionReceipt(receipt *domain.DeletionReceipt) any {
+	return v1DeletionReceipt{
+		ID:        receipt.ID,
+		UserID:    receipt.UserID,
+		DeletedAt: receipt.DeletedAt,
+		Erased:    receipt.Erased,
+		Retained:  receipt.Retained,
+		Signature: receipt.Signature,
+	}
+}
+
+func (v1Presenter) GuestSession(session *domain.GuestSession) any {
+	return v1GuestSession{UserID: session.UserID, Token: session.Token, ExpiresAt: session.ExpiresAt}
+}
+
+func (v1Presenter) SystemStatus(status *domain.SystemStatus) any {
+	deps := make([]v1DependencyStatus, 0, len(status.Dependencies))
+	for _, d := range status.Dependencies {
+		deps = append(deps, v1DependencyStatus{Name: d.Name, Status: d.Status, LatencyMs: d.LatencyMs, CheckedAt: d.CheckedAt})
+	}
+	return v1SystemStatus{
+		Version:       status.Version,
+		Commit:        status.Commit,
+		StartedAt:     status.StartedAt,
+		UptimeSeconds: status.UptimeSeconds,
+		Ready:         status.Ready,
+		Dependencies:  deps,
+	}
+}
+
+func (v1Presenter) ParseDeletionReceipt(c *fiber.Ctx) (*domain.DeletionReceipt, error) {
+	var receipt v1DeletionReceipt
+	if err := c.BodyParser(&receipt); err != nil {
+		return nil, err
+	}
+	return &domain.DeletionReceipt{
+		ID:        receipt.ID,
+		UserID:    receipt.UserID,
+		DeletedAt: receipt.DeletedAt,
+		Erased:    receipt.Erased,
+		Retained:  receipt.Retained,
+		Signature: receipt.Signature,
+	}, nil
+}
@@ -0,0 +1,23 @@
+package http
+
+import (
+	"encoding/json"
+	"stress-relief-ai-chat-back/internal/domain"
+	"strings"
+	"testing"
+)
+
+func TestV1UserDataOmitsKeyID(t *testing.T) {
+	userData := &domain.UserData{UserID: "user", ThreadID: ptr("thread"), KeyID: ptr("k1")}
+	body, err := json.Marshal(v1Presenter{}.UserData(userData))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if strings.Contains(string(body), "key_id") || strings.Contains(string(body), "k1") {
+		t.Fatalf("UserData: got %s, want no key id", body)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,148 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"stress-relief-ai-chat-back/internal/domain"
+	"time"
+)
+
+// The DTOs of v2 name every field in camelCase, and carry the quota of the
+// user in chat responses along with the quota headers.
+
+type v2ChatResponse struct {
+	Content  string `json:"content"`
+	ThreadID string `json:"threadId"`
+	// Quota is null for plans without a quota
+	Quota *v2Quota `json:"quota"`
+}
+
+type v2Quota struct {
+	Plan      string    `json:"plan"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"resetAt"`
+}
+
+type v2UserData struct {
+	UserID        string     `json:"userId"`
+	ThreadID      *string    `json:"threadId"`
+	Plan          string     `json:"plan"`
+	LastActiveAt  *time.Time `json:"lastActiveAt"`
+	RetentionDays *int       `json:"retentionDays"`
+}
+
+type v2DataExport struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"userId"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+type v2DeletionReceipt struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	DeletedAt time.Time `json:"deletedAt"`
+	Erased    []string  `json:"erased"`
+	Retained  []string  `json:"retained"`
+	Signature string    `json:"signature"`
+}
+
+type v2GuestSession struct {
+	UserID    string    `json:"userId"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type v2SystemStatus struct {
+	Version       string               `json:"version"`
+	Commit        string               `json:"commit"`
+	StartedAt     time.Time            `json:"startedAt"`
+	UptimeSeconds int64                `json:"uptimeSeconds"`
+	Ready         bool                 `json:"ready"`
+	Dependencies  []v2DependencyStatus `json:"dependencies"`
+}
+
+type v2DependencyStatus struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	LatencyMs int64     `json:"latencyMs"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+type v2Presenter struct{}
+
+func (v2Presenter) ChatResponse(resp *domain.ChatResponse) any {
+	dto := v2ChatResponse{Content: resp.Content, ThreadID: resp.ThreadID}
+	if q := resp.Quota; q != nil && !q.Unlimited() {
+		dto.Quota = &v2Quota{Plan: string(q.Plan), Limit: q.Limit, Remaining: q.Remaining, ResetAt: q.ResetAt}
+	}
+	return dto
+}
+
+func (v2Presenter) UserData(userData *domain.UserData) any {
+	return v2UserData{
+		UserID:        userData.UserID,
+		ThreadID:      userData.ThreadID,
+		Plan:          string(userData.EffectivePlan()),
+		LastActiveAt:  userData.LastActiveAt,
+		RetentionDays: userData.RetentionDays,
+	}
+}
+
+func (v2Presenter) DataExport(export *domain.DataExport) any {
+	return v2DataExport{
+		ID:          export.ID,
+		UserID:      export.UserID,
+		Status:      string(export.Status),
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt,
+		Error:       export.Error,
+	}
+}
+
+func (v2Presenter) DeletionReceipt(receipt *domain.DeletionReceipt) any {
+	return v2DeletionReceipt{
+		ID:        receipt.ID,
+		UserID:    receipt.UserID,
+		DeletedAt: receipt.DeletedAt,
+		Erased:    receipt.Erased,
+		Retained:  receipt.Retained,
+		Signature: receipt.Signature,
+	}
+}
+
+func (v2Presenter) GuestSession(session *domain.GuestSession) any {
+	return v2GuestSession{UserID: session.UserID, Token: session.Token, ExpiresAt: session.ExpiresAt}
+}
+
+func (v2Presenter) SystemStatus(status *domain.SystemStatus) any {
+	deps := make([]v2DependencyStatus, 0, len(status.Dependencies))
+	for _, d := range status.Dependencies {
+		deps = append(deps, v2DependencyStatus{Name: d.Name, Status: d.Status, LatencyMs: d.LatencyMs, CheckedAt: d.CheckedAt})
+	}
+	return v2SystemStatus{
+		Version:       status.Version,
+		Commit:        status.Commit,
+		StartedAt:     status.StartedAt,
+		UptimeSeconds: status.UptimeSeconds,
+		Ready:         status.Ready,
+		Dependencies:  deps,
+	}
+}
+
+func (v2Presenter) ParseDeletionReceipt(c *fiber.Ctx) (*domain.DeletionReceipt, error) {
+	var receipt v2DeletionReceipt
+	if err := c.BodyParser(&receipt); err != nil {
+		return nil, err
+	}
+	return &domain.DeletionReceipt{
+		ID:        receipt.ID,
+		UserID:    receipt.UserID,
+		DeletedAt: receipt.DeletedAt,
+		Erased:    receipt.Erased,
+		Retained:  receipt.Retained,
+		Signature: receipt.Signature,
+	}, nil
+}
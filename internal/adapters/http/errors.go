@@ -0,0 +1,135 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"strings"
+)
+
+// errNoPrincipal is returned when an authenticated route is served without the
+// principal the auth middleware stores, which is a bug of the routing.
+var errNoPrincipal = errors.New("could not get principal from context")
+
+// errInvalidBody is returned when the body of a request can't be parsed.
+var errInvalidBody = domain.NewError(domain.ErrInvalidInput, "Invalid request body")
+
+// errorResponse is the body of every error response.
+type errorResponse struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"requestId,omitempty"`
+	Retryable bool   `json:"retryable"`
+}
+
+// errorStatuses maps the kinds of the domain error catalog to HTTP statuses.
+// Errors of no kind are internal server errors.
+var errorStatuses = map[error]int{
+	domain.ErrInvalidInput:   fiber.StatusBadRequest,
+	domain.ErrUnauthorized:   fiber.StatusUnauthorized,
+	domain.ErrForbidden:      fiber.StatusForbidden,
+	domain.ErrNotFound:       fiber.StatusNotFound,
+	domain.ErrConflict:       fiber.StatusConflict,
+	domain.ErrAccountDeleted: fiber.StatusGone,
+	domain.ErrQuotaExceeded:  fiber.StatusTooManyRequests,
+	domain.ErrRateLimited:    fiber.StatusTooManyRequests,
+	domain.ErrUnavailable:    fiber.StatusServiceUnavailable,
+}
+
+// fiberErrorCodes maps the statuses of the errors raised by Fiber itself, such
+// as unmatched routes or oversized bodies, to error codes.
+var fiberErrorCodes = map[int]string{
+	fiber.StatusBadRequest:            "invalid_input",
+	fiber.StatusNotFound:              "not_found",
+	fiber.StatusMethodNotAllowed:      "method_not_allowed",
+	fiber.StatusRequestEntityTooLarge: "payload_too_large",
+	fiber.StatusUnsupportedMediaType:  "unsupported_media_type",
+	fiber.StatusServiceUnavailable:    "unavailable",
+}
+
+// validationError describes the first field of a request that failed
+// validation, named as in its JSON body.
+func validationError(err error) error {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) || len(validationErrs) == 0 {
+		return domain.WrapError(domain.ErrInvalidInput, "Invalid request body", err)
+	}
+	fe := validationErrs[0]
+	var msg string
+	switch fe.Tag() {
+	case "required":
+		msg = fmt.Sprintf("%s is required", fe.Field())
+	case "oneof":
+		msg = fmt.Sprintf("%s must be one of: %s", fe.Field(), strings.ReplaceAll(fe.Param(), " ", ", "))
+	case "gt":
+		msg = fmt.Sprintf("%s must be greater than %s", fe.Field(), fe.Param())
+	default:
+		msg = fmt.Sprintf("%s is invalid", fe.Field())
+	}
+	return domain.WrapError(domain.ErrInvalidInput, msg, err)
+}
+
+// errorStatus returns the status err is answered with.
+func errorStatus(err error) int {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code
+	}
+	if status, ok := errorStatuses[domain.KindOf(err).Err]; ok {
+		return status
+	}
+	return fiber.StatusInternalServerError
+}
+
+// newErrorResponse builds the response of err, which only holds details that
+// are safe to show to users.
+func newErrorResponse(err error) errorResponse {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		code, ok := fiberErrorCodes[fiberErr.Code]
+		if !ok {
+			code = domain.ErrorKindInternal.Code
+			if fiberErr.Code < fiber.StatusInternalServerError {
+				code = "invalid_input"
+			}
+		}
+		return errorResponse{
+			Code:      code,
+			Message:   fiberErr.Message,
+			Retryable: fiberErr.Code == fiber.StatusServiceUnavailable,
+		}
+	}
+
+	kind := domain.KindOf(err)
+	return errorResponse{
+		Code:      kind.Code,
+		Message:   domain.UserMessage(err),
+		Retryable: kind.Retryable,
+	}
+}
+
+// errorHandler answers the requests that failed with a JSON error response.
+// Failures of the service are logged with the details of the error, which
+// are never sent to the client.
+func errorHandler(logger ports.Logger) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		status := errorStatus(err)
+		resp := newErrorResponse(err)
+		resp.RequestID, _ = c.Locals("requestID").(string)
+
+		if status >= fiber.StatusInternalServerError {
+			logger.Error(c.UserContext(), "Request failed",
+				"method", utils.CopyString(c.Method()),
+				"path", utils.CopyString(c.Path()),
+				"status", status,
+				"error", err.Error(),
+			)
+		}
+
+		return c.Status(status).JSON(resp)
+	}
+}
@@ -1,74 +1,257 @@
 package http
 
 import (
+	"errors"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v4"
-	"os"
+	"reflect"
+	"strconv"
 	"stress-relief-ai-chat-back/internal/domain"
 	"stress-relief-ai-chat-back/internal/ports"
 	"strings"
+	"time"
 )
 
+// Config holds the settings of the HTTP handler.
+type Config struct {
+	// AdminAPIKey grants access to the admin routes when sent in the
+	// X-Admin-API-Key header. Access by key is disabled when empty.
+	AdminAPIKey string
+	// AdminRole is the application role that grants access to the admin
+	// routes to authenticated users. Access by role is disabled when empty.
+	AdminRole string
+	// AuthWebhookSecret authenticates the Supabase Auth webhook, sent in the
+	// X-Webhook-Secret header. The webhook is only registered when it is not
+	// empty.
+	AuthWebhookSecret string
+	// ExportWait is how long a data export request waits for the export to be
+	// generated before answering with a location to poll instead.
+	ExportWait time.Duration
+	// LegacySunset is when the unversioned /api routes, deprecated aliases of
+	// the /api/v1 routes, stop being served. Not announced when zero.
+	LegacySunset time.Time
+	// RateLimits holds the rate limit of each route group, keyed by group name
+	// (see the RouteGroup constants). Groups without an entry are not limited.
+	RateLimits map[string]domain.RateLimit
+	// V1Deprecation deprecates the /api/v1 routes in favor of /api/v2 when
+	// set.
+	V1Deprecation *Deprecation
+}
+
+// Route groups that can be configured with a rate limit.
+const (
+	RouteGroupAdmin    = "admin"
+	RouteGroupMe       = "me"
+	RouteGroupMessages = "messages"
+	RouteGroupPublic   = "public"
+	RouteGroupSessions = "sessions"
+)
+
+// Services groups the ports the handler serves requests with.
+type Services struct {
+	Account        ports.AccountService
+	Admin          ports.AdminService
+	Audit          ports.AuditLogger
+	Auth           ports.AuthPort
+	Chat           ports.ChatService
+	Export         ports.ExportService
+	Health         ports.HealthService
+	Metrics        ports.Metrics
+	Quota          ports.QuotaService
+	RateLimitStore ports.RateLimitStore
+	Retention      ports.RetentionService
+	// Session is optional, the guest session routes are only registered when
+	// it is set.
+	Session ports.SessionService
+	Tracer  ports.Tracer
+	// UserDataCache is optional, the cache stats route is only registered when
+	// it is set.
+	UserDataCache ports.CacheStatsReporter
+	// LogLevel is optional, the log level routes are only registered when it
+	// is set.
+	LogLevel ports.LogLevelController
+}
+
 type Handler struct {
-	chatService ports.ChatService
-	logger      ports.Logger
-	validator   *validator.Validate
+	config    Config
+	logger    ports.Logger
+	services  Services
+	validator *validator.Validate
+	versions  []*apiVersion
 }
 
-func NewHandler(chatService ports.ChatService, logger ports.Logger) *Handler {
+func NewHandler(services Services, logger ports.Logger, config Config) *Handler {
 	h := &Handler{
-		chatService: chatService,
-		logger:      logger,
-		validator:   validator.New(),
+		config:    config,
+		logger:    logger,
+		services:  services,
+		validator: newValidator(),
+		versions:  newAPIVersions(config),
+	}
+	if h.services.Account == nil {
+		panic("Cannot create handler without an AccountService")
 	}
-	if h.chatService == nil {
+	if h.services.Admin == nil {
+		panic("Cannot create handler without an AdminService")
+	}
+	if h.services.Audit == nil {
+		panic("Cannot create handler without an AuditLogger")
+	}
+	if h.services.Auth == nil {
+		panic("Cannot create handler without an AuthPort")
+	}
+	if h.services.Chat == nil {
 		panic("Cannot create handler without a ChatService")
 	}
+	if h.services.Export == nil {
+		panic("Cannot create handler without an ExportService")
+	}
+	if h.services.Health == nil {
+		panic("Cannot create handler without a HealthService")
+	}
+	if h.services.Metrics == nil {
+		panic("Cannot create handler without a Metrics")
+	}
+	if h.services.Quota == nil {
+		panic("Cannot create handler without a QuotaService")
+	}
+	if h.services.RateLimitStore == nil {
+		panic("Cannot create handler without a RateLimitStore")
+	}
+	if h.services.Retention == nil {
+		panic("Cannot create handler without a RetentionService")
+	}
+	if h.services.Tracer == nil {
+		panic("Cannot create handler without a Tracer")
+	}
 	if h.logger == nil {
 		panic("Cannot create handler without a Logger")
 	}
 	return h
 }
 
+// newValidator creates a validator reporting the fields of requests by their
+// JSON name.
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
+	return v
+}
+
 func (h *Handler) SetupRoutes(app *fiber.App) {
+	app.Use(h.requestIDMiddleware, h.tracingMiddleware, h.metricsMiddleware)
+
+	// Probes
+	app.Get("/healthz", h.handleHealthz)
+	app.Get("/readyz", h.handleReadyz)
+
+	// Client routes, served by every version of the API
+	for _, v := range h.versions {
+		h.setupVersionRoutes(app, v)
+	}
+
 	api := app.Group("/api")
 
+	// Documentation
+	api.Get("/openapi.json", h.handleGetOpenAPISpec)
+	api.Get("/docs", h.handleGetDocs)
+
+	// Webhooks
+	if h.config.AuthWebhookSecret != "" {
+		api.Post("/webhooks/supabase/auth", h.webhookMiddleware, h.handleAuthWebhook)
+	}
+
+	// Admin routes
+	admin := api.Group("/admin")
+	admin.Use(h.rateLimitMiddleware(RouteGroupAdmin), h.adminMiddleware)
+	admin.Get("/users/:userID", h.handleGetUser)
+	admin.Delete("/users/:userID/thread", h.handleResetThread)
+	admin.Put("/users/:userID/plan", h.handleSetPlan)
+	admin.Delete("/users/:userID/usage", h.handleResetUsage)
+	admin.Get("/usage", h.handleGetUsage)
+	admin.Get("/safety-events", h.handleListSafetyEvents)
+	admin.Get("/audit", h.handleQueryAuditLog)
+	if h.services.UserDataCache != nil {
+		admin.Get("/cache", h.handleGetCacheStats)
+	}
+	if h.services.LogLevel != nil {
+		admin.Get("/log-level", h.handleGetLogLevel)
+		admin.Put("/log-level", h.handleSetLogLevel)
+	}
+}
+
+// setupVersionRoutes registers the routes of a version of the API under its
+// prefix. The middlewares are registered on each route rather than on the
+// prefix, since the prefix of the unversioned routes is shared with the other
+// versions.
+func (h *Handler) setupVersionRoutes(app *fiber.App, v *apiVersion) {
+	version := h.versionMiddleware(v)
+	api := app.Group(v.prefix)
+	api.Get("/status", version, h.rateLimitMiddleware(RouteGroupPublic), h.handleGetStatus)
+
 	// Chat routes
 	chat := api.Group("/messages")
-	chat.Use(h.authMiddleware)
+	chat.Use(version, h.authMiddleware, h.rateLimitMiddleware(RouteGroupMessages))
 	chat.Post("/", h.handleMessage)
+
+	// Routes on the data of the authenticated user
+	me := api.Group("/me")
+	me.Use(version, h.authMiddleware, h.rateLimitMiddleware(RouteGroupMe))
+	me.Post("/export", h.handleStartExport)
+	if v.getStartsExport {
+		me.Get("/export", h.handleStartExport)
+	}
+	me.Get("/export/:exportID", h.handleGetExport)
+	me.Put("/retention", h.handleSetRetention)
+	me.Delete("/", h.handleDeleteAccount)
+
+	api.Post("/deletion-receipts/verify", version, h.rateLimitMiddleware(RouteGroupPublic), h.handleVerifyDeletionReceipt)
+
+	// Session routes
+	if h.services.Session != nil {
+		sessions := api.Group("/sessions")
+		sessions.Post("/guest", version, h.rateLimitMiddleware(RouteGroupSessions), h.handleCreateGuestSession)
+		sessions.Post("/link", version, h.authMiddleware, h.rateLimitMiddleware(RouteGroupSessions), h.handleLinkGuestSession)
+	}
 }
 
 func (h *Handler) authMiddleware(c *fiber.Ctx) error {
+	if _, err := h.authenticate(c); err != nil {
+		return err
+	}
+	return c.Next()
+}
+
+// authenticate verifies the bearer token of the request and stores the
+// principal in the request locals.
+func (h *Handler) authenticate(c *fiber.Ctx) (*domain.Principal, error) {
 	token := c.Get("Authorization")
 	if token == "" {
-		return fiber.NewError(fiber.StatusUnauthorized, "Missing authorization token")
+		return nil, domain.NewError(domain.ErrUnauthorized, "Missing authorization token")
 	}
 
 	token = strings.TrimPrefix(token, "Bearer ")
 
-	parsedToken, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
-		// Validate the signing method
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid signing method")
-		}
-		// Return the secret key for validation
-		return []byte(os.Getenv("SUPABASE_JWT_SECRET")), nil
-	})
-
-	if err != nil || !parsedToken.Valid {
-		return fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired token")
+	principal, err := h.services.Auth.Authenticate(c.UserContext(), token)
+	switch {
+	case errors.Is(err, domain.ErrAccountDeleted):
+		return nil, domain.WrapError(domain.ErrAccountDeleted, "", err)
+	case errors.Is(err, domain.ErrUnavailable):
+		return nil, domain.WrapError(domain.ErrUnavailable, "", err)
+	case err != nil:
+		return nil, domain.WrapError(domain.ErrUnauthorized, "Invalid or expired token", err)
 	}
 
-	claims, ok := parsedToken.Claims.(jwt.MapClaims)
-	if !ok || !parsedToken.Valid {
-		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token claims")
-	}
-
-	c.Locals("user", claims)
-	c.Locals("userID", claims["sub"])
-	return c.Next()
+	c.Locals("principal", principal)
+	c.Locals("userID", principal.UserID)
+	c.SetUserContext(domain.WithUserID(c.UserContext(), principal.UserID))
+	return principal, nil
 }
 
 func (h *Handler) handleMessage(c *fiber.Ctx) error {
@@ -77,26 +260,43 @@ func (h *Handler) handleMessage(c *fiber.Ctx) error {
 	}
 
 	if err := c.BodyParser(&req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+		return errInvalidBody
 	}
 
 	if err := h.validator.Struct(req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		return validationError(err)
 	}
 
 	chM := &domain.ChatMessage{
 		Content: req.Message,
 	}
 
-	userID, ok := c.Locals("userID").(string)
+	principal, ok := c.Locals("principal").(*domain.Principal)
 	if !ok {
-		h.logger.Error(c.Context(), "could not get user UserID from context")
-		return fiber.NewError(fiber.StatusInternalServerError, "Oops! Something went wrong")
+		return errNoPrincipal
 	}
-	resp, err := h.chatService.ProcessMessage(c.Context(), chM, userID)
+	resp, err := h.services.Chat.ProcessMessage(c.UserContext(), chM, principal)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		var quotaErr *domain.QuotaExceededError
+		if errors.As(err, &quotaErr) {
+			setQuotaHeaders(c, &quotaErr.Status)
+			retryAfter := int(time.Until(quotaErr.Status.ResetAt).Seconds()) + 1
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+		}
+		return err
 	}
 
-	return c.JSON(resp)
+	setQuotaHeaders(c, resp.Quota)
+	return c.JSON(h.version(c).presenter.ChatResponse(resp))
+}
+
+// setQuotaHeaders exposes the quota status of the user as response headers.
+// Nothing is set for unlimited plans.
+func setQuotaHeaders(c *fiber.Ctx, quota *domain.QuotaStatus) {
+	if quota == nil || quota.Unlimited() {
+		return
+	}
+	c.Set("X-Quota-Limit", strconv.Itoa(quota.Limit))
+	c.Set("X-Quota-Remaining", strconv.Itoa(quota.Remaining))
+	c.Set("X-Quota-Reset", strconv.FormatInt(quota.ResetAt.Unix(), 10))
 }
@@ -0,0 +1,23 @@
+package http
+
+import "github.com/gofiber/fiber/v2"
+
+// handleHealthz reports that the process is alive and serving requests. It
+// does not check the dependencies, so a failing dependency doesn't get the
+// process restarted.
+func (h *Handler) handleHealthz(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+// handleReadyz reports whether traffic should be routed to the service: it is
+// not shutting down and all its dependencies are up.
+func (h *Handler) handleReadyz(c *fiber.Ctx) error {
+	if !h.services.Health.Ready(c.UserContext()) {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"status": "not_ready"})
+	}
+	return c.JSON(fiber.Map{"status": "ready"})
+}
+
+func (h *Handler) handleGetStatus(c *fiber.Ctx) error {
+	return c.JSON(h.version(c).presenter.SystemStatus(h.services.Health.Status(c.UserContext())))
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"stress-relief-ai-chat-back/internal/domain"
+)
+
+func (h *Handler) handleStartExport(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return errNoPrincipal
+	}
+
+	export, err := h.services.Export.Start(c.UserContext(), userID, h.config.ExportWait)
+	if err != nil {
+		return err
+	}
+
+	return h.sendExport(c, export)
+}
+
+func (h *Handler) handleGetExport(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return errNoPrincipal
+	}
+
+	export, err := h.services.Export.Get(c.UserContext(), userID, c.Params("exportID"))
+	if err != nil {
+		return err
+	}
+
+	return h.sendExport(c, export)
+}
+
+// sendExport sends the archive of a ready export as a download, or the status
+// of the export and where to poll it otherwise.
+func (h *Handler) sendExport(c *fiber.Ctx, export *domain.DataExport) error {
+	v := h.version(c)
+	switch export.Status {
+	case domain.ExportStatusReady:
+		c.Set(fiber.HeaderContentType, "application/zip")
+		c.Set(fiber.HeaderContentDisposition,
+			fmt.Sprintf(`attachment; filename="stress-relief-export-%s.zip"`, export.CreatedAt.Format("2006-01-02")))
+		return c.Send(export.Archive)
+	case domain.ExportStatusPending:
+		c.Set(fiber.HeaderLocation, v.prefix+"/me/export/"+export.ID)
+		c.Set(fiber.HeaderRetryAfter, "5")
+		return c.Status(fiber.StatusAccepted).JSON(v.presenter.DataExport(export))
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(v.presenter.DataExport(export))
+	}
+}
+
+func (h *Handler) handleDeleteAccount(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return errNoPrincipal
+	}
+
+	receipt, err := h.services.Account.DeleteAccount(c.UserContext(), userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(h.version(c).presenter.DeletionReceipt(receipt))
+}
+
+func (h *Handler) handleVerifyDeletionReceipt(c *fiber.Ctx) error {
+	receipt, err := h.version(c).presenter.ParseDeletionReceipt(c)
+	if err != nil {
+		return errInvalidBody
+	}
+
+	return c.JSON(fiber.Map{
+		"valid": h.services.Account.VerifyReceipt(c.UserContext(), receipt),
+	})
+}
+
+func (h *Handler) handleSetRetention(c *fiber.Ctx) error {
+	var req struct {
+		Days int `json:"days" validate:"required,gt=0"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return errInvalidBody
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return validationError(err)
+	}
+
+	userID, ok := c.Locals("userID").(string)
+	if !ok {
+		return errNoPrincipal
+	}
+
+	userData, err := h.services.Retention.SetUserRetention(c.UserContext(), userID, req.Days)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(h.version(c).presenter.UserData(userData))
+}
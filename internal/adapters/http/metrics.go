@@ -0,0 +1,40 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"time"
+)
+
+// metricsMiddleware records the method, route, status and duration of every
+// request. Requests that match no route are recorded under a single route, to
+// keep the cardinality of the metrics bounded.
+func (h *Handler) metricsMiddleware(c *fiber.Ctx) error {
+	start := time.Now()
+	err := c.Next()
+
+	status := responseStatus(c, err)
+	// The method is backed by a buffer reused across requests, so it must be
+	// copied before it is kept as a label
+	h.services.Metrics.ObserveHTTPRequest(utils.CopyString(c.Method()), routeName(c, status), status, time.Since(start))
+	return err
+}
+
+// responseStatus returns the status the request is answered with. Errors are
+// turned into responses by the error handler once the middlewares return, so
+// the status is derived from the error when there is one.
+func responseStatus(c *fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+	return errorStatus(err)
+}
+
+// routeName returns the template of the route that served the request, or
+// "unmatched" when no route did.
+func routeName(c *fiber.Ctx, status int) string {
+	if status == fiber.StatusNotFound && c.Route().Path == "/" {
+		return "unmatched"
+	}
+	return c.Route().Path
+}
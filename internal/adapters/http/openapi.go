@@ -0,0 +1,40 @@
+package http
+
+import (
+	_ "embed"
+	"github.com/gofiber/fiber/v2"
+)
+
+// openAPISpec is the OpenAPI specification of the routes registered by
+// SetupRoutes. TestRoutesMatchOpenAPISpec keeps them from drifting apart.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// docsPage renders the specification with Swagger UI.
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Stress Relief AI Chat API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+func (h *Handler) handleGetOpenAPISpec(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	return c.Send(openAPISpec)
+}
+
+func (h *Handler) handleGetDocs(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.SendString(docsPage)
+}
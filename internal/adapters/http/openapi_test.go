@@ -0,0 +1,167 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"sort"
+	"stress-relief-ai-chat-back/internal/adapters/noop"
+	"stress-relief-ai-chat-back/internal/ports"
+	"strings"
+	"testing"
+)
+
+type testLogger struct{}
+
+func (testLogger) Close() error                                  { return nil }
+func (testLogger) Debug(context.Context, string, ...interface{}) {}
+func (testLogger) Info(context.Context, string, ...interface{})  {}
+func (testLogger) Warn(context.Context, string, ...interface{})  {}
+func (testLogger) Error(context.Context, string, ...interface{}) {}
+func (testLogger) Fatal(context.Context, string, ...interface{}) {}
+
+// The services are left unimplemented by default, tests implement the methods
+// the requests they send are served with.
+type (
+	stubAccount   struct{ ports.AccountService }
+	stubAdmin     struct{ ports.AdminService }
+	stubAudit     struct{ ports.AuditLogger }
+	stubAuth      struct{ ports.AuthPort }
+	stubCache     struct{ ports.CacheStatsReporter }
+	stubChat      struct{ ports.ChatService }
+	stubExport    struct{ ports.ExportService }
+	stubHealth    struct{ ports.HealthService }
+	stubLogLevel  struct{ ports.LogLevelController }
+	stubQuota     struct{ ports.QuotaService }
+	stubRateLimit struct{ ports.RateLimitStore }
+	stubRetention struct{ ports.RetentionService }
+	stubSession   struct{ ports.SessionService }
+)
+
+// stubServices returns the services required by the handler, none of which
+// serve requests.
+func stubServices() Services {
+	return Services{
+		Account:        stubAccount{},
+		Admin:          stubAdmin{},
+		Audit:          stubAudit{},
+		Auth:           stubAuth{},
+		Chat:           stubChat{},
+		Export:         stubExport{},
+		Health:         stubHealth{},
+		Metrics:        noop.NewMetrics(),
+		Quota:          stubQuota{},
+		RateLimitStore: stubRateLimit{},
+		Retention:      stubRetention{},
+		Tracer:         noop.NewTracer(),
+	}
+}
+
+// TestRoutesMatchOpenAPISpec checks the routes registered by SetupRoutes
+// against the specification clients are built from, with and without the
+// optional routes.
+func TestRoutesMatchOpenAPISpec(t *testing.T) {
+	for name, optional := range map[string]bool{"all routes": true, "required routes": false} {
+		t.Run(name, func(t *testing.T) {
+			services := stubServices()
+			// The service always serves the log level routes
+			services.LogLevel = stubLogLevel{}
+			var config Config
+			if optional {
+				services.Session = stubSession{}
+				services.UserDataCache = stubCache{}
+				config.AuthWebhookSecret = "secret"
+			}
+
+			app := fiber.New()
+			NewHandler(services, testLogger{}, config).SetupRoutes(app)
+			if err := checkRoutes(app); err != nil {
+				t.Fatalf("routes don't match the OpenAPI specification:\n%s", err)
+			}
+		})
+	}
+}
+
+// specMethods are the keys of a path item of the specification that describe
+// operations.
+var specMethods = []string{
+	fiber.MethodGet, fiber.MethodPut, fiber.MethodPost, fiber.MethodDelete,
+	fiber.MethodOptions, fiber.MethodHead, fiber.MethodPatch, fiber.MethodTrace,
+}
+
+// checkRoutes returns an error listing the routes of app missing from the
+// specification, and the operations of the specification app doesn't serve.
+// Operations marked with x-optional are only registered with some settings,
+// so they may be missing from app.
+func checkRoutes(app *fiber.App) error {
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		return fmt.Errorf("could not parse OpenAPI specification: %w", err)
+	}
+
+	// optional holds the operations of the specification, and whether they
+	// may be missing from app
+	optional := make(map[string]bool)
+	for path, item := range spec.Paths {
+		for _, method := range specMethods {
+			raw, ok := item[strings.ToLower(method)]
+			if !ok {
+				continue
+			}
+			var op struct {
+				Optional string `json:"x-optional"`
+			}
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return fmt.Errorf("could not parse OpenAPI operation %s %s: %w", method, path, err)
+			}
+			optional[method+" "+path] = op.Optional != ""
+		}
+	}
+
+	var errs []error
+	served := make(map[string]bool)
+	for _, route := range app.GetRoutes(true) {
+		// Fiber serves HEAD along with every GET route
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+		key := route.Method + " " + specPath(route.Path)
+		if served[key] {
+			continue
+		}
+		served[key] = true
+		if _, ok := optional[key]; !ok {
+			errs = append(errs, fmt.Errorf("route %s is not in the OpenAPI specification", key))
+		}
+	}
+	for key, isOptional := range optional {
+		if !served[key] && !isOptional {
+			errs = append(errs, fmt.Errorf("operation %s of the OpenAPI specification is not served", key))
+		}
+	}
+
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Error() < errs[j].Error()
+	})
+	return errors.Join(errs...)
+}
+
+// specPath converts the path of a Fiber route, such as /api/users/:userID/,
+// to an OpenAPI path, such as /api/users/{userID}.
+func specPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + strings.TrimSuffix(segment[1:], "?") + "}"
+		}
+	}
+	path = strings.Join(segments, "/")
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return path
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"math"
+	"strconv"
+	"stress-relief-ai-chat-back/internal/domain"
+	"time"
+)
+
+// rateLimitMiddleware enforces the rate limit configured for the given route
+// group. Requests are keyed by the authenticated user, so it must run after
+// authMiddleware on authenticated routes; unauthenticated requests are keyed
+// by client IP, as forwarded by a trusted proxy (see ServerConfig). It sets the RateLimit-* headers on every response.
+func (h *Handler) rateLimitMiddleware(group string) fiber.Handler {
+	limit, ok := h.config.RateLimits[group]
+	if !ok {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		key := "ip:" + c.IP()
+		if userID, ok := c.Locals("userID").(string); ok && userID != "" {
+			key = "user:" + userID
+		}
+
+		res, err := h.services.RateLimitStore.Take(c.UserContext(), group+":"+key, limit)
+		if err != nil {
+			// Fail open, a broken rate limit store must not take the API down
+			h.logger.Error(c.UserContext(), "could not take rate limit token", "group", group, "error", err.Error())
+			return c.Next()
+		}
+
+		c.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+		c.Set("RateLimit-Policy", strconv.Itoa(limit.Limit)+";w="+strconv.Itoa(ceilSeconds(limit.Period)))
+
+		if !res.Allowed {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			return domain.NewError(domain.ErrRateLimited, "")
+		}
+		return c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
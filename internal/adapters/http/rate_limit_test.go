@@ -0,0 +1,76 @@
+package http
+
+import (
+	"context"
+	"net/http/httptest"
+	"stress-relief-ai-chat-back/internal/domain"
+	"testing"
+	"time"
+)
+
+// recordingRateLimit refuses every request, recording the keys taken.
+type recordingRateLimit struct {
+	keys []string
+}
+
+func (r *recordingRateLimit) Take(_ context.Context, key string, limit domain.RateLimit) (*domain.RateLimitResult, error) {
+	r.keys = append(r.keys, key)
+	return &domain.RateLimitResult{Limit: limit.Limit, Reset: limit.Period, RetryAfter: limit.Period}, nil
+}
+
+// TestRateLimitKeyedByClientIP checks that unauthenticated requests are keyed
+// by the client address forwarded by trusted proxies only. The address of the
+// connections of app.Test is 0.0.0.0.
+func TestRateLimitKeyedByClientIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  ServerConfig
+		header  string
+		wantKey string
+	}{
+		{
+			name:    "no proxy",
+			config:  ServerConfig{},
+			header:  "203.0.113.7",
+			wantKey: "public:ip:0.0.0.0",
+		},
+		{
+			name:    "trusted proxy",
+			config:  ServerConfig{ProxyHeader: "X-Real-IP", TrustedProxies: []string{"0.0.0.0/8"}},
+			header:  "203.0.113.7",
+			wantKey: "public:ip:203.0.113.7",
+		},
+		{
+			name:    "untrusted proxy",
+			config:  ServerConfig{ProxyHeader: "X-Real-IP", TrustedProxies: []string{"10.0.0.0/8"}},
+			header:  "203.0.113.7",
+			wantKey: "public:ip:0.0.0.0",
+		},
+		{
+			name:    "invalid address",
+			config:  ServerConfig{ProxyHeader: "X-Real-IP", TrustedProxies: []string{"0.0.0.0"}},
+			header:  "not an address",
+			wantKey: "public:ip:0.0.0.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &recordingRateLimit{}
+			services := stubServices()
+			services.RateLimitStore = store
+			server := New(testLogger{}, tt.config)
+			NewHandler(services, testLogger{}, Config{
+				RateLimits: map[string]domain.RateLimit{RouteGroupPublic: {Limit: 1, Period: time.Minute}},
+			}).SetupRoutes(server.App)
+
+			req := httptest.NewRequest("GET", "/api/v2/status", nil)
+			req.Header.Set("X-Real-IP", tt.header)
+			if _, err := server.Test(req); err != nil {
+				t.Fatalf("Test: %v", err)
+			}
+			if len(store.keys) != 1 || store.keys[0] != tt.wantKey {
+				t.Fatalf("keys: got %q, want [%q]", store.keys, tt.wantKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"stress-relief-ai-chat-back/internal/domain"
+)
+
+// maxRequestIDLength bounds the length of a request id received from a client
+// or proxy, so it can't bloat the logs.
+const maxRequestIDLength = 128
+
+// requestIDMiddleware tags the request with an id, taken from the
+// X-Request-ID header when it is valid and generated otherwise. The id is
+// echoed in the response and carried by the user context of the request, so
+// the log lines and audit events of the request can be correlated.
+func (h *Handler) requestIDMiddleware(c *fiber.Ctx) error {
+	requestID := c.Get(fiber.HeaderXRequestID)
+	if !validRequestID(requestID) {
+		requestID = uuid.NewString()
+	}
+
+	c.Set(fiber.HeaderXRequestID, requestID)
+	c.Locals("requestID", requestID)
+	c.SetUserContext(domain.WithRequestID(c.UserContext(), requestID))
+	return c.Next()
+}
+
+// validRequestID accepts non empty ids of printable ASCII characters.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
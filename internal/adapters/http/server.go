@@ -3,17 +3,38 @@ package http
 import "github.com/gofiber/fiber/v2"
 import "github.com/gofiber/fiber/v2/middleware/recover"
 import "github.com/gofiber/fiber/v2/middleware/cors"
+import "stress-relief-ai-chat-back/internal/ports"
 
 type FiberServer struct {
 	*fiber.App
 }
 
-func New() *FiberServer {
+// ServerConfig holds the settings of the server.
+type ServerConfig struct {
+	// ProxyHeader is the header the reverse proxy sets to the client address,
+	// read by c.IP() on requests from TrustedProxies. Client addresses are
+	// taken from the connection when empty.
+	ProxyHeader string
+	// TrustedProxies are the addresses or CIDR ranges of the reverse proxies
+	// whose ProxyHeader is trusted.
+	TrustedProxies []string
+}
+
+// New creates the server, answering failed requests with JSON error responses
+// and logging the failures of the service with logger.
+func New(logger ports.Logger, config ServerConfig) *FiberServer {
 	server := &FiberServer{
 		App: fiber.New(fiber.Config{
 			ServerHeader: "text-to-api",
 			AppName:      "text-to-api",
 			BodyLimit:    2 * 1024 * 1024, // 2MB
+			ErrorHandler: errorHandler(logger),
+			// The header is ignored on requests from other addresses, and
+			// invalid values fall back to the address of the connection
+			ProxyHeader:             config.ProxyHeader,
+			EnableTrustedProxyCheck: true,
+			TrustedProxies:          config.TrustedProxies,
+			EnableIPValidation:      true,
 		}),
 	}
 
@@ -0,0 +1,45 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"stress-relief-ai-chat-back/internal/domain"
+)
+
+func (h *Handler) handleCreateGuestSession(c *fiber.Ctx) error {
+	// The guest sessions of a client share their quota, so the client is the
+	// address forwarded by a trusted proxy rather than the proxy itself. The
+	// address is copied, as the service may keep it beyond the request.
+	session, err := h.services.Session.CreateGuest(c.UserContext(), utils.CopyString(c.IP()))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(h.version(c).presenter.GuestSession(session))
+}
+
+func (h *Handler) handleLinkGuestSession(c *fiber.Ctx) error {
+	var req struct {
+		GuestToken string `json:"guestToken" validate:"required"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return errInvalidBody
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return validationError(err)
+	}
+
+	principal, ok := c.Locals("principal").(*domain.Principal)
+	if !ok {
+		return errNoPrincipal
+	}
+
+	userData, err := h.services.Session.LinkGuest(c.UserContext(), principal, req.GuestToken)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(h.version(c).presenter.UserData(userData))
+}
@@ -0,0 +1,45 @@
+package http
+
+import (
+	"context"
+	"net/http/httptest"
+	"stress-relief-ai-chat-back/internal/domain"
+	"testing"
+)
+
+// recordingSession creates guest sessions, recording their client keys.
+type recordingSession struct {
+	stubSession
+	clientKeys []string
+}
+
+func (s *recordingSession) CreateGuest(_ context.Context, clientKey string) (*domain.GuestSession, error) {
+	s.clientKeys = append(s.clientKeys, clientKey)
+	return &domain.GuestSession{UserID: "guest", Token: "token"}, nil
+}
+
+// TestGuestSessionKeyedByClientIP checks that the guest sessions created
+// behind a trusted proxy share the quota of the client, not of the proxy. The
+// address of the connections of app.Test is 0.0.0.0.
+func TestGuestSessionKeyedByClientIP(t *testing.T) {
+	session := &recordingSession{}
+	services := stubServices()
+	services.Session = session
+	server := New(testLogger{}, ServerConfig{ProxyHeader: "X-Real-IP", TrustedProxies: []string{"0.0.0.0"}})
+	NewHandler(services, testLogger{}, Config{}).SetupRoutes(server.App)
+
+	for _, header := range []string{"203.0.113.7", "203.0.113.8"} {
+		req := httptest.NewRequest("POST", "/api/v2/sessions/guest", nil)
+		req.Header.Set("X-Real-IP", header)
+		resp, err := server.Test(req)
+		if err != nil {
+			t.Fatalf("Test: %v", err)
+		}
+		if resp.StatusCode != 201 {
+			t.Fatalf("status: got %d, want 201", resp.StatusCode)
+		}
+	}
+	if len(session.clientKeys) != 2 || session.clientKeys[0] != "203.0.113.7" || session.clientKeys[1] != "203.0.113.8" {
+		t.Fatalf("client keys: got %q, want the forwarded addresses", session.clientKeys)
+	}
+}
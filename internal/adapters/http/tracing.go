@@ -0,0 +1,59 @@
+package http
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// tracingMiddleware records a span for every request, continuing the trace
+// of the caller when the request carries W3C trace context headers. The span
+// is carried by the user context of the request, so the work done to serve it
+// is recorded in child spans.
+func (h *Handler) tracingMiddleware(c *fiber.Ctx) error {
+	method := utils.CopyString(c.Method())
+	ctx := h.services.Tracer.Extract(c.UserContext(), requestCarrier{c})
+	ctx, span := h.services.Tracer.Start(ctx, "HTTP "+method,
+		"http.request.method", method,
+		"url.path", utils.CopyString(c.Path()),
+		"request_id", c.Locals("requestID"),
+	)
+	c.SetUserContext(ctx)
+	err := c.Next()
+
+	status := responseStatus(c, err)
+	route := routeName(c, status)
+	span.SetName(method + " " + route)
+	span.SetAttributes("http.route", route, "http.response.status_code", status)
+	// Client errors are the caller's, only failures of the service mark the
+	// span as failed
+	var spanErr error
+	if status >= fiber.StatusInternalServerError {
+		spanErr = err
+		if spanErr == nil {
+			spanErr = fmt.Errorf("status %d", status)
+		}
+	}
+	span.End(spanErr)
+	return err
+}
+
+// requestCarrier reads the trace context headers of a request.
+type requestCarrier struct {
+	c *fiber.Ctx
+}
+
+func (r requestCarrier) Get(key string) string {
+	return r.c.Get(key)
+}
+
+// Set does nothing, trace context is only read from requests.
+func (r requestCarrier) Set(string, string) {}
+
+func (r requestCarrier) Keys() []string {
+	var keys []string
+	r.c.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
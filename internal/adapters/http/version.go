@@ -0,0 +1,103 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+	"stress-relief-ai-chat-back/internal/domain"
+	"strings"
+	"time"
+)
+
+// legacyDeprecatedAt is when the unversioned /api routes were deprecated in
+// favor of /api/v1.
+var legacyDeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// httpDateFormat is the format of dates in HTTP headers.
+const httpDateFormat = "Mon, 02 Jan 2006 15:04:05 GMT"
+
+// Deprecation announces that a version of the API is being phased out, with
+// the Deprecation, Sunset and Link headers of its responses.
+type Deprecation struct {
+	// At is when the version was deprecated.
+	At time.Time
+	// Sunset is when the version stops being served. Not announced when zero.
+	Sunset time.Time
+}
+
+// presenter converts the domain types to the DTOs of a version of the API, so
+// that the responses of a version don't change along with the domain.
+type presenter interface {
+	ChatResponse(resp *domain.ChatResponse) any
+	DataExport(export *domain.DataExport) any
+	DeletionReceipt(receipt *domain.DeletionReceipt) any
+	GuestSession(session *domain.GuestSession) any
+	SystemStatus(status *domain.SystemStatus) any
+	UserData(userData *domain.UserData) any
+	// ParseDeletionReceipt reads a deletion receipt from the body of a request.
+	ParseDeletionReceipt(c *fiber.Ctx) (*domain.DeletionReceipt, error)
+}
+
+// apiVersion is a version of the API, served under its own prefix.
+type apiVersion struct {
+	prefix    string
+	presenter presenter
+	// deprecation is set when the version is deprecated, in favor of the
+	// version served under successor
+	deprecation *Deprecation
+	successor   string
+	// getStartsExport keeps starting exports with GET /me/export, as the
+	// clients of the version did before it was made a POST
+	getStartsExport bool
+}
+
+// newAPIVersions returns the versions of the API. The unversioned routes are
+// the routes of v1 from before the API was versioned, kept as deprecated
+// aliases.
+func newAPIVersions(config Config) []*apiVersion {
+	return []*apiVersion{
+		{
+			prefix:          "/api",
+			presenter:       v1Presenter{},
+			deprecation:     &Deprecation{At: legacyDeprecatedAt, Sunset: config.LegacySunset},
+			successor:       "/api/v1",
+			getStartsExport: true,
+		},
+		{
+			prefix:          "/api/v1",
+			presenter:       v1Presenter{},
+			deprecation:     config.V1Deprecation,
+			successor:       "/api/v2",
+			getStartsExport: true,
+		},
+		{
+			prefix:    "/api/v2",
+			presenter: v2Presenter{},
+		},
+	}
+}
+
+// versionMiddleware tags the request with the version of the API serving it,
+// and announces its deprecation.
+func (h *Handler) versionMiddleware(v *apiVersion) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals("apiVersion", v)
+		if d := v.deprecation; d != nil {
+			c.Set("Deprecation", "@"+strconv.FormatInt(d.At.Unix(), 10))
+			if !d.Sunset.IsZero() {
+				c.Set("Sunset", d.Sunset.UTC().Format(httpDateFormat))
+			}
+			successor := v.successor + strings.TrimPrefix(c.Path(), v.prefix)
+			c.Set(fiber.HeaderLink, `</api/docs>; rel="deprecation"; type="text/html", <`+successor+`>; rel="successor-version"`)
+		}
+		return c.Next()
+	}
+}
+
+// version returns the version of the API serving the request.
+func (h *Handler) version(c *fiber.Ctx) *apiVersion {
+	if v, ok := c.Locals("apiVersion").(*apiVersion); ok {
+		return v
+	}
+	// Routes outside of the versions are served with the latest DTOs
+	return h.versions[len(h.versions)-1]
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"crypto/subtle"
+	"github.com/gofiber/fiber/v2"
+	"stress-relief-ai-chat-back/internal/domain"
+)
+
+// authWebhookPayload is the payload of a Supabase database webhook on the
+// auth.users table.
+type authWebhookPayload struct {
+	Type      string `json:"type"`
+	Schema    string `json:"schema"`
+	Table     string `json:"table"`
+	OldRecord *struct {
+		ID string `json:"id"`
+	} `json:"old_record"`
+}
+
+func (h *Handler) webhookMiddleware(c *fiber.Ctx) error {
+	secret := c.Get("X-Webhook-Secret")
+	if secret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(h.config.AuthWebhookSecret)) != 1 {
+		return domain.NewError(domain.ErrUnauthorized, "Invalid webhook secret")
+	}
+	return c.Next()
+}
+
+// handleAuthWebhook erases the data of users deleted from Supabase Auth.
+// Events other than deletions are acknowledged and ignored.
+func (h *Handler) handleAuthWebhook(c *fiber.Ctx) error {
+	var payload authWebhookPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return errInvalidBody
+	}
+
+	if payload.Type != "DELETE" || payload.Schema != "auth" || payload.Table != "users" {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+	if payload.OldRecord == nil || payload.OldRecord.ID == "" {
+		return domain.NewError(domain.ErrInvalidInput, "Missing deleted user id")
+	}
+
+	receipt, err := h.services.Account.PurgeUserData(c.UserContext(), payload.OldRecord.ID)
+	if err != nil {
+		// Supabase retries failed webhooks, and purging is idempotent
+		return err
+	}
+
+	return c.JSON(receipt)
+}
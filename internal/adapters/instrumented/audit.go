@@ -0,0 +1,36 @@
+package instrumented
+
+import (
+	"context"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+type auditLogger struct {
+	observer
+	next ports.AuditLogger
+}
+
+// NewAuditLogger wraps next so its calls are recorded as calls to backend.
+func NewAuditLogger(next ports.AuditLogger, backend string, metrics ports.Metrics) (ports.AuditLogger, error) {
+	if next == nil {
+		return nil, fmt.Errorf("next can't be nil")
+	}
+	o, err := newObserver(backend, metrics)
+	if err != nil {
+		return nil, err
+	}
+	return &auditLogger{observer: o, next: next}, nil
+}
+
+func (a *auditLogger) Record(ctx context.Context, event *domain.AuditEvent) (err error) {
+	defer a.observe("record_audit_event", time.Now(), &err)
+	return a.next.Record(ctx, event)
+}
+
+func (a *auditLogger) Query(ctx context.Context, q domain.AuditQuery) (_ []domain.AuditEvent, err error) {
+	defer a.observe("query_audit_log", time.Now(), &err)
+	return a.next.Query(ctx, q)
+}
@@ -0,0 +1,40 @@
+package instrumented
+
+import (
+	"context"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+type cache struct {
+	observer
+	next ports.Cache
+}
+
+// NewCache wraps next so its calls are recorded as calls to backend.
+func NewCache(next ports.Cache, backend string, metrics ports.Metrics) (ports.Cache, error) {
+	if next == nil {
+		return nil, fmt.Errorf("next can't be nil")
+	}
+	o, err := newObserver(backend, metrics)
+	if err != nil {
+		return nil, err
+	}
+	return &cache{observer: o, next: next}, nil
+}
+
+func (c *cache) Get(ctx context.Context, key string) (_ []byte, _ bool, err error) {
+	defer c.observe("cache_get", time.Now(), &err)
+	return c.next.Get(ctx, key)
+}
+
+func (c *cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) (err error) {
+	defer c.observe("cache_set", time.Now(), &err)
+	return c.next.Set(ctx, key, value, ttl)
+}
+
+func (c *cache) Delete(ctx context.Context, key string) (err error) {
+	defer c.observe("cache_delete", time.Now(), &err)
+	return c.next.Delete(ctx, key)
+}
@@ -0,0 +1,4 @@
+// Package instrumented provides decorators of the storage ports that record
+// the latency and errors of every call with ports.Metrics, for the backends
+// that don't record them themselves as the Supabase client does.
+package instrumented
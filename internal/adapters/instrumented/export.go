@@ -0,0 +1,62 @@
+package instrumented
+
+import (
+	"context"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+type exportRepository struct {
+	observer
+	next ports.ExportRepository
+}
+
+// NewExportRepository wraps next so its calls are recorded as calls to
+// backend.
+func NewExportRepository(next ports.ExportRepository, backend string, metrics ports.Metrics) (ports.ExportRepository, error) {
+	if next == nil {
+		return nil, fmt.Errorf("next can't be nil")
+	}
+	o, err := newObserver(backend, metrics)
+	if err != nil {
+		return nil, err
+	}
+	return &exportRepository{observer: o, next: next}, nil
+}
+
+func (r *exportRepository) Insert(ctx context.Context, export *domain.DataExport) (err error) {
+	defer r.observe("insert_export", time.Now(), &err)
+	return r.next.Insert(ctx, export)
+}
+
+func (r *exportRepository) Update(ctx context.Context, export *domain.DataExport) (err error) {
+	defer r.observe("update_export", time.Now(), &err)
+	return r.next.Update(ctx, export)
+}
+
+func (r *exportRepository) Get(ctx context.Context, exportID string) (_ *domain.DataExport, err error) {
+	defer r.observe("get_export", time.Now(), &err)
+	return r.next.Get(ctx, exportID)
+}
+
+func (r *exportRepository) ListByUser(ctx context.Context, userID string) (_ []domain.DataExport, err error) {
+	defer r.observe("list_exports", time.Now(), &err)
+	return r.next.ListByUser(ctx, userID)
+}
+
+func (r *exportRepository) Delete(ctx context.Context, exportID string) (err error) {
+	defer r.observe("delete_export", time.Now(), &err)
+	return r.next.Delete(ctx, exportID)
+}
+
+func (r *exportRepository) DeleteByUser(ctx context.Context, userID string) (err error) {
+	defer r.observe("delete_exports", time.Now(), &err)
+	return r.next.DeleteByUser(ctx, userID)
+}
+
+func (r *exportRepository) DeleteCreatedBefore(ctx context.Context, t time.Time) (err error) {
+	defer r.observe("delete_expired_exports", time.Now(), &err)
+	return r.next.DeleteCreatedBefore(ctx, t)
+}
@@ -0,0 +1,35 @@
+package instrumented
+
+import (
+	"errors"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+// observer records the calls to a storage backend.
+type observer struct {
+	backend string
+	metrics ports.Metrics
+}
+
+func newObserver(backend string, metrics ports.Metrics) (observer, error) {
+	if backend == "" {
+		return observer{}, fmt.Errorf("backend can't be empty")
+	}
+	if metrics == nil {
+		return observer{}, fmt.Errorf("metrics can't be nil")
+	}
+	return observer{backend: backend, metrics: metrics}, nil
+}
+
+// observe records a call started at start, once it returned *err. A missing
+// entry is an answer, not a failure of the backend.
+func (o observer) observe(operation string, start time.Time, err *error) {
+	failure := *err
+	if errors.Is(failure, domain.ErrNotFound) {
+		failure = nil
+	}
+	o.metrics.ObserveStorageCall(o.backend, operation, time.Since(start), failure)
+}
@@ -0,0 +1,31 @@
+package instrumented
+
+import (
+	"context"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+type rateLimitStore struct {
+	observer
+	next ports.RateLimitStore
+}
+
+// NewRateLimitStore wraps next so its calls are recorded as calls to backend.
+func NewRateLimitStore(next ports.RateLimitStore, backend string, metrics ports.Metrics) (ports.RateLimitStore, error) {
+	if next == nil {
+		return nil, fmt.Errorf("next can't be nil")
+	}
+	o, err := newObserver(backend, metrics)
+	if err != nil {
+		return nil, err
+	}
+	return &rateLimitStore{observer: o, next: next}, nil
+}
+
+func (s *rateLimitStore) Take(ctx context.Context, key string, limit domain.RateLimit) (_ *domain.RateLimitResult, err error) {
+	defer s.observe("take_rate_limit_token", time.Now(), &err)
+	return s.next.Take(ctx, key, limit)
+}
@@ -0,0 +1,42 @@
+package instrumented
+
+import (
+	"context"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+type safetyEventRepository struct {
+	observer
+	next ports.SafetyEventRepository
+}
+
+// NewSafetyEventRepository wraps next so its calls are recorded as calls to
+// backend.
+func NewSafetyEventRepository(next ports.SafetyEventRepository, backend string, metrics ports.Metrics) (ports.SafetyEventRepository, error) {
+	if next == nil {
+		return nil, fmt.Errorf("next can't be nil")
+	}
+	o, err := newObserver(backend, metrics)
+	if err != nil {
+		return nil, err
+	}
+	return &safetyEventRepository{observer: o, next: next}, nil
+}
+
+func (r *safetyEventRepository) Record(ctx context.Context, event *domain.SafetyEvent) (err error) {
+	defer r.observe("record_safety_event", time.Now(), &err)
+	return r.next.Record(ctx, event)
+}
+
+func (r *safetyEventRepository) List(ctx context.Context, limit int) (_ []domain.SafetyEvent, err error) {
+	defer r.observe("list_safety_events", time.Now(), &err)
+	return r.next.List(ctx, limit)
+}
+
+func (r *safetyEventRepository) DeleteByUser(ctx context.Context, userID string) (err error) {
+	defer r.observe("delete_safety_events", time.Now(), &err)
+	return r.next.DeleteByUser(ctx, userID)
+}
@@ -0,0 +1,36 @@
+package instrumented
+
+import (
+	"context"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+type tombstoneRepository struct {
+	observer
+	next ports.TombstoneRepository
+}
+
+// NewTombstoneRepository wraps next so its calls are recorded as calls to
+// backend.
+func NewTombstoneRepository(next ports.TombstoneRepository, backend string, metrics ports.Metrics) (ports.TombstoneRepository, error) {
+	if next == nil {
+		return nil, fmt.Errorf("next can't be nil")
+	}
+	o, err := newObserver(backend, metrics)
+	if err != nil {
+		return nil, err
+	}
+	return &tombstoneRepository{observer: o, next: next}, nil
+}
+
+func (r *tombstoneRepository) Add(ctx context.Context, key string) (err error) {
+	defer r.observe("add_tombstone", time.Now(), &err)
+	return r.next.Add(ctx, key)
+}
+
+func (r *tombstoneRepository) Exists(ctx context.Context, key string) (_ bool, err error) {
+	defer r.observe("check_tombstone", time.Now(), &err)
+	return r.next.Exists(ctx, key)
+}
@@ -0,0 +1,47 @@
+package instrumented
+
+import (
+	"context"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+type usageRepository struct {
+	observer
+	next ports.UsageRepository
+}
+
+// NewUsageRepository wraps next so its calls are recorded as calls to
+// backend.
+func NewUsageRepository(next ports.UsageRepository, backend string, metrics ports.Metrics) (ports.UsageRepository, error) {
+	if next == nil {
+		return nil, fmt.Errorf("next can't be nil")
+	}
+	o, err := newObserver(backend, metrics)
+	if err != nil {
+		return nil, err
+	}
+	return &usageRepository{observer: o, next: next}, nil
+}
+
+func (r *usageRepository) Increment(ctx context.Context, userID, day string, plan domain.Plan, limit int) (_ int, _ bool, err error) {
+	defer r.observe("increment_usage", time.Now(), &err)
+	return r.next.Increment(ctx, userID, day, plan, limit)
+}
+
+func (r *usageRepository) Decrement(ctx context.Context, userID, day string) (err error) {
+	defer r.observe("decrement_usage", time.Now(), &err)
+	return r.next.Decrement(ctx, userID, day)
+}
+
+func (r *usageRepository) Delete(ctx context.Context, userID string) (err error) {
+	defer r.observe("delete_usage", time.Now(), &err)
+	return r.next.Delete(ctx, userID)
+}
+
+func (r *usageRepository) Summary(ctx context.Context, day string) (_ *domain.UsageSummary, err error) {
+	defer r.observe("usage_summary", time.Now(), &err)
+	return r.next.Summary(ctx, day)
+}
@@ -0,0 +1,57 @@
+package instrumented
+
+import (
+	"context"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+type userDataHandler struct {
+	observer
+	next ports.UserDataAPIHandler
+}
+
+// NewUserDataHandler wraps next so its calls are recorded as calls to
+// backend.
+func NewUserDataHandler(next ports.UserDataAPIHandler, backend string, metrics ports.Metrics) (ports.UserDataAPIHandler, error) {
+	if next == nil {
+		return nil, fmt.Errorf("next can't be nil")
+	}
+	o, err := newObserver(backend, metrics)
+	if err != nil {
+		return nil, err
+	}
+	return &userDataHandler{observer: o, next: next}, nil
+}
+
+func (h *userDataHandler) GetByID(ctx context.Context, userID string) (_ *domain.UserData, err error) {
+	defer h.observe("get_user_data", time.Now(), &err)
+	return h.next.GetByID(ctx, userID)
+}
+
+func (h *userDataHandler) Insert(ctx context.Context, userID string, userData *domain.UserData) (err error) {
+	defer h.observe("insert_user_data", time.Now(), &err)
+	return h.next.Insert(ctx, userID, userData)
+}
+
+func (h *userDataHandler) Update(ctx context.Context, userID string, userData *domain.UserData) (err error) {
+	defer h.observe("update_user_data", time.Now(), &err)
+	return h.next.Update(ctx, userID, userData)
+}
+
+func (h *userDataHandler) Patch(ctx context.Context, userID string, patch domain.UserDataPatch) (err error) {
+	defer h.observe("patch_user_data", time.Now(), &err)
+	return h.next.Patch(ctx, userID, patch)
+}
+
+func (h *userDataHandler) Delete(ctx context.Context, userID string) (err error) {
+	defer h.observe("delete_user_data", time.Now(), &err)
+	return h.next.Delete(ctx, userID)
+}
+
+func (h *userDataHandler) List(ctx context.Context, filter domain.UserDataFilter) (_ []domain.UserData, err error) {
+	defer h.observe("list_user_data", time.Now(), &err)
+	return h.next.List(ctx, filter)
+}
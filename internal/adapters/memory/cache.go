@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"stress-relief-ai-chat-back/internal/ports"
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+type cache struct {
+	mu         sync.Mutex
+	maxEntries int
+	// lru holds the entries, most recently used first.
+	lru     *list.List
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+// NewCache creates a Cache that keeps up to maxEntries values in memory,
+// evicting the least recently used ones once full.
+func NewCache(maxEntries int) ports.Cache {
+	if maxEntries <= 0 {
+		panic("Cannot create cache without a positive size")
+	}
+	return &cache{
+		maxEntries: maxEntries,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+func (c *cache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := el.Value.(*cacheEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(el)
+		return nil, false, nil
+	}
+	c.lru.MoveToFront(el)
+	return bytes.Clone(entry.value), true, nil
+}
+
+func (c *cache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value = bytes.Clone(value)
+	expiresAt := c.now().Add(ttl)
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*cacheEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.lru.MoveToFront(el)
+		return nil
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry{
+		key:       key,
+		value:     value,
+		expiresAt: expiresAt,
+	})
+	for c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+	return nil
+}
+
+func (c *cache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	return nil
+}
+
+// remove drops an entry. It must be called with the lock held.
+func (c *cache) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*cacheEntry).key)
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCacheCopiesValues(t *testing.T) {
+	ctx := context.Background()
+	c := NewCache(10)
+
+	value := []byte("value")
+	if err := c.Set(ctx, "key", value, time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	value[0] = 'X'
+	got, ok, err := c.Get(ctx, "key")
+	if err != nil || !ok || string(got) != "value" {
+		t.Fatalf("Get after modifying the set value: got %q, %v, %v, want value", got, ok, err)
+	}
+
+	got[0] = 'X'
+	got, ok, err = c.Get(ctx, "key")
+	if err != nil || !ok || string(got) != "value" {
+		t.Fatalf("Get after modifying a got value: got %q, %v, %v, want value", got, ok, err)
+	}
+}
+
+func TestCacheExpires(t *testing.T) {
+	ctx := context.Background()
+	c := NewCache(10).(*cache)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	if err := c.Set(ctx, "key", []byte("value"), time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	now = now.Add(time.Minute - time.Nanosecond)
+	if _, ok, _ := c.Get(ctx, "key"); !ok {
+		t.Fatalf("Get before the TTL: got a miss, want a hit")
+	}
+	now = now.Add(time.Nanosecond)
+	if _, ok, _ := c.Get(ctx, "key"); ok {
+		t.Fatalf("Get after the TTL: got a hit, want a miss")
+	}
+	if len(c.entries) != 0 {
+		t.Fatalf("entries after expiry: got %d, want 0", len(c.entries))
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := NewCache(2)
+
+	for _, key := range []string{"a", "b"} {
+		if err := c.Set(ctx, key, []byte(key), time.Minute); err != nil {
+			t.Fatalf("Set %s: %v", key, err)
+		}
+	}
+	// Using a makes b the least recently used
+	if _, ok, _ := c.Get(ctx, "a"); !ok {
+		t.Fatalf("Get a: got a miss, want a hit")
+	}
+	if err := c.Set(ctx, "c", []byte("c"), time.Minute); err != nil {
+		t.Fatalf("Set c: %v", err)
+	}
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok, _ := c.Get(ctx, key); ok != want {
+			t.Fatalf("Get %s: got %v, want %v", key, ok, want)
+		}
+	}
+}
+
+func TestCacheDelete(t *testing.T) {
+	ctx := context.Background()
+	c := NewCache(10)
+
+	if err := c.Set(ctx, "key", []byte("value"), time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := c.Delete(ctx, "key"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok, _ := c.Get(ctx, "key"); ok {
+		t.Fatalf("Get after Delete: got a hit, want a miss")
+	}
+	if err := c.Delete(ctx, "missing"); err != nil {
+		t.Fatalf("Delete of a missing key: %v", err)
+	}
+}
@@ -0,0 +1,3 @@
+// Package memory provides in-memory implementations of repository ports, meant
+// for single instance deployments and local development.
+package memory
@@ -0,0 +1,177 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"sync"
+	"time"
+)
+
+type exportRepository struct {
+	mu           sync.Mutex
+	maxTotalSize int64
+	exports      map[string]*domain.DataExport
+	// size is the total size of the stored archives
+	size int64
+}
+
+// NewExportRepository creates an ExportRepository that keeps the exports in
+// memory, so they are only served by the instance that generated them and are
+// lost on restart. Up to maxTotalSize bytes of archives are kept, the oldest
+// archives being discarded to make room for new ones.
+func NewExportRepository(maxTotalSize int64) ports.ExportRepository {
+	if maxTotalSize <= 0 {
+		panic("Cannot create repository without a positive maxTotalSize")
+	}
+	return &exportRepository{
+		maxTotalSize: maxTotalSize,
+		exports:      make(map[string]*domain.DataExport),
+	}
+}
+
+func (r *exportRepository) Insert(ctx context.Context, export *domain.DataExport) error {
+	if export == nil {
+		return fmt.Errorf("can't insert nil export")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.exports[export.ID]; ok {
+		return fmt.Errorf("export %s already exists", export.ID)
+	}
+	r.store(export)
+	return nil
+}
+
+func (r *exportRepository) Update(ctx context.Context, export *domain.DataExport) error {
+	if export == nil {
+		return fmt.Errorf("can't update nil export")
+	}
+	size := int64(len(export.Archive))
+	if size > r.maxTotalSize {
+		return fmt.Errorf("export archive of %d bytes is larger than the exports kept", size)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.exports[export.ID]; !ok {
+		return fmt.Errorf("%w: export not found", domain.ErrNotFound)
+	}
+	r.remove(export.ID)
+
+	// Make room for the archive by discarding the oldest ones
+	if size > 0 {
+		for _, other := range r.sorted() {
+			if r.size+size <= r.maxTotalSize {
+				break
+			}
+			if other.Archive != nil {
+				r.remove(other.ID)
+			}
+		}
+	}
+	r.store(export)
+	return nil
+}
+
+func (r *exportRepository) Get(ctx context.Context, exportID string) (*domain.DataExport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	export, ok := r.exports[exportID]
+	if !ok {
+		return nil, fmt.Errorf("%w: export not found", domain.ErrNotFound)
+	}
+	return clone(export), nil
+}
+
+func (r *exportRepository) ListByUser(ctx context.Context, userID string) ([]domain.DataExport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	exports := []domain.DataExport{}
+	for _, export := range r.sorted() {
+		if export.UserID == userID {
+			e := *export
+			e.Archive = nil
+			exports = append(exports, e)
+		}
+	}
+	return exports, nil
+}
+
+func (r *exportRepository) Delete(ctx context.Context, exportID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.remove(exportID)
+	return nil
+}
+
+func (r *exportRepository) DeleteByUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, export := range r.exports {
+		if export.UserID == userID {
+			r.remove(id)
+		}
+	}
+	return nil
+}
+
+func (r *exportRepository) DeleteCreatedBefore(ctx context.Context, t time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, export := range r.exports {
+		if export.CreatedAt.Before(t) {
+			r.remove(id)
+		}
+	}
+	return nil
+}
+
+// sorted returns the stored exports, oldest first. It must be called with r.mu
+// held.
+func (r *exportRepository) sorted() []*domain.DataExport {
+	exports := make([]*domain.DataExport, 0, len(r.exports))
+	for _, export := range r.exports {
+		exports = append(exports, export)
+	}
+	slices.SortFunc(exports, func(a, b *domain.DataExport) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return exports
+}
+
+// store keeps a copy of export, so the caller can't change it. It must be
+// called with r.mu held.
+func (r *exportRepository) store(export *domain.DataExport) {
+	r.exports[export.ID] = clone(export)
+	r.size += int64(len(export.Archive))
+}
+
+// remove drops an export. It must be called with r.mu held.
+func (r *exportRepository) remove(id string) {
+	if export, ok := r.exports[id]; ok {
+		r.size -= int64(len(export.Archive))
+		delete(r.exports, id)
+	}
+}
+
+func clone(export *domain.DataExport) *domain.DataExport {
+	e := *export
+	e.Archive = bytes.Clone(export.Archive)
+	if export.CompletedAt != nil {
+		completedAt := *export.CompletedAt
+		e.CompletedAt = &completedAt
+	}
+	return &e
+}
@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"stress-relief-ai-chat-back/internal/domain"
+	"testing"
+	"time"
+)
+
+func TestExportRepositoryEvictsOldestArchives(t *testing.T) {
+	ctx := context.Background()
+	r := NewExportRepository(10)
+
+	created := time.Now().UTC()
+	for i, id := range []string{"a", "b", "c"} {
+		export := &domain.DataExport{ID: id, UserID: "user", Status: domain.ExportStatusPending,
+			CreatedAt: created.Add(time.Duration(i) * time.Second)}
+		if err := r.Insert(ctx, export); err != nil {
+			t.Fatalf("Insert %s: %v", id, err)
+		}
+		export.Status = domain.ExportStatusReady
+		export.Archive = []byte("12345")
+		if err := r.Update(ctx, export); err != nil {
+			t.Fatalf("Update %s: %v", id, err)
+		}
+	}
+
+	if _, err := r.Get(ctx, "a"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("Get of the oldest archive: got %v, want ErrNotFound", err)
+	}
+	for _, id := range []string{"b", "c"} {
+		if got, err := r.Get(ctx, id); err != nil || string(got.Archive) != "12345" {
+			t.Fatalf("Get %s: got %v, %v, want its archive", id, got, err)
+		}
+	}
+
+	if err := r.Update(ctx, &domain.DataExport{ID: "a"}); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("Update of a removed export: got %v, want ErrNotFound", err)
+	}
+	if err := r.Update(ctx, &domain.DataExport{ID: "b", Archive: make([]byte, 11)}); err == nil {
+		t.Fatalf("Update with an archive larger than the total: got nil, want an error")
+	}
+}
+
+func TestExportRepositoryCopiesExports(t *testing.T) {
+	ctx := context.Background()
+	r := NewExportRepository(10)
+
+	export := &domain.DataExport{ID: "a", UserID: "user", Archive: []byte("value"), CreatedAt: time.Now()}
+	if err := r.Insert(ctx, export); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	export.Archive[0] = 'X'
+	got, err := r.Get(ctx, "a")
+	if err != nil || string(got.Archive) != "value" {
+		t.Fatalf("Get after modifying the inserted export: got %v, %v, want value", got, err)
+	}
+
+	list, err := r.ListByUser(ctx, "user")
+	if err != nil || len(list) != 1 || list[0].Archive != nil {
+		t.Fatalf("ListByUser: got %v, %v, want the export without its archive", list, err)
+	}
+}
@@ -0,0 +1,24 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+)
+
+type identityProvider struct{}
+
+// NewIdentityProvider creates an IdentityProvider for deployments without an
+// external identity store. It holds no identities, so deleting one always
+// reports ErrNotFound.
+func NewIdentityProvider() ports.IdentityProvider {
+	return identityProvider{}
+}
+
+func (identityProvider) DeleteUser(_ context.Context, userID string) error {
+	if userID == "" {
+		return fmt.Errorf("can't delete user with empty userID")
+	}
+	return fmt.Errorf("%w: no identity store configured", domain.ErrNotFound)
+}
@@ -0,0 +1,3 @@
+// Package noop provides implementations of the observability ports that
+// discard everything, for when they are disabled.
+package noop
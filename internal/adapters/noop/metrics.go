@@ -0,0 +1,25 @@
+package noop
+
+import (
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+type metrics struct{}
+
+// NewMetrics creates a Metrics that records nothing.
+func NewMetrics() ports.Metrics {
+	return metrics{}
+}
+
+func (metrics) ObserveHTTPRequest(string, string, int, time.Duration) {}
+
+func (metrics) ObserveMessage(string) {}
+
+func (metrics) ObserveLLMCall(string, time.Duration, error) {}
+
+func (metrics) ObserveRun(string, int) {}
+
+func (metrics) AddTokens(string, int) {}
+
+func (metrics) ObserveStorageCall(string, string, time.Duration, error) {}
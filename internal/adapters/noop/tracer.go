@@ -0,0 +1,31 @@
+package noop
+
+import (
+	"context"
+	"stress-relief-ai-chat-back/internal/ports"
+)
+
+type tracer struct{}
+
+// NewTracer creates a Tracer that records nothing and propagates nothing.
+func NewTracer() ports.Tracer {
+	return tracer{}
+}
+
+func (tracer) Start(ctx context.Context, _ string, _ ...any) (context.Context, ports.Span) {
+	return ctx, span{}
+}
+
+func (tracer) Extract(ctx context.Context, _ ports.TraceCarrier) context.Context {
+	return ctx
+}
+
+func (tracer) Inject(context.Context, ports.TraceCarrier) {}
+
+type span struct{}
+
+func (span) SetName(string) {}
+
+func (span) SetAttributes(...any) {}
+
+func (span) End(error) {}
@@ -7,31 +7,73 @@ import (
 	"github.com/sashabaranov/go-openai"
 	"stress-relief-ai-chat-back/internal/domain"
 	"stress-relief-ai-chat-back/internal/ports"
+	"sync"
 	"time"
 )
 
+// LLM operations recorded in the metrics.
+const (
+	opCreateThread  = "create_thread"
+	opCreateMessage = "create_message"
+	opCreateRun     = "create_run"
+	opRetrieveRun   = "retrieve_run"
+	opListMessages  = "list_messages"
+	opDeleteThread  = "delete_thread"
+
+	opRetrieveAssistant = "retrieve_assistant"
+	opCancelRun         = "cancel_run"
+)
+
 type handler struct {
 	assistantID string
 	client      *openai.Client
 	logger      ports.Logger
+	metrics     ports.Metrics
+	tracer      ports.Tracer
+
+	// activeRuns holds the runs being waited for, by run id, so that they can
+	// be cancelled on shutdown
+	mu         sync.Mutex
+	activeRuns map[string]domain.RunRef
 }
 
-func NewOpenAIAdapter(apiKey, assistantID string, l ports.Logger) ports.ChatHandler {
+func NewOpenAIAdapter(apiKey, assistantID string, l ports.Logger, m ports.Metrics, t ports.Tracer) (ports.ChatHandler, error) {
 	if apiKey == "" {
-		panic("Cannot create OpenAI handler without an API key")
+		return nil, fmt.Errorf("apiKey can't be empty")
+	}
+	if assistantID == "" {
+		return nil, fmt.Errorf("assistantID can't be empty")
+	}
+	if l == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	if m == nil {
+		return nil, fmt.Errorf("metrics can't be nil")
+	}
+	if t == nil {
+		return nil, fmt.Errorf("tracer can't be nil")
 	}
-	h := &handler{
+	return &handler{
 		assistantID: assistantID,
 		client:      openai.NewClient(apiKey),
 		logger:      l,
+		metrics:     m,
+		tracer:      t,
+		activeRuns:  make(map[string]domain.RunRef),
+	}, nil
+}
+
+// startCall starts recording a call to the OpenAI API. The returned context
+// carries the span of the call, and the returned function must be called with
+// the result of the call once it returns.
+func (h *handler) startCall(ctx context.Context, operation string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := h.tracer.Start(ctx, "openai."+operation,
+		"thread_id", domain.ThreadID(ctx), "run_id", domain.RunID(ctx))
+	return ctx, func(err error) {
+		h.metrics.ObserveLLMCall(operation, time.Since(start), err)
+		span.End(err)
 	}
-	if h.assistantID == "" {
-		panic("Cannot create OpenAI handler without an Assistant UserID")
-	}
-	if h.logger == nil {
-		panic("Cannot create OpenAI handler without a Logger")
-	}
-	return h
 }
 
 // ProcessMessage
@@ -47,8 +89,9 @@ func (h *handler) ProcessMessage(ctx context.Context, message *domain.ChatMessag
 	if threadID == nil {
 		startCreateThread := time.Now().UTC()
 		// there's no open thread for the user, so create a new thread and run with the prompt
+		callCtx, done := h.startCall(ctx, opCreateThread)
 		run, err = h.client.CreateThreadAndRun(
-			ctx,
+			callCtx,
 			openai.CreateThreadAndRunRequest{
 				RunRequest: openai.RunRequest{
 					AssistantID: h.assistantID,
@@ -63,6 +106,7 @@ func (h *handler) ProcessMessage(ctx context.Context, message *domain.ChatMessag
 				},
 			},
 		)
+		done(err)
 		if err != nil {
 			h.logger.Error(ctx, "Error creating thread and run", "error", err)
 			return nil, fmt.Errorf("could not create thread and run: %w", err)
@@ -79,10 +123,12 @@ func (h *handler) ProcessMessage(ctx context.Context, message *domain.ChatMessag
 		// Todo: once CreateRun can receive an openai.RunRequest with additional messages, use that instead
 		// and get rid of this CreateMessage part
 		startCreateMessage := time.Now().UTC()
-		_, err = h.client.CreateMessage(ctx, *threadID, openai.MessageRequest{
+		callCtx, done := h.startCall(ctx, opCreateMessage)
+		_, err = h.client.CreateMessage(callCtx, *threadID, openai.MessageRequest{
 			Role:    string(openai.ThreadMessageRoleUser),
 			Content: message.Content,
 		})
+		done(err)
 		if err != nil {
 			h.logger.Error(ctx, "Error creating message", "error", err)
 			return nil, fmt.Errorf("could not create message: %w", err)
@@ -91,9 +137,11 @@ func (h *handler) ProcessMessage(ctx context.Context, message *domain.ChatMessag
 
 		// Run thread
 		startCreateRun := time.Now().UTC()
-		run, err = h.client.CreateRun(ctx, *threadID, openai.RunRequest{
+		callCtx, done = h.startCall(ctx, opCreateRun)
+		run, err = h.client.CreateRun(callCtx, *threadID, openai.RunRequest{
 			AssistantID: h.assistantID,
 		})
+		done(err)
 		if err != nil {
 			h.logger.Error(ctx, "Error creating run", "error", err)
 			return nil, fmt.Errorf("could not create run: %w", err)
@@ -101,16 +149,23 @@ func (h *handler) ProcessMessage(ctx context.Context, message *domain.ChatMessag
 		h.logger.Debug(ctx, "Run created", "time", time.Since(startCreateRun).String())
 	}
 
+	ctx = domain.WithRunID(domain.WithThreadID(ctx, run.ThreadID), run.ID)
 	startWaitForRunCompletion := time.Now().UTC()
-	err = waitForRunCompletion(ctx, h.client, run.ThreadID, run.ID, 500*time.Millisecond)
+	untrack := h.trackRun(run.ThreadID, run.ID)
+	run, err = h.waitForRunCompletion(ctx, run.ThreadID, run.ID, 500*time.Millisecond)
+	untrack()
 	if err != nil {
 		h.logger.Error(ctx, "Error waiting for run completion", "error", err)
 		return nil, fmt.Errorf("could not wait for run completion: %w", err)
 	}
 	h.logger.Debug(ctx, "Run completed", "time", time.Since(startWaitForRunCompletion).String())
+	h.metrics.AddTokens("prompt", run.Usage.PromptTokens)
+	h.metrics.AddTokens("completion", run.Usage.CompletionTokens)
 
-	messageList, err := h.client.ListMessage(ctx, run.ThreadID, domain.IntPtr(1),
+	callCtx, done := h.startCall(ctx, opListMessages)
+	messageList, err := h.client.ListMessage(callCtx, run.ThreadID, domain.IntPtr(1),
 		domain.StrPtr("desc"), nil, nil, domain.StrPtr(run.ID))
+	done(err)
 	if err != nil {
 		h.logger.Error(ctx, "Error listing messages", "error", err)
 		return nil, fmt.Errorf("could not list messages: %w", err)
@@ -139,33 +194,119 @@ func (h *handler) ProcessMessage(ctx context.Context, message *domain.ChatMessag
 
 }
 
+// trackRun records that a run is being waited for, until the returned
+// function is called.
+func (h *handler) trackRun(threadID, runID string) func() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.activeRuns[runID] = domain.RunRef{ThreadID: threadID, RunID: runID}
+	return func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.activeRuns, runID)
+	}
+}
+
+// CancelActiveRuns cancels the runs being waited for. Waiting for them ends
+// with an error once the cancellation is seen.
+func (h *handler) CancelActiveRuns(ctx context.Context) ([]domain.RunRef, error) {
+	h.mu.Lock()
+	runs := make([]domain.RunRef, 0, len(h.activeRuns))
+	for _, run := range h.activeRuns {
+		runs = append(runs, run)
+	}
+	h.mu.Unlock()
+
+	var (
+		cancelled []domain.RunRef
+		errs      []error
+	)
+	for _, run := range runs {
+		callCtx, done := h.startCall(domain.WithRunID(domain.WithThreadID(ctx, run.ThreadID), run.RunID), opCancelRun)
+		_, err := h.client.CancelRun(callCtx, run.ThreadID, run.RunID)
+		done(err)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not cancel run %s: %w", run.RunID, err))
+			continue
+		}
+		cancelled = append(cancelled, run)
+	}
+	return cancelled, errors.Join(errs...)
+}
+
 // waitForRunCompletion waits for the completion of a run in a given thread.
-// It periodically checks the status of the run at the specified check interval.
+// It periodically checks the status of the run at the specified check interval,
+// until the run reaches a terminal status, and returns the run as last seen.
 //
 // Parameters:
 //   - ctx: The context to control cancellation and timeout.
-//   - client: The OpenAI client used to retrieve the run status.
 //   - threadID: The UserID of the thread containing the run.
 //   - runID: The UserID of the run to wait for completion.
 //   - checkInterval: The interval at which to check the run status.
 //
 // Returns:
-//   - error: An error if the context is done or if there is an issue retrieving the run status.
-func waitForRunCompletion(ctx context.Context, client *openai.Client, threadID, runID string, checkInterval time.Duration) error {
+//   - openai.Run: The completed run.
+//   - error: An error if the context is done, if there is an issue retrieving the run status or if the run
+//     finished without completing.
+func (h *handler) waitForRunCompletion(ctx context.Context, threadID, runID string, checkInterval time.Duration) (run openai.Run, err error) {
+	ctx, span := h.tracer.Start(ctx, "openai.wait_for_run", "thread_id", threadID, "run_id", runID)
+	polls := 0
+	defer func() {
+		span.SetAttributes("polls", polls, "run_status", string(run.Status))
+		span.End(err)
+	}()
+
 	ticker := time.NewTicker(checkInterval)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			h.metrics.ObserveRun("abandoned", polls)
+			return openai.Run{}, ctx.Err()
 		case <-ticker.C:
-			run, err := client.RetrieveRun(ctx, threadID, runID)
+			polls++
+			callCtx, done := h.startCall(ctx, opRetrieveRun)
+			run, err = h.client.RetrieveRun(callCtx, threadID, runID)
+			done(err)
 			if err != nil {
-				return fmt.Errorf("could not retrieve run: %w", err)
+				return openai.Run{}, fmt.Errorf("could not retrieve run: %w", err)
 			}
-			if run.Status == openai.RunStatusCompleted {
-				return nil
+			switch run.Status {
+			case openai.RunStatusCompleted:
+				h.metrics.ObserveRun(string(run.Status), polls)
+				return run, nil
+			case openai.RunStatusFailed, openai.RunStatusCancelled, openai.RunStatusExpired,
+				openai.RunStatusIncomplete, openai.RunStatusRequiresAction:
+				// The assistant has no tools, so a run requiring action can't
+				// make progress either
+				h.metrics.ObserveRun(string(run.Status), polls)
+				if run.Status == openai.RunStatusCancelled {
+					// Runs are only cancelled on shutdown, the message can be
+					// sent again to another instance
+					return run, fmt.Errorf("%w: run cancelled", domain.ErrUnavailable)
+				}
+				if run.LastError != nil {
+					return run, fmt.Errorf("run %s: %s", run.Status, run.LastError.Message)
+				}
+				return run, fmt.Errorf("run %s", run.Status)
 			}
 		}
 	}
 }
+
+// Name identifies the LLM provider in the status of the service.
+func (h *handler) Name() string {
+	return "openai"
+}
+
+// Check checks that the OpenAI API is reachable, accepts the API key and
+// knows the assistant.
+func (h *handler) Check(ctx context.Context) error {
+	callCtx, done := h.startCall(ctx, opRetrieveAssistant)
+	_, err := h.client.RetrieveAssistant(callCtx, h.assistantID)
+	done(err)
+	if err != nil {
+		return fmt.Errorf("could not retrieve assistant: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sashabaranov/go-openai"
+	"net/http"
+	"stress-relief-ai-chat-back/internal/domain"
+	"strings"
+	"time"
+)
+
+// listMessagesPageSize is the maximum page size allowed by the OpenAI API.
+const listMessagesPageSize = 100
+
+// ListMessages returns all the messages of a thread, oldest first, following
+// the pagination of the OpenAI API.
+func (h *handler) ListMessages(ctx context.Context, threadID string) ([]domain.ThreadMessage, error) {
+	var (
+		messages []domain.ThreadMessage
+		after    *string
+	)
+	for {
+		callCtx, done := h.startCall(domain.WithThreadID(ctx, threadID), opListMessages)
+		page, err := h.client.ListMessage(callCtx, threadID, domain.IntPtr(listMessagesPageSize),
+			domain.StrPtr("asc"), after, nil, nil)
+		done(err)
+		if err != nil {
+			h.logger.Error(ctx, "Error listing messages", "thread_id", threadID, "error", err)
+			return nil, fmt.Errorf("could not list messages: %w", err)
+		}
+
+		for _, m := range page.Messages {
+			messages = append(messages, domain.ThreadMessage{
+				ID:        m.ID,
+				Role:      m.Role,
+				Content:   messageText(m),
+				CreatedAt: time.Unix(int64(m.CreatedAt), 0).UTC(),
+			})
+		}
+
+		if !page.HasMore || page.LastID == nil {
+			return messages, nil
+		}
+		after = page.LastID
+	}
+}
+
+// AppendMessages adds messages to the end of a thread, keeping their roles.
+func (h *handler) AppendMessages(ctx context.Context, threadID string, messages []domain.ThreadMessage) error {
+	for _, m := range messages {
+		if m.Content == "" {
+			continue
+		}
+		callCtx, done := h.startCall(domain.WithThreadID(ctx, threadID), opCreateMessage)
+		_, err := h.client.CreateMessage(callCtx, threadID, openai.MessageRequest{
+			Role:    m.Role,
+			Content: m.Content,
+		})
+		done(err)
+		if err != nil {
+			h.logger.Error(ctx, "Error creating message", "thread_id", threadID, "error", err)
+			return fmt.Errorf("could not create message: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeleteThread deletes a thread and all its messages. It returns an error
+// wrapping domain.ErrNotFound if the thread does not exist.
+func (h *handler) DeleteThread(ctx context.Context, threadID string) error {
+	callCtx, done := h.startCall(domain.WithThreadID(ctx, threadID), opDeleteThread)
+	_, err := h.client.DeleteThread(callCtx, threadID)
+	done(err)
+	if err != nil {
+		var apiErr *openai.APIError
+		if errors.As(err, &apiErr) && apiErr.HTTPStatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w: thread %s", domain.ErrNotFound, threadID)
+		}
+		h.logger.Error(ctx, "Error deleting thread", "thread_id", threadID, "error", err)
+		return fmt.Errorf("could not delete thread: %w", err)
+	}
+	return nil
+}
+
+// messageText joins the text parts of a message, ignoring any other content.
+func messageText(m openai.Message) string {
+	var parts []string
+	for _, c := range m.Content {
+		if c.Text != nil {
+			parts = append(parts, c.Text.Value)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
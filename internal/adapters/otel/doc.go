@@ -0,0 +1,4 @@
+// Package otel provides an implementation of the ports.Tracer interface with
+// OpenTelemetry (go.opentelemetry.io/otel), propagating W3C trace context and
+// exporting spans over OTLP/HTTP.
+package otel
@@ -0,0 +1,161 @@
+package otel
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+// instrumentationName identifies the spans of the service.
+const instrumentationName = "stress-relief-ai-chat-back"
+
+// Exporters spans can be sent with.
+const (
+	// ExporterNone records no spans, but still propagates the trace context
+	// of incoming requests to the services called.
+	ExporterNone = "none"
+	// ExporterOTLP exports spans over OTLP/HTTP. The exporter is configured
+	// with the standard OTEL_EXPORTER_OTLP_* environment variables.
+	ExporterOTLP = "otlp"
+)
+
+// Config holds the settings of the Tracer.
+type Config struct {
+	// Exporter is one of the Exporter constants. Empty means ExporterNone.
+	Exporter string
+	// SampleRatio is the fraction of the traces started by the service that
+	// are sampled. Traces started by callers follow their sampling decision.
+	SampleRatio float64
+	// ServiceName identifies the service in the traces. OTEL_SERVICE_NAME and
+	// OTEL_RESOURCE_ATTRIBUTES take precedence.
+	ServiceName string
+}
+
+// Tracer records spans with OpenTelemetry. It must be shut down to flush the
+// spans not exported yet.
+type Tracer struct {
+	propagator propagation.TextMapPropagator
+	provider   *sdktrace.TracerProvider
+	tracer     trace.Tracer
+}
+
+// NewTracer creates a Tracer exporting spans as configured.
+func NewTracer(ctx context.Context, config Config) (*Tracer, error) {
+	t := &Tracer{
+		propagator: propagation.TraceContext{},
+	}
+
+	switch config.Exporter {
+	case "", ExporterNone:
+		t.tracer = noop.NewTracerProvider().Tracer(instrumentationName)
+		return t, nil
+	case ExporterOTLP:
+	default:
+		return nil, fmt.Errorf("unknown exporter %q", config.Exporter)
+	}
+	if config.SampleRatio < 0 || config.SampleRatio > 1 {
+		return nil, fmt.Errorf("sample ratio must be between 0 and 1")
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not create exporter: %w", err)
+	}
+
+	var attrs []attribute.KeyValue
+	if config.ServiceName != "" {
+		attrs = append(attrs, attribute.String("service.name", config.ServiceName))
+	}
+	res, err := resource.New(ctx, resource.WithAttributes(attrs...), resource.WithFromEnv(), resource.WithTelemetrySDK())
+	if err != nil {
+		return nil, fmt.Errorf("could not create resource: %w", err)
+	}
+
+	t.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	t.tracer = t.provider.Tracer(instrumentationName)
+	return t, nil
+}
+
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...any) (context.Context, ports.Span) {
+	ctx, s := t.tracer.Start(ctx, name, trace.WithAttributes(attributes(attrs)...))
+	return ctx, span{s}
+}
+
+func (t *Tracer) Extract(ctx context.Context, carrier ports.TraceCarrier) context.Context {
+	return t.propagator.Extract(ctx, carrier)
+}
+
+func (t *Tracer) Inject(ctx context.Context, carrier ports.TraceCarrier) {
+	t.propagator.Inject(ctx, carrier)
+}
+
+// Shutdown exports the spans not exported yet and stops the exporter.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t.provider == nil {
+		return nil
+	}
+	return t.provider.Shutdown(ctx)
+}
+
+type span struct {
+	span trace.Span
+}
+
+func (s span) SetName(name string) {
+	s.span.SetName(name)
+}
+
+func (s span) SetAttributes(attrs ...any) {
+	s.span.SetAttributes(attributes(attrs)...)
+}
+
+func (s span) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
+
+// attributes converts key value pairs to span attributes. Values of types
+// without an attribute counterpart are formatted as strings, and a key
+// without a value is ignored.
+func attributes(kv []any) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			continue
+		}
+		switch v := kv[i+1].(type) {
+		case string:
+			attrs = append(attrs, attribute.String(key, v))
+		case bool:
+			attrs = append(attrs, attribute.Bool(key, v))
+		case int:
+			attrs = append(attrs, attribute.Int(key, v))
+		case int64:
+			attrs = append(attrs, attribute.Int64(key, v))
+		case float64:
+			attrs = append(attrs, attribute.Float64(key, v))
+		case time.Duration:
+			attrs = append(attrs, attribute.String(key, v.String()))
+		default:
+			attrs = append(attrs, attribute.String(key, fmt.Sprint(v)))
+		}
+	}
+	return attrs
+}
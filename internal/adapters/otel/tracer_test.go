@@ -0,0 +1,183 @@
+package otel
+
+import (
+	"context"
+	"crypto/tls"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"stress-relief-ai-chat-back/internal/adapters/noop"
+	"stress-relief-ai-chat-back/internal/adapters/openai"
+	"stress-relief-ai-chat-back/internal/adapters/supabase"
+	"stress-relief-ai-chat-back/internal/adapters/supabase/users"
+	"stress-relief-ai-chat-back/internal/domain"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type testLogger struct{}
+
+func (testLogger) Close() error                                  { return nil }
+func (testLogger) Debug(context.Context, string, ...interface{}) {}
+func (testLogger) Info(context.Context, string, ...interface{})  {}
+func (testLogger) Warn(context.Context, string, ...interface{})  {}
+func (testLogger) Error(context.Context, string, ...interface{}) {}
+func (testLogger) Fatal(context.Context, string, ...interface{}) {}
+
+// fakeUpstream answers the OpenAI and PostgREST requests made for a message,
+// recording the trace context each request carries by path.
+type fakeUpstream struct {
+	mu          sync.Mutex
+	traceparent map[string]string
+}
+
+func (u *fakeUpstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	u.mu.Lock()
+	u.traceparent[r.URL.Path] = r.Header.Get("traceparent")
+	u.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/threads/runs":
+		_, _ = w.Write([]byte(`{"id":"run_1","object":"thread.run","thread_id":"thread_1","status":"queued"}`))
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/threads/thread_1/runs/run_1":
+		_, _ = w.Write([]byte(`{"id":"run_1","object":"thread.run","thread_id":"thread_1","status":"completed",
+			"usage":{"prompt_tokens":3,"completion_tokens":5,"total_tokens":8}}`))
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/threads/thread_1/messages":
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"msg_1","object":"thread.message","thread_id":"thread_1",
+			"role":"assistant","content":[{"type":"text","text":{"value":"Breathe.","annotations":[]}}]}],
+			"first_id":"msg_1","last_id":"msg_1","has_more":false}`))
+	case r.Method == http.MethodGet && r.URL.Path == "/rest/v1/user_data":
+		_, _ = w.Write([]byte(`[{"user_id":"user_1","thread_id":"thread_1"}]`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// routeTo sends every request made with the default transport to server,
+// whatever the host, until the test ends.
+func routeTo(t *testing.T, server *httptest.Server) {
+	t.Helper()
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, network, server.Listener.Addr().String())
+	}
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+
+	previous := http.DefaultTransport
+	http.DefaultTransport = transport
+	t.Cleanup(func() { http.DefaultTransport = previous })
+}
+
+func TestSpansOfUpstreamCalls(t *testing.T) {
+	upstream := &fakeUpstream{traceparent: make(map[string]string)}
+	server := httptest.NewTLSServer(upstream)
+	defer server.Close()
+	routeTo(t, server)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := &Tracer{
+		propagator: propagation.TraceContext{},
+		provider:   provider,
+		tracer:     provider.Tracer(instrumentationName),
+	}
+	defer func() { _ = tracer.Shutdown(context.Background()) }()
+
+	llm, err := openai.NewOpenAIAdapter("sk-test", "asst_test", testLogger{}, noop.NewMetrics(), tracer)
+	if err != nil {
+		t.Fatalf("NewOpenAIAdapter: %v", err)
+	}
+	client, err := supabase.NewClient("service-key", server.URL, 0, testLogger{}, noop.NewMetrics(), tracer)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	userData, err := users.NewUserAPIHandler(client, testLogger{})
+	if err != nil {
+		t.Fatalf("NewUserAPIHandler: %v", err)
+	}
+
+	ctx, root := tracer.Start(context.Background(), "chat.ProcessMessage")
+	if _, err := userData.GetByID(ctx, "user_1"); err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	resp, err := llm.ProcessMessage(ctx, &domain.ChatMessage{Content: "I feel stressed."}, nil)
+	if err != nil {
+		t.Fatalf("ProcessMessage: %v", err)
+	}
+	if resp.Content != "Breathe." || resp.ThreadID != "thread_1" {
+		t.Fatalf("ProcessMessage: got %+v", resp)
+	}
+	root.End(nil)
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+		for _, attr := range s.Attributes() {
+			if strings.Contains(string(attr.Key), "user") || strings.Contains(attr.Value.Emit(), "user_1") {
+				t.Errorf("span %s identifies the user with %s=%s", s.Name(), attr.Key, attr.Value.Emit())
+			}
+		}
+	}
+	span := func(name string) sdktrace.ReadOnlySpan {
+		t.Helper()
+		s, ok := spans[name]
+		if !ok {
+			t.Fatalf("no %s span, got %v", name, recorder.Ended())
+		}
+		return s
+	}
+	chat := span("chat.ProcessMessage")
+
+	for name, parent := range map[string]string{
+		"supabase.get_user_data": "chat.ProcessMessage",
+		"openai.create_thread":   "chat.ProcessMessage",
+		"openai.wait_for_run":    "chat.ProcessMessage",
+		"openai.retrieve_run":    "openai.wait_for_run",
+		"openai.list_messages":   "chat.ProcessMessage",
+	} {
+		s := span(name)
+		if s.SpanContext().TraceID() != chat.SpanContext().TraceID() {
+			t.Errorf("span %s is not in the trace of the message", name)
+		}
+		if s.Parent().SpanID() != span(parent).SpanContext().SpanID() {
+			t.Errorf("span %s is not a child of %s", name, parent)
+		}
+	}
+
+	for name, want := range map[string][]attribute.KeyValue{
+		"supabase.get_user_data": {
+			attribute.String("http.request.method", http.MethodGet),
+			attribute.String("url.path", "/rest/v1/user_data"),
+			attribute.Int("http.response.status_code", http.StatusOK),
+		},
+		"openai.wait_for_run": {
+			attribute.String("thread_id", "thread_1"),
+			attribute.String("run_id", "run_1"),
+			attribute.String("run_status", "completed"),
+		},
+		"openai.list_messages": {
+			attribute.String("thread_id", "thread_1"),
+			attribute.String("run_id", "run_1"),
+		},
+	} {
+		got := attribute.NewSet(span(name).Attributes()...)
+		for _, attr := range want {
+			if v, ok := got.Value(attr.Key); !ok || v != attr.Value {
+				t.Errorf("span %s: got %s=%s, want %s", name, attr.Key, v.Emit(), attr.Value.Emit())
+			}
+		}
+	}
+
+	// PostgREST receives the context of the span of its call
+	traceparent := upstream.traceparent["/rest/v1/user_data"]
+	if id := span("supabase.get_user_data").SpanContext().SpanID().String(); !strings.Contains(traceparent, id) {
+		t.Errorf("PostgREST got traceparent %q, want the span %s", traceparent, id)
+	}
+}
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"strings"
+	"time"
+)
+
+type auditLogger struct {
+	logger ports.Logger
+	pool   *pgxpool.Pool
+}
+
+func NewAuditLogger(pool *pgxpool.Pool, logger ports.Logger) (ports.AuditLogger, error) {
+	a := &auditLogger{
+		logger: logger,
+		pool:   pool,
+	}
+	if a.pool == nil {
+		return nil, fmt.Errorf("pool can't be nil")
+	}
+	if a.logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	return a, nil
+}
+
+func (a *auditLogger) Record(ctx context.Context, event *domain.AuditEvent) error {
+	if event == nil {
+		return fmt.Errorf("can't record nil event")
+	}
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+
+	_, err := a.pool.Exec(ctx, `insert into audit_log
+		(id, request_id, actor, action, target_user_id, outcome, details, created_at)
+		values ($1, nullif($2, ''), $3, $4, nullif($5, ''), $6, nullif($7, ''), $8)`,
+		event.ID, event.RequestID, event.Actor, event.Action, event.TargetUserID, event.Outcome, event.Details,
+		event.CreatedAt)
+	if err != nil {
+		a.logger.Error(ctx, "Error recording audit event", "error", err)
+		return fmt.Errorf("error recording audit event: %w", err)
+	}
+	return nil
+}
+
+func (a *auditLogger) Query(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEvent, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if q.Actor != "" {
+		conditions = append(conditions, "actor = "+arg(q.Actor))
+	}
+	if q.Action != "" {
+		conditions = append(conditions, "action = "+arg(q.Action))
+	}
+	if q.TargetUserID != "" {
+		conditions = append(conditions, "target_user_id = "+arg(q.TargetUserID))
+	}
+	if !q.Since.IsZero() {
+		conditions = append(conditions, "created_at >= "+arg(q.Since))
+	}
+	if !q.Until.IsZero() {
+		conditions = append(conditions, "created_at <= "+arg(q.Until))
+	}
+
+	query := `select id, coalesce(request_id, ''), actor, action, coalesce(target_user_id, ''), outcome,
+		coalesce(details, ''), created_at from audit_log`
+	if len(conditions) > 0 {
+		query += " where " + strings.Join(conditions, " and ")
+	}
+	query += " order by created_at desc"
+	if q.Limit > 0 {
+		query += " limit " + arg(q.Limit)
+	}
+
+	rows, err := a.pool.Query(ctx, query, args...)
+	if err != nil {
+		a.logger.Error(ctx, "Error querying audit log", "error", err)
+		return nil, fmt.Errorf("error querying audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var events []domain.AuditEvent
+	for rows.Next() {
+		var e domain.AuditEvent
+		err := rows.Scan(&e.ID, &e.RequestID, &e.Actor, &e.Action, &e.TargetUserID, &e.Outcome, &e.Details, &e.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning audit event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error querying audit log: %w", err)
+	}
+	return events, nil
+}
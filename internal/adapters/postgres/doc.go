@@ -0,0 +1,5 @@
+// Package postgres provides implementations of the repository ports backed by a
+// PostgreSQL database, for deployments that don't use Supabase.
+//
+// The schema is managed with the embedded SQL migrations, see Migrate.
+package postgres
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+type exportRepository struct {
+	logger ports.Logger
+	pool   *pgxpool.Pool
+}
+
+func NewExportRepository(pool *pgxpool.Pool, logger ports.Logger) (ports.ExportRepository, error) {
+	r := &exportRepository{
+		logger: logger,
+		pool:   pool,
+	}
+	if r.pool == nil {
+		return nil, fmt.Errorf("pool can't be nil")
+	}
+	if r.logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	return r, nil
+}
+
+func (r *exportRepository) Insert(ctx context.Context, export *domain.DataExport) error {
+	if export == nil {
+		return fmt.Errorf("can't insert nil export")
+	}
+
+	_, err := r.pool.Exec(ctx, `insert into data_exports (id, user_id, status, error, archive, created_at, completed_at)
+		values ($1, $2, $3, nullif($4, ''), $5, $6, $7)`,
+		export.ID, export.UserID, export.Status, export.Error, export.Archive, export.CreatedAt, export.CompletedAt)
+	if err != nil {
+		r.logger.Error(ctx, "Error inserting export", "error", err)
+		return fmt.Errorf("error inserting export: %w", err)
+	}
+	return nil
+}
+
+func (r *exportRepository) Update(ctx context.Context, export *domain.DataExport) error {
+	if export == nil {
+		return fmt.Errorf("can't update nil export")
+	}
+
+	tag, err := r.pool.Exec(ctx, `update data_exports
+		set status = $2, error = nullif($3, ''), archive = $4, completed_at = $5
+		where id = $1`,
+		export.ID, export.Status, export.Error, export.Archive, export.CompletedAt)
+	if err != nil {
+		r.logger.Error(ctx, "Error updating export", "error", err)
+		return fmt.Errorf("error updating export: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: export not found", domain.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *exportRepository) Get(ctx context.Context, exportID string) (*domain.DataExport, error) {
+	var export domain.DataExport
+	err := r.pool.QueryRow(ctx, `select id, user_id, status, coalesce(error, ''), archive, created_at, completed_at
+		from data_exports where id = $1`, exportID).
+		Scan(&export.ID, &export.UserID, &export.Status, &export.Error, &export.Archive, &export.CreatedAt,
+			&export.CompletedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: export not found", domain.ErrNotFound)
+	}
+	if err != nil {
+		r.logger.Error(ctx, "Error getting export", "error", err)
+		return nil, fmt.Errorf("error getting export: %w", err)
+	}
+	return &export, nil
+}
+
+func (r *exportRepository) ListByUser(ctx context.Context, userID string) ([]domain.DataExport, error) {
+	rows, err := r.pool.Query(ctx, `select id, user_id, status, coalesce(error, ''), created_at, completed_at
+		from data_exports where user_id = $1 order by created_at`, userID)
+	if err != nil {
+		r.logger.Error(ctx, "Error listing exports", "error", err)
+		return nil, fmt.Errorf("error listing exports: %w", err)
+	}
+	defer rows.Close()
+
+	exports := []domain.DataExport{}
+	for rows.Next() {
+		var e domain.DataExport
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Status, &e.Error, &e.CreatedAt, &e.CompletedAt); err != nil {
+			return nil, fmt.Errorf("error scanning export: %w", err)
+		}
+		exports = append(exports, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing exports: %w", err)
+	}
+	return exports, nil
+}
+
+func (r *exportRepository) Delete(ctx context.Context, exportID string) error {
+	if _, err := r.pool.Exec(ctx, "delete from data_exports where id = $1", exportID); err != nil {
+		r.logger.Error(ctx, "Error deleting export", "error", err)
+		return fmt.Errorf("error deleting export: %w", err)
+	}
+	return nil
+}
+
+func (r *exportRepository) DeleteByUser(ctx context.Context, userID string) error {
+	if userID == "" {
+		return fmt.Errorf("can't delete exports with empty userID")
+	}
+	if _, err := r.pool.Exec(ctx, "delete from data_exports where user_id = $1", userID); err != nil {
+		r.logger.Error(ctx, "Error deleting exports", "error", err)
+		return fmt.Errorf("error deleting exports: %w", err)
+	}
+	return nil
+}
+
+func (r *exportRepository) DeleteCreatedBefore(ctx context.Context, t time.Time) error {
+	if _, err := r.pool.Exec(ctx, "delete from data_exports where created_at < $1", t); err != nil {
+		r.logger.Error(ctx, "Error deleting expired exports", "error", err)
+		return fmt.Errorf("error deleting expired exports: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"io/fs"
+	"sort"
+	"stress-relief-ai-chat-back/internal/ports"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// migrationLockID is the key of the advisory lock that keeps concurrent
+// migrations from running at the same time.
+const migrationLockID = 7260113
+
+// Migrate applies the embedded migrations that have not been applied yet, in
+// order of their file name. Each migration runs in its own transaction.
+func Migrate(ctx context.Context, pool *pgxpool.Pool, logger ports.Logger) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("could not acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "select pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("could not acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.Exec(context.Background(), "select pg_advisory_unlock($1)", migrationLockID); err != nil {
+			logger.Error(ctx, "Error releasing migration lock", "error", err)
+		}
+	}()
+
+	_, err = conn.Exec(ctx, `create table if not exists schema_migrations (
+		version    text primary key,
+		applied_at timestamptz not null default now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("could not create schema_migrations table: %w", err)
+	}
+
+	files, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return fmt.Errorf("could not list migrations: %w", err)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		version := strings.TrimSuffix(strings.TrimPrefix(file, "migrations/"), ".sql")
+		sql, err := migrations.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("could not read migration %s: %w", version, err)
+		}
+
+		applied, err := apply(ctx, conn.Conn(), version, string(sql))
+		if err != nil {
+			return fmt.Errorf("could not apply migration %s: %w", version, err)
+		}
+		if applied {
+			logger.Info(ctx, "Applied migration", "version", version)
+		}
+	}
+	return nil
+}
+
+// apply runs a migration unless it has already been applied. It reports
+// whether the migration was applied.
+func apply(ctx context.Context, conn *pgx.Conn, version, sql string) (bool, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	err = tx.QueryRow(ctx, "select exists(select 1 from schema_migrations where version = $1)", version).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(ctx, "insert into schema_migrations (version) values ($1)", version); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
@@ -0,0 +1,25 @@
+create table if not exists audit_log (
+    id             uuid primary key,
+    request_id     text,
+    actor          text not null,
+    action         text not null,
+    target_user_id text,
+    outcome        text not null,
+    details        text,
+    created_at     timestamptz not null
+);
+
+create index if not exists audit_log_created_at_idx on audit_log (created_at desc);
+create index if not exists audit_log_target_user_id_idx on audit_log (target_user_id, created_at desc);
+
+-- The audit log is append-only
+create or replace function audit_log_append_only() returns trigger as $$
+begin
+    raise exception 'audit_log is append-only';
+end;
+$$ language plpgsql;
+
+drop trigger if exists audit_log_append_only on audit_log;
+create trigger audit_log_append_only
+    before update or delete on audit_log
+    for each row execute function audit_log_append_only();
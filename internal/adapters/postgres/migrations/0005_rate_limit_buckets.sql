@@ -0,0 +1,10 @@
+create table if not exists rate_limit_buckets (
+    key        text primary key,
+    tokens     double precision not null,
+    allowed    boolean not null,
+    updated_at timestamptz not null,
+    -- when the bucket is full again, after which it can be dropped
+    full_at    timestamptz not null
+);
+
+create index if not exists rate_limit_buckets_full_at_idx on rate_limit_buckets (full_at);
@@ -0,0 +1,5 @@
+-- Entries without a last activity were never selected for retention. Their
+-- retention starts now.
+update user_data set last_active_at = now() where last_active_at is null;
+
+alter table user_data alter column last_active_at set default now();
@@ -0,0 +1,12 @@
+create table if not exists data_exports (
+    id           uuid primary key,
+    user_id      text not null,
+    status       text not null,
+    error        text,
+    archive      bytea,
+    created_at   timestamptz not null,
+    completed_at timestamptz
+);
+
+create index if not exists data_exports_user_id_idx on data_exports (user_id, created_at);
+create index if not exists data_exports_created_at_idx on data_exports (created_at);
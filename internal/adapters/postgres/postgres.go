@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"stress-relief-ai-chat-back/internal/ports"
+)
+
+// NewPool creates a connection pool for the database at dsn, which can be a
+// URL or a key/value connection string. Pool settings such as pool_max_conns
+// can be passed in the dsn.
+func NewPool(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("dsn can't be empty")
+	}
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("could not create connection pool: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("could not connect to database: %w", err)
+	}
+	return pool, nil
+}
+
+type healthChecker struct {
+	pool *pgxpool.Pool
+}
+
+// NewHealthChecker creates a HealthChecker that pings the database.
+func NewHealthChecker(pool *pgxpool.Pool) (ports.HealthChecker, error) {
+	if pool == nil {
+		return nil, fmt.Errorf("pool can't be nil")
+	}
+	return &healthChecker{pool: pool}, nil
+}
+
+func (h *healthChecker) Name() string {
+	return "postgres"
+}
+
+func (h *healthChecker) Check(ctx context.Context) error {
+	return h.pool.Ping(ctx)
+}
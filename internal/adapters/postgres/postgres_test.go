@@ -0,0 +1,398 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"os"
+	"stress-relief-ai-chat-back/internal/domain"
+	"testing"
+	"time"
+)
+
+// The tests in this file run against the database at TEST_DATABASE_URL, e.g.
+// the one started by make db-up, and are skipped when it isn't set. They
+// migrate it, and use fresh user ids so they can share it with other data.
+const testDSNEnv = "TEST_DATABASE_URL"
+
+type testLogger struct{}
+
+func (testLogger) Close() error                                  { return nil }
+func (testLogger) Debug(context.Context, string, ...interface{}) {}
+func (testLogger) Info(context.Context, string, ...interface{})  {}
+func (testLogger) Warn(context.Context, string, ...interface{})  {}
+func (testLogger) Error(context.Context, string, ...interface{}) {}
+func (testLogger) Fatal(context.Context, string, ...interface{}) {}
+
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSNEnv)
+	}
+
+	ctx := context.Background()
+	pool, err := NewPool(ctx, dsn)
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	if err := Migrate(ctx, pool, testLogger{}); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	return pool
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestUserDataRepository(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo, err := NewUserDataRepository(pool, testLogger{})
+	if err != nil {
+		t.Fatalf("NewUserDataRepository: %v", err)
+	}
+	userID := uuid.NewString()
+	t.Cleanup(func() { _ = repo.Delete(context.Background(), userID) })
+
+	if _, err := repo.GetByID(ctx, userID); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("GetByID of a missing entry: got %v, want ErrNotFound", err)
+	}
+	if err := repo.Update(ctx, userID, &domain.UserData{ThreadID: ptr("thread")}); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("Update of a missing entry: got %v, want ErrNotFound", err)
+	}
+	if err := repo.Patch(ctx, userID, domain.UserDataPatch{ThreadID: ptr("thread")}); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("Patch of a missing entry: got %v, want ErrNotFound", err)
+	}
+
+	err = repo.Insert(ctx, userID, &domain.UserData{ThreadID: ptr("sealed"), Plan: domain.PlanFree, KeyID: ptr("k1")})
+	if err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	got, err := repo.GetByID(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.ThreadID == nil || *got.ThreadID != "sealed" || got.Plan != domain.PlanFree ||
+		got.KeyID == nil || *got.KeyID != "k1" {
+		t.Fatalf("GetByID after Insert: got %+v", got)
+	}
+	if got.LastActiveAt == nil {
+		t.Fatalf("Insert didn't default last_active_at")
+	}
+
+	// A plaintext thread id clears the key id, an empty plan keeps the plan
+	err = repo.Update(ctx, userID, &domain.UserData{ThreadID: ptr("plain"), RetentionDays: ptr(90)})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err = repo.GetByID(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.ThreadID == nil || *got.ThreadID != "plain" || got.KeyID != nil || got.Plan != domain.PlanFree ||
+		got.RetentionDays == nil || *got.RetentionDays != 90 {
+		t.Fatalf("GetByID after Update: got %+v", got)
+	}
+
+	lastActive := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	err = repo.Patch(ctx, userID, domain.UserDataPatch{LastActiveAt: &lastActive})
+	if err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+	got, err = repo.GetByID(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.ThreadID == nil || *got.ThreadID != "plain" || !got.LastActiveAt.Equal(lastActive) ||
+		got.RetentionDays == nil || *got.RetentionDays != 90 {
+		t.Fatalf("GetByID after Patch: got %+v", got)
+	}
+
+	// A conditional patch only applies to an entry still holding the thread
+	// and key id it was based on
+	for _, cond := range []domain.UserDataCondition{{ThreadID: "stale"}, {ThreadID: "plain", KeyID: ptr("k1")}} {
+		err = repo.Patch(ctx, userID, domain.UserDataPatch{ThreadID: ptr("sealed"), KeyID: ptr("k2"), If: &cond})
+		if !errors.Is(err, domain.ErrNotFound) {
+			t.Fatalf("Patch with condition %+v: got %v, want ErrNotFound", cond, err)
+		}
+	}
+	err = repo.Patch(ctx, userID, domain.UserDataPatch{
+		ThreadID: ptr("sealed"),
+		KeyID:    ptr("k2"),
+		If:       &domain.UserDataCondition{ThreadID: "plain"},
+	})
+	if err != nil {
+		t.Fatalf("Patch with a met condition: %v", err)
+	}
+	got, err = repo.GetByID(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.ThreadID == nil || *got.ThreadID != "sealed" || got.KeyID == nil || *got.KeyID != "k2" ||
+		got.RetentionDays == nil || *got.RetentionDays != 90 {
+		t.Fatalf("GetByID after conditional Patch: got %+v", got)
+	}
+
+	users, err := repo.List(ctx, domain.UserDataFilter{InactiveBefore: lastActive.Add(time.Second)})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	found := false
+	for _, u := range users {
+		found = found || u.UserID == userID
+	}
+	if !found {
+		t.Fatalf("List of inactive entries doesn't contain %s", userID)
+	}
+
+	// Plan and retention are patched alone, and unlinking the thread of an
+	// inactive entry requires it to still be inactive
+	err = repo.Patch(ctx, userID, domain.UserDataPatch{Plan: ptr(domain.PlanPremium), RetentionDays: ptr(120)})
+	if err != nil {
+		t.Fatalf("Patch of plan and retention: %v", err)
+	}
+	unlink := domain.UserDataPatch{
+		ClearThreadID: true,
+		If:            &domain.UserDataCondition{ThreadID: "sealed", KeyID: ptr("k2"), InactiveBefore: lastActive},
+	}
+	if err := repo.Patch(ctx, userID, unlink); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("Patch unlinking an entry active since: got %v, want ErrNotFound", err)
+	}
+	unlink.If.InactiveBefore = lastActive.Add(time.Second)
+	if err := repo.Patch(ctx, userID, unlink); err != nil {
+		t.Fatalf("Patch unlinking an inactive entry: %v", err)
+	}
+	got, err = repo.GetByID(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.ThreadID != nil || got.KeyID != nil || got.Plan != domain.PlanPremium ||
+		got.RetentionDays == nil || *got.RetentionDays != 120 || !got.LastActiveAt.Equal(lastActive) {
+		t.Fatalf("GetByID after unlinking: got %+v", got)
+	}
+
+	if err := repo.Delete(ctx, userID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, userID); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("GetByID after Delete: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestTombstoneRepository(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo, err := NewTombstoneRepository(pool, testLogger{})
+	if err != nil {
+		t.Fatalf("NewTombstoneRepository: %v", err)
+	}
+	key := domain.TombstoneKey(uuid.NewString())
+
+	if exists, err := repo.Exists(ctx, key); err != nil || exists {
+		t.Fatalf("Exists before Add: got %v, %v", exists, err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := repo.Add(ctx, key); err != nil {
+			t.Fatalf("Add #%d: %v", i+1, err)
+		}
+	}
+	if exists, err := repo.Exists(ctx, key); err != nil || !exists {
+		t.Fatalf("Exists after Add: got %v, %v", exists, err)
+	}
+}
+
+func TestUsageRepository(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo, err := NewUsageRepository(pool, testLogger{})
+	if err != nil {
+		t.Fatalf("NewUsageRepository: %v", err)
+	}
+	userID := uuid.NewString()
+	t.Cleanup(func() { _ = repo.Delete(context.Background(), userID) })
+	day := "2026-01-02"
+
+	for want := 1; want <= 2; want++ {
+		count, ok, err := repo.Increment(ctx, userID, day, domain.PlanFree, 2)
+		if err != nil || !ok || count != want {
+			t.Fatalf("Increment #%d: got %d, %v, %v", want, count, ok, err)
+		}
+	}
+	if _, ok, err := repo.Increment(ctx, userID, day, domain.PlanFree, 2); err != nil || ok {
+		t.Fatalf("Increment over the limit: got %v, %v", ok, err)
+	}
+
+	if err := repo.Decrement(ctx, userID, day); err != nil {
+		t.Fatalf("Decrement: %v", err)
+	}
+	if count, ok, err := repo.Increment(ctx, userID, day, domain.PlanFree, 2); err != nil || !ok || count != 2 {
+		t.Fatalf("Increment after Decrement: got %d, %v, %v", count, ok, err)
+	}
+
+	// A negative limit means unlimited
+	if _, ok, err := repo.Increment(ctx, userID, day, domain.PlanPremium, -1); err != nil || !ok {
+		t.Fatalf("Increment without a limit: got %v, %v", ok, err)
+	}
+
+	if err := repo.Delete(ctx, userID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if count, ok, err := repo.Increment(ctx, userID, day, domain.PlanFree, 2); err != nil || !ok || count != 1 {
+		t.Fatalf("Increment after Delete: got %d, %v, %v", count, ok, err)
+	}
+}
+
+func TestRateLimitStore(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	store, err := NewRateLimitStore(pool, testLogger{})
+	if err != nil {
+		t.Fatalf("NewRateLimitStore: %v", err)
+	}
+	key := "test:" + uuid.NewString()
+	limit := domain.RateLimit{Limit: 2, Period: time.Hour}
+
+	for i := 0; i < 2; i++ {
+		res, err := store.Take(ctx, key, limit)
+		if err != nil || !res.Allowed {
+			t.Fatalf("Take #%d: got %+v, %v", i+1, res, err)
+		}
+	}
+	res, err := store.Take(ctx, key, limit)
+	if err != nil || res.Allowed {
+		t.Fatalf("Take over the limit: got %+v, %v", res, err)
+	}
+	if res.RetryAfter <= 0 {
+		t.Fatalf("Take over the limit: got RetryAfter %v, want a positive duration", res.RetryAfter)
+	}
+}
+
+func TestAuditLogger(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	audit, err := NewAuditLogger(pool, testLogger{})
+	if err != nil {
+		t.Fatalf("NewAuditLogger: %v", err)
+	}
+	userID := uuid.NewString()
+
+	event := domain.NewAuditEvent(ctx, "admin", domain.AuditActionRetentionSet, userID, errors.New("boom"))
+	if err := audit.Record(ctx, event); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	events, err := audit.Query(ctx, domain.AuditQuery{TargetUserID: userID})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(events) != 1 || events[0].Actor != "admin" || events[0].Outcome != domain.AuditOutcomeFailure ||
+		events[0].Details != "boom" {
+		t.Fatalf("Query: got %+v", events)
+	}
+}
+
+func TestSafetyEventRepository(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo, err := NewSafetyEventRepository(pool, testLogger{})
+	if err != nil {
+		t.Fatalf("NewSafetyEventRepository: %v", err)
+	}
+	userID := uuid.NewString()
+	t.Cleanup(func() { _ = repo.DeleteByUser(context.Background(), userID) })
+
+	// Future timestamps keep the events first among the ones of other tests
+	now := time.Now().Add(time.Hour).UTC().Truncate(time.Microsecond)
+	older := &domain.SafetyEvent{UserID: userID, Category: domain.SafetyCategorySelfHarm, CreatedAt: now}
+	newer := &domain.SafetyEvent{UserID: userID, ThreadID: "thread", Category: domain.SafetyCategoryHarmToOthers,
+		Details: "keyword", CreatedAt: now.Add(time.Second)}
+	for _, event := range []*domain.SafetyEvent{older, newer} {
+		if err := repo.Record(ctx, event); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+		if event.ID == "" {
+			t.Fatalf("Record: got an empty ID")
+		}
+	}
+
+	events, err := repo.List(ctx, 2)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(events) != 2 || events[0] != *newer || !events[1].CreatedAt.Equal(older.CreatedAt) || events[1].ID != older.ID {
+		t.Fatalf("List: got %+v, want %+v and %+v", events, *newer, *older)
+	}
+
+	if err := repo.DeleteByUser(ctx, userID); err != nil {
+		t.Fatalf("DeleteByUser: %v", err)
+	}
+	events, err = repo.List(ctx, 0)
+	if err != nil {
+		t.Fatalf("List after DeleteByUser: %v", err)
+	}
+	for _, event := range events {
+		if event.UserID == userID {
+			t.Fatalf("List after DeleteByUser: got %+v", event)
+		}
+	}
+}
+
+func TestExportRepository(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo, err := NewExportRepository(pool, testLogger{})
+	if err != nil {
+		t.Fatalf("NewExportRepository: %v", err)
+	}
+	userID := uuid.NewString()
+	t.Cleanup(func() { _ = repo.DeleteByUser(context.Background(), userID) })
+
+	created := time.Now().UTC().Truncate(time.Microsecond)
+	older := &domain.DataExport{ID: uuid.NewString(), UserID: userID, Status: domain.ExportStatusPending,
+		CreatedAt: created.Add(-2 * time.Hour)}
+	export := &domain.DataExport{ID: uuid.NewString(), UserID: userID, Status: domain.ExportStatusPending,
+		CreatedAt: created}
+	for _, e := range []*domain.DataExport{export, older} {
+		if err := repo.Insert(ctx, e); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+
+	completed := created.Add(time.Second)
+	export.Status = domain.ExportStatusReady
+	export.Archive = []byte("zip")
+	export.CompletedAt = &completed
+	if err := repo.Update(ctx, export); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err := repo.Get(ctx, export.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Status != domain.ExportStatusReady || string(got.Archive) != "zip" || !got.CreatedAt.Equal(created) ||
+		got.CompletedAt == nil || !got.CompletedAt.Equal(completed) {
+		t.Fatalf("Get: got %+v, want the updated export", got)
+	}
+
+	list, err := repo.ListByUser(ctx, userID)
+	if err != nil || len(list) != 2 || list[0].ID != older.ID || list[1].Archive != nil {
+		t.Fatalf("ListByUser: got %+v, %v, want both exports oldest first without archives", list, err)
+	}
+
+	if err := repo.DeleteCreatedBefore(ctx, created.Add(-time.Hour)); err != nil {
+		t.Fatalf("DeleteCreatedBefore: %v", err)
+	}
+	if _, err := repo.Get(ctx, older.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("Get of an expired export: got %v, want ErrNotFound", err)
+	}
+
+	if err := repo.Delete(ctx, export.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := repo.Update(ctx, export); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("Update of a deleted export: got %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"sync"
+	"time"
+)
+
+// refilled is the number of tokens in a bucket after refilling it for the
+// time elapsed since it was last used. $2 is the capacity of the bucket and $3
+// the number of tokens added per second.
+const refilled = "least($2::float8, b.tokens + extract(epoch from now() - b.updated_at) * $3::float8)"
+
+// takeToken refills the bucket, then takes a token from it if one is left.
+// The update reads the locked row, so concurrent requests can't take the same
+// token.
+const takeToken = `insert into rate_limit_buckets as b (key, tokens, allowed, updated_at, full_at)
+values ($1, $2::float8 - 1, true, now(), now() + make_interval(secs => 1 / $3::float8))
+on conflict (key) do update set
+    tokens = case when ` + refilled + ` >= 1 then ` + refilled + ` - 1 else ` + refilled + ` end,
+    allowed = ` + refilled + ` >= 1,
+    updated_at = now(),
+    full_at = now() + make_interval(secs => ($2::float8 - ` + refilled + ` + 1) / $3::float8)
+returning tokens, allowed`
+
+// sweepInterval is how often the buckets that are full again are deleted.
+const sweepInterval = time.Minute
+
+type rateLimitStore struct {
+	logger ports.Logger
+	pool   *pgxpool.Pool
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewRateLimitStore creates a RateLimitStore that keeps the token buckets in
+// the database, so that limits are enforced across all the instances sharing
+// it. Every request takes a token with a single statement.
+func NewRateLimitStore(pool *pgxpool.Pool, logger ports.Logger) (ports.RateLimitStore, error) {
+	s := &rateLimitStore{
+		logger: logger,
+		pool:   pool,
+	}
+	if s.pool == nil {
+		return nil, fmt.Errorf("pool can't be nil")
+	}
+	if s.logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	return s, nil
+}
+
+func (s *rateLimitStore) Take(ctx context.Context, key string, limit domain.RateLimit) (*domain.RateLimitResult, error) {
+	s.sweep(ctx)
+
+	capacity := float64(limit.Limit)
+	rate := capacity / limit.Period.Seconds()
+
+	var (
+		tokens  float64
+		allowed bool
+	)
+	if err := s.pool.QueryRow(ctx, takeToken, key, capacity, rate).Scan(&tokens, &allowed); err != nil {
+		s.logger.Error(ctx, "Error taking rate limit token", "error", err)
+		return nil, fmt.Errorf("error taking rate limit token: %w", err)
+	}
+
+	res := &domain.RateLimitResult{
+		Allowed:   allowed,
+		Limit:     limit.Limit,
+		Remaining: int(tokens),
+		Reset:     seconds((capacity - tokens) / rate),
+	}
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return res, nil
+}
+
+// sweep deletes the buckets that are full again, as they are equivalent to a
+// missing bucket. Only one instance needs to succeed, so errors are logged.
+func (s *rateLimitStore) sweep(ctx context.Context) {
+	s.mu.Lock()
+	if time.Since(s.lastSweep) < sweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = time.Now()
+	s.mu.Unlock()
+
+	if _, err := s.pool.Exec(ctx, "delete from rate_limit_buckets where full_at < now()"); err != nil {
+		s.logger.Warn(ctx, "Error deleting full rate limit buckets", "error", err)
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"stress-relief-ai-chat-back/internal/ports"
+)
+
+type tombstoneRepository struct {
+	logger ports.Logger
+	pool   *pgxpool.Pool
+}
+
+func NewTombstoneRepository(pool *pgxpool.Pool, logger ports.Logger) (ports.TombstoneRepository, error) {
+	r := &tombstoneRepository{
+		logger: logger,
+		pool:   pool,
+	}
+	if r.pool == nil {
+		return nil, fmt.Errorf("pool can't be nil")
+	}
+	if r.logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	return r, nil
+}
+
+func (r *tombstoneRepository) Add(ctx context.Context, key string) error {
+	if key == "" {
+		return fmt.Errorf("can't add tombstone with empty key")
+	}
+	_, err := r.pool.Exec(ctx, "insert into user_tombstones (key) values ($1) on conflict do nothing", key)
+	if err != nil {
+		r.logger.Error(ctx, "Error adding tombstone", "error", err)
+		return fmt.Errorf("error adding tombstone: %w", err)
+	}
+	return nil
+}
+
+func (r *tombstoneRepository) Exists(ctx context.Context, key string) (bool, error) {
+	if key == "" {
+		return false, fmt.Errorf("can't look up tombstone with empty key")
+	}
+	var exists bool
+	err := r.pool.QueryRow(ctx, "select exists(select 1 from user_tombstones where key = $1)", key).Scan(&exists)
+	if err != nil {
+		r.logger.Error(ctx, "Error getting tombstone", "error", err)
+		return false, fmt.Errorf("error getting tombstone: %w", err)
+	}
+	return exists, nil
+}
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+)
+
+// incrementUsage counts a message, restarting the count on a new day. The
+// update is skipped, and no row returned, when the limit is reached.
+const incrementUsage = `insert into message_usage (user_id, day, plan, count) values ($1, $2, $3, 1)
+on conflict (user_id) do update set
+    count = case when message_usage.day = excluded.day then message_usage.count + 1 else 1 end,
+    day = excluded.day,
+    plan = excluded.plan
+where message_usage.day <> excluded.day or $4 < 0 or message_usage.count < $4
+returning count`
+
+type usageRepository struct {
+	logger ports.Logger
+	pool   *pgxpool.Pool
+}
+
+func NewUsageRepository(pool *pgxpool.Pool, logger ports.Logger) (ports.UsageRepository, error) {
+	r := &usageRepository{
+		logger: logger,
+		pool:   pool,
+	}
+	if r.pool == nil {
+		return nil, fmt.Errorf("pool can't be nil")
+	}
+	if r.logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	return r, nil
+}
+
+func (r *usageRepository) Increment(ctx context.Context, userID, day string, plan domain.Plan, limit int) (int, bool, error) {
+	if userID == "" {
+		return 0, false, fmt.Errorf("can't count message with empty userID")
+	}
+
+	var count int
+	err := r.pool.QueryRow(ctx, incrementUsage, userID, day, string(plan), limit).Scan(&count)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return limit, false, nil
+	}
+	if err != nil {
+		r.logger.Error(ctx, "Error counting message", "error", err)
+		return 0, false, fmt.Errorf("error counting message: %w", err)
+	}
+	return count, true, nil
+}
+
+func (r *usageRepository) Decrement(ctx context.Context, userID, day string) error {
+	_, err := r.pool.Exec(ctx, "update message_usage set count = count - 1 where user_id = $1 and day = $2 and count > 0",
+		userID, day)
+	if err != nil {
+		r.logger.Error(ctx, "Error uncounting message", "error", err)
+		return fmt.Errorf("error uncounting message: %w", err)
+	}
+	return nil
+}
+
+func (r *usageRepository) Delete(ctx context.Context, userID string) error {
+	if userID == "" {
+		return fmt.Errorf("can't delete usage with empty userID")
+	}
+	if _, err := r.pool.Exec(ctx, "delete from message_usage where user_id = $1", userID); err != nil {
+		r.logger.Error(ctx, "Error deleting usage", "error", err)
+		return fmt.Errorf("error deleting usage: %w", err)
+	}
+	return nil
+}
+
+func (r *usageRepository) Summary(ctx context.Context, day string) (*domain.UsageSummary, error) {
+	rows, err := r.pool.Query(ctx, `select plan, count(*), sum(count) from message_usage
+where day = $1 and count > 0 group by plan`, day)
+	if err != nil {
+		r.logger.Error(ctx, "Error summarizing usage", "error", err)
+		return nil, fmt.Errorf("error summarizing usage: %w", err)
+	}
+	defer rows.Close()
+
+	summary := &domain.UsageSummary{
+		Day:            day,
+		MessagesByPlan: make(map[domain.Plan]int),
+	}
+	for rows.Next() {
+		var (
+			plan            string
+			users, messages int
+		)
+		if err := rows.Scan(&plan, &users, &messages); err != nil {
+			return nil, fmt.Errorf("error scanning usage: %w", err)
+		}
+		summary.ActiveUsers += users
+		summary.TotalMessages += messages
+		summary.MessagesByPlan[domain.Plan(plan)] += messages
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error(ctx, "Error summarizing usage", "error", err)
+		return nil, fmt.Errorf("error summarizing usage: %w", err)
+	}
+	return summary, nil
+}
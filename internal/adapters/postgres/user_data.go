@@ -0,0 +1,239 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"strings"
+)
+
+const userDataColumns = "user_id, thread_id, plan, last_active_at, retention_days, key_id"
+
+type userDataRepository struct {
+	logger ports.Logger
+	pool   *pgxpool.Pool
+}
+
+func NewUserDataRepository(pool *pgxpool.Pool, logger ports.Logger) (ports.UserDataAPIHandler, error) {
+	r := &userDataRepository{
+		logger: logger,
+		pool:   pool,
+	}
+	if r.pool == nil {
+		return nil, fmt.Errorf("pool can't be nil")
+	}
+	if r.logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	return r, nil
+}
+
+func (r *userDataRepository) GetByID(ctx context.Context, userID string) (*domain.UserData, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("can't get user with empty userID")
+	}
+
+	row := r.pool.QueryRow(ctx, "select "+userDataColumns+" from user_data where user_id = $1", userID)
+	userData, err := scanUserData(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: user_data not found", domain.ErrNotFound)
+	}
+	if err != nil {
+		r.logger.Error(ctx, "Error getting user", "error", err)
+		return nil, fmt.Errorf("error getting user: %w", err)
+	}
+	return userData, nil
+}
+
+func (r *userDataRepository) Insert(ctx context.Context, userID string, userData *domain.UserData) error {
+	if userID == "" {
+		return fmt.Errorf("can't insert user with empty userID")
+	}
+	if userData == nil {
+		return fmt.Errorf("can't insert nil userData")
+	}
+
+	userData.UserID = userID
+	// Entries are created active, so that their retention has a start
+	_, err := r.pool.Exec(ctx, "insert into user_data ("+userDataColumns+") values ($1, $2, $3, coalesce($4, now()), $5, $6)",
+		userID, userData.ThreadID, nullablePlan(userData.Plan), userData.LastActiveAt, userData.RetentionDays, userData.KeyID)
+	if err != nil {
+		r.logger.Error(ctx, "Error inserting user", "error", err)
+		return fmt.Errorf("error inserting user: %w", err)
+	}
+	return nil
+}
+
+func (r *userDataRepository) Update(ctx context.Context, userID string, userData *domain.UserData) error {
+	if userID == "" {
+		return fmt.Errorf("can't update user with empty userID")
+	}
+	if userData == nil {
+		return fmt.Errorf("can't update nil userData")
+	}
+
+	// An empty plan or last activity is left untouched, as they are omitted
+	// from the PATCH sent to the Supabase table
+	userData.UserID = userID
+	tag, err := r.pool.Exec(ctx, `update user_data set
+		thread_id = $2,
+		plan = coalesce($3, plan),
+		last_active_at = coalesce($4, last_active_at),
+		retention_days = $5,
+		key_id = $6
+		where user_id = $1`,
+		userID, userData.ThreadID, nullablePlan(userData.Plan), userData.LastActiveAt, userData.RetentionDays, userData.KeyID)
+	if err != nil {
+		r.logger.Error(ctx, "Error updating user", "error", err)
+		return fmt.Errorf("error updating user: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: user_data not found", domain.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *userDataRepository) Patch(ctx context.Context, userID string, patch domain.UserDataPatch) error {
+	if userID == "" {
+		return fmt.Errorf("can't patch user with empty userID")
+	}
+
+	args := []any{userID}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	var set []string
+	column := func(name string, v any) {
+		set = append(set, name+" = "+arg(v))
+	}
+	switch {
+	case patch.ClearThreadID:
+		set = append(set, "thread_id = null", "key_id = null")
+	case patch.ThreadID != nil:
+		column("thread_id", *patch.ThreadID)
+		column("key_id", patch.KeyID)
+	}
+	if patch.LastActiveAt != nil {
+		column("last_active_at", *patch.LastActiveAt)
+	}
+	if patch.Plan != nil {
+		column("plan", string(*patch.Plan))
+	}
+	if patch.RetentionDays != nil {
+		column("retention_days", *patch.RetentionDays)
+	}
+	if len(set) == 0 {
+		return nil
+	}
+
+	where := "user_id = $1"
+	if patch.If != nil {
+		where += " and thread_id = " + arg(patch.If.ThreadID) + " and key_id is not distinct from " + arg(patch.If.KeyID)
+		if !patch.If.InactiveBefore.IsZero() {
+			where += " and last_active_at < " + arg(patch.If.InactiveBefore)
+		}
+	}
+
+	tag, err := r.pool.Exec(ctx, "update user_data set "+strings.Join(set, ", ")+" where "+where, args...)
+	if err != nil {
+		r.logger.Error(ctx, "Error patching user", "error", err)
+		return fmt.Errorf("error patching user: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: user_data not found", domain.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *userDataRepository) Delete(ctx context.Context, userID string) error {
+	if userID == "" {
+		return fmt.Errorf("can't delete user with empty userID")
+	}
+
+	if _, err := r.pool.Exec(ctx, "delete from user_data where user_id = $1", userID); err != nil {
+		r.logger.Error(ctx, "Error deleting user", "error", err)
+		return fmt.Errorf("error deleting user: %w", err)
+	}
+	return nil
+}
+
+func (r *userDataRepository) List(ctx context.Context, filter domain.UserDataFilter) ([]domain.UserData, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if !filter.InactiveBefore.IsZero() {
+		conditions = append(conditions, "thread_id is not null", "last_active_at < "+arg(filter.InactiveBefore))
+	}
+	if filter.NotEncryptedWith != "" {
+		conditions = append(conditions, "thread_id is not null",
+			"(key_id is null or key_id <> "+arg(filter.NotEncryptedWith)+")")
+	}
+	if filter.AfterUserID != "" {
+		conditions = append(conditions, "user_id > "+arg(filter.AfterUserID))
+	}
+
+	query := "select " + userDataColumns + " from user_data"
+	if len(conditions) > 0 {
+		query += " where " + strings.Join(conditions, " and ")
+	}
+	query += " order by user_id"
+	if filter.Limit > 0 {
+		query += " limit " + arg(filter.Limit)
+	}
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		r.logger.Error(ctx, "Error listing users", "error", err)
+		return nil, fmt.Errorf("error listing users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []domain.UserData
+	for rows.Next() {
+		userData, err := scanUserData(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning user: %w", err)
+		}
+		users = append(users, *userData)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error(ctx, "Error listing users", "error", err)
+		return nil, fmt.Errorf("error listing users: %w", err)
+	}
+	return users, nil
+}
+
+func scanUserData(row pgx.Row) (*domain.UserData, error) {
+	var (
+		userData domain.UserData
+		plan     *string
+	)
+	err := row.Scan(&userData.UserID, &userData.ThreadID, &plan, &userData.LastActiveAt,
+		&userData.RetentionDays, &userData.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if plan != nil {
+		userData.Plan = domain.Plan(*plan)
+	}
+	return &userData, nil
+}
+
+func nullablePlan(p domain.Plan) *string {
+	if p == "" {
+		return nil
+	}
+	s := string(p)
+	return &s
+}
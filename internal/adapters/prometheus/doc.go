@@ -0,0 +1,4 @@
+// Package prometheus provides an implementation of the ports.Metrics interface
+// exposing the measurements in the Prometheus format
+// (github.com/prometheus/client_golang).
+package prometheus
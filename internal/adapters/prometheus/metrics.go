@@ -0,0 +1,164 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+// namespace prefixes the name of every metric.
+const namespace = "stress_relief"
+
+// Metrics records the measurements in its own registry, served by Handler.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests   *prometheus.CounterVec
+	httpDuration   *prometheus.HistogramVec
+	messages       *prometheus.CounterVec
+	llmDuration    *prometheus.HistogramVec
+	llmErrors      *prometheus.CounterVec
+	runs           *prometheus.CounterVec
+	runPolls       prometheus.Histogram
+	tokens         *prometheus.CounterVec
+	storageLatency *prometheus.HistogramVec
+	storageErrors  *prometheus.CounterVec
+}
+
+var _ ports.Metrics = (*Metrics)(nil)
+
+// NewMetrics creates the metrics, along with the Go runtime and process
+// collectors.
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests served, by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to serve HTTP requests, by method and route.",
+			// Chat messages wait for the assistant run, so allow for long
+			// requests
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 20, 30, 60},
+		}, []string{"method", "route"}),
+		messages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "chat_messages_total",
+			Help:      "Chat messages, by outcome.",
+		}, []string{"outcome"}),
+		llmDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "llm_call_duration_seconds",
+			Help:      "Time taken by calls to the LLM provider, by operation.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 20, 30, 60},
+		}, []string{"operation"}),
+		llmErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "llm_call_errors_total",
+			Help:      "Failed calls to the LLM provider, by operation.",
+		}, []string{"operation"}),
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "llm_runs_total",
+			Help:      "Finished assistant runs, by terminal status.",
+		}, []string{"status"}),
+		runPolls: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "llm_run_polls",
+			Help:      "Times an assistant run was polled before finishing.",
+			Buckets:   []float64{1, 2, 4, 8, 16, 32, 64, 128},
+		}),
+		tokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "llm_tokens_total",
+			Help:      "Tokens used by the LLM, by kind.",
+		}, []string{"kind"}),
+		storageLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "storage_call_duration_seconds",
+			Help:      "Time taken by calls to storage backends, by backend and operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"backend", "operation"}),
+		storageErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "storage_call_errors_total",
+			Help:      "Failed calls to storage backends, by backend and operation.",
+		}, []string{"backend", "operation"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests, m.httpDuration, m.messages, m.llmDuration, m.llmErrors, m.runs, m.runPolls, m.tokens,
+		m.storageLatency, m.storageErrors,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RegisterCacheStats exposes the stats of a cache, read at scrape time.
+func (m *Metrics) RegisterCacheStats(cache string, reporter ports.CacheStatsReporter) {
+	for _, c := range []struct {
+		result string
+		value  func(domain.CacheStats) uint64
+	}{
+		{"hit", func(s domain.CacheStats) uint64 { return s.Hits }},
+		{"negative_hit", func(s domain.CacheStats) uint64 { return s.NegativeHits }},
+		{"miss", func(s domain.CacheStats) uint64 { return s.Misses }},
+	} {
+		value := c.value
+		m.registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "cache_lookups_total",
+			Help:        "Cache lookups, by cache and result.",
+			ConstLabels: prometheus.Labels{"cache": cache, "result": c.result},
+		}, func() float64 { return float64(value(reporter.CacheStats())) }))
+	}
+}
+
+func (m *Metrics) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveMessage(outcome string) {
+	m.messages.WithLabelValues(outcome).Inc()
+}
+
+func (m *Metrics) ObserveLLMCall(operation string, duration time.Duration, err error) {
+	m.llmDuration.WithLabelValues(operation).Observe(duration.Seconds())
+	if err != nil {
+		m.llmErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+func (m *Metrics) ObserveRun(status string, polls int) {
+	m.runs.WithLabelValues(status).Inc()
+	m.runPolls.Observe(float64(polls))
+}
+
+func (m *Metrics) AddTokens(kind string, tokens int) {
+	if tokens > 0 {
+		m.tokens.WithLabelValues(kind).Add(float64(tokens))
+	}
+}
+
+func (m *Metrics) ObserveStorageCall(backend, operation string, duration time.Duration, err error) {
+	m.storageLatency.WithLabelValues(backend, operation).Observe(duration.Seconds())
+	if err != nil {
+		m.storageErrors.WithLabelValues(backend, operation).Inc()
+	}
+}
@@ -0,0 +1,2 @@
+// Package ratelimit provides implementations of the ports.RateLimitStore interface.
+package ratelimit
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often idle buckets are removed from memory.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens   float64
+	last     time.Time
+	capacity float64
+	// rate is the number of tokens added per second.
+	rate float64
+}
+
+type memoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore creates a RateLimitStore that keeps the token buckets in
+// memory. Limits are only enforced per instance.
+func NewMemoryStore() ports.RateLimitStore {
+	return &memoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (s *memoryStore) Take(ctx context.Context, key string, limit domain.RateLimit) (*domain.RateLimitResult, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{
+			tokens:   float64(limit.Limit),
+			last:     now,
+			capacity: float64(limit.Limit),
+			rate:     float64(limit.Limit) / limit.Period.Seconds(),
+		}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	res := &domain.RateLimitResult{Limit: limit.Limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / b.rate)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = seconds((b.capacity - b.tokens) / b.rate)
+
+	return res, nil
+}
+
+// sweep removes the buckets that have been idle long enough to be full again,
+// as they are equivalent to a missing bucket. It must be called with s.mu held.
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.capacity {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
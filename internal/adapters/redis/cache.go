@@ -0,0 +1,52 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+type cache struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewCache creates a Cache storing values in Redis, under keys starting with
+// prefix so the server can be shared with other applications.
+func NewCache(client *redis.Client, prefix string) (ports.Cache, error) {
+	if client == nil {
+		return nil, fmt.Errorf("client can't be nil")
+	}
+	return &cache{client: client, prefix: prefix}, nil
+}
+
+func (c *cache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	switch {
+	case errors.Is(err, redis.Nil):
+		return nil, false, nil
+	case err != nil:
+		return nil, false, fmt.Errorf("error getting cached value: %w", err)
+	}
+	return value, true, nil
+}
+
+func (c *cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("ttl must be positive")
+	}
+	if err := c.client.Set(ctx, c.prefix+key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("error setting cached value: %w", err)
+	}
+	return nil
+}
+
+func (c *cache) Delete(ctx context.Context, key string) error {
+	if err := c.client.Del(ctx, c.prefix+key).Err(); err != nil {
+		return fmt.Errorf("error deleting cached value: %w", err)
+	}
+	return nil
+}
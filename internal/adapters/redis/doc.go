@@ -0,0 +1,3 @@
+// Package redis provides implementations of ports backed by a Redis server,
+// shared by the instances of the service.
+package redis
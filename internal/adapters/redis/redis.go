@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"stress-relief-ai-chat-back/internal/ports"
+)
+
+// NewClient creates a client of the Redis server at url, such as
+// redis://:password@localhost:6379/0. Client settings such as pool_size can be
+// passed in the url.
+func NewClient(ctx context.Context, url string) (*redis.Client, error) {
+	if url == "" {
+		return nil, fmt.Errorf("url can't be empty")
+	}
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse url: %w", err)
+	}
+	client := redis.NewClient(options)
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("could not connect to Redis: %w", err)
+	}
+	return client, nil
+}
+
+type healthChecker struct {
+	client *redis.Client
+}
+
+// NewHealthChecker creates a HealthChecker that pings the Redis server.
+func NewHealthChecker(client *redis.Client) (ports.HealthChecker, error) {
+	if client == nil {
+		return nil, fmt.Errorf("client can't be nil")
+	}
+	return &healthChecker{client: client}, nil
+}
+
+func (h *healthChecker) Name() string {
+	return "redis"
+}
+
+func (h *healthChecker) Check(ctx context.Context) error {
+	return h.client.Ping(ctx).Err()
+}
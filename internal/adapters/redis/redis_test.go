@@ -0,0 +1,94 @@
+package redis
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"os"
+	"testing"
+	"time"
+)
+
+// The tests in this file run against the Redis server at TEST_REDIS_URL, e.g.
+// the one started by make redis-up, and are skipped when it isn't set. They
+// use fresh key prefixes so they can share it with other data.
+const testURLEnv = "TEST_REDIS_URL"
+
+func testCache(t *testing.T) *cache {
+	t.Helper()
+	url := os.Getenv(testURLEnv)
+	if url == "" {
+		t.Skipf("%s is not set", testURLEnv)
+	}
+
+	client, err := NewClient(context.Background(), url)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	c, err := NewCache(client, "test:"+uuid.NewString()+":")
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+	return c.(*cache)
+}
+
+func TestCache(t *testing.T) {
+	c := testCache(t)
+	ctx := context.Background()
+
+	if _, ok, err := c.Get(ctx, "key"); err != nil || ok {
+		t.Fatalf("Get of a missing key: got %v, %v", ok, err)
+	}
+
+	value := []byte("value")
+	if err := c.Set(ctx, "key", value, time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	value[0] = 'X'
+	got, ok, err := c.Get(ctx, "key")
+	if err != nil || !ok || string(got) != "value" {
+		t.Fatalf("Get: got %q, %v, %v, want value", got, ok, err)
+	}
+	if ttl := c.client.TTL(ctx, c.prefix+"key").Val(); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("TTL: got %v, want at most a minute", ttl)
+	}
+
+	if err := c.Delete(ctx, "key"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok, err := c.Get(ctx, "key"); err != nil || ok {
+		t.Fatalf("Get after Delete: got %v, %v", ok, err)
+	}
+	if err := c.Delete(ctx, "key"); err != nil {
+		t.Fatalf("Delete of a missing key: %v", err)
+	}
+}
+
+// TestCacheShared checks that an invalidation through one client is seen by
+// another, as by another instance of the service.
+func TestCacheShared(t *testing.T) {
+	c := testCache(t)
+	ctx := context.Background()
+	other, err := NewClient(ctx, os.Getenv(testURLEnv))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { _ = other.Close() })
+	otherCache, err := NewCache(other, c.prefix)
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+
+	if err := c.Set(ctx, "key", []byte("value"), time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, ok, err := otherCache.Get(ctx, "key"); err != nil || !ok || string(got) != "value" {
+		t.Fatalf("Get from the other client: got %q, %v, %v, want value", got, ok, err)
+	}
+	if err := otherCache.Delete(ctx, "key"); err != nil {
+		t.Fatalf("Delete from the other client: %v", err)
+	}
+	if _, ok, err := c.Get(ctx, "key"); err != nil || ok {
+		t.Fatalf("Get after Delete from the other client: got %v, %v", ok, err)
+	}
+}
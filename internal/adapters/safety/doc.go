@@ -0,0 +1,3 @@
+// Package safety provides implementations of the ports.SafetyClassifier
+// interface.
+package safety
@@ -0,0 +1,57 @@
+package safety
+
+import (
+	"context"
+	"regexp"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+)
+
+// keywordRule flags the messages matching pattern with category.
+type keywordRule struct {
+	category string
+	name     string
+	pattern  *regexp.Regexp
+}
+
+// keywordRules are matched in order, the first matching rule flags the
+// message.
+var keywordRules = []keywordRule{
+	{
+		category: domain.SafetyCategorySelfHarm,
+		name:     "suicidal ideation",
+		pattern:  regexp.MustCompile(`(?i)\b(kill(ing)? myself|suicid(e|al)|end(ing)? (my|it) (life|all)|want(ed)? to die|better off dead|no reason to live)\b`),
+	},
+	{
+		category: domain.SafetyCategorySelfHarm,
+		name:     "self-harm",
+		pattern:  regexp.MustCompile(`(?i)\b(hurt(ing)? myself|harm(ing)? myself|self[- ]harm|cut(ting)? myself|overdos(e|ing))\b`),
+	},
+	{
+		category: domain.SafetyCategoryHarmToOthers,
+		name:     "threat of violence",
+		pattern:  regexp.MustCompile(`(?i)\b(kill|hurt|shoot|stab) (him|her|them|someone|somebody|everyone|my \w+)\b`),
+	},
+}
+
+type keywordClassifier struct{}
+
+// NewKeywordClassifier creates a SafetyClassifier that flags the messages
+// mentioning self-harm or violence with a fixed list of phrases. It is meant
+// as a safety net, it misses indirect wording and flags some harmless
+// messages.
+func NewKeywordClassifier() ports.SafetyClassifier {
+	return keywordClassifier{}
+}
+
+func (keywordClassifier) Classify(ctx context.Context, message string) (*domain.SafetyFlag, error) {
+	for _, rule := range keywordRules {
+		if rule.pattern.MatchString(message) {
+			return &domain.SafetyFlag{
+				Category: rule.category,
+				Reason:   "message mentions " + rule.name,
+			}, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,120 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/google/uuid"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"strings"
+	"time"
+)
+
+type auditLogger struct {
+	db     *sql.DB
+	logger ports.Logger
+}
+
+func NewAuditLogger(db *sql.DB, logger ports.Logger) (ports.AuditLogger, error) {
+	a := &auditLogger{
+		db:     db,
+		logger: logger,
+	}
+	if a.db == nil {
+		return nil, fmt.Errorf("db can't be nil")
+	}
+	if a.logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	return a, nil
+}
+
+func (a *auditLogger) Record(ctx context.Context, event *domain.AuditEvent) error {
+	if event == nil {
+		return fmt.Errorf("can't record nil event")
+	}
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+
+	_, err := a.db.ExecContext(ctx, `insert into audit_log
+		(id, request_id, actor, action, target_user_id, outcome, details, created_at)
+		values (?, nullif(?, ''), ?, ?, nullif(?, ''), ?, nullif(?, ''), ?)`,
+		event.ID, event.RequestID, event.Actor, event.Action, event.TargetUserID, event.Outcome, event.Details,
+		formatTime(event.CreatedAt))
+	if err != nil {
+		a.logger.Error(ctx, "Error recording audit event", "error", err)
+		return fmt.Errorf("error recording audit event: %w", err)
+	}
+	return nil
+}
+
+func (a *auditLogger) Query(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEvent, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if q.Actor != "" {
+		conditions = append(conditions, "actor = ?")
+		args = append(args, q.Actor)
+	}
+	if q.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, q.Action)
+	}
+	if q.TargetUserID != "" {
+		conditions = append(conditions, "target_user_id = ?")
+		args = append(args, q.TargetUserID)
+	}
+	if !q.Since.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, formatTime(q.Since))
+	}
+	if !q.Until.IsZero() {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, formatTime(q.Until))
+	}
+
+	query := `select id, coalesce(request_id, ''), actor, action, coalesce(target_user_id, ''), outcome,
+		coalesce(details, ''), created_at from audit_log`
+	if len(conditions) > 0 {
+		query += " where " + strings.Join(conditions, " and ")
+	}
+	query += " order by created_at desc"
+	if q.Limit > 0 {
+		query += " limit ?"
+		args = append(args, q.Limit)
+	}
+
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		a.logger.Error(ctx, "Error querying audit log", "error", err)
+		return nil, fmt.Errorf("error querying audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var events []domain.AuditEvent
+	for rows.Next() {
+		var (
+			e         domain.AuditEvent
+			createdAt string
+		)
+		err := rows.Scan(&e.ID, &e.RequestID, &e.Actor, &e.Action, &e.TargetUserID, &e.Outcome, &e.Details, &createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning audit event: %w", err)
+		}
+		if e.CreatedAt, err = parseTime(createdAt); err != nil {
+			return nil, fmt.Errorf("error scanning audit event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error querying audit log: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,6 @@
+// Package sqlite provides implementations of the repository ports backed by an
+// embedded SQLite database, for local development and single node deployments
+// that don't need an external database.
+//
+// The schema is managed with the embedded SQL migrations, see Migrate.
+package sqlite
@@ -0,0 +1,164 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+type exportRepository struct {
+	db     *sql.DB
+	logger ports.Logger
+}
+
+func NewExportRepository(db *sql.DB, logger ports.Logger) (ports.ExportRepository, error) {
+	r := &exportRepository{
+		db:     db,
+		logger: logger,
+	}
+	if r.db == nil {
+		return nil, fmt.Errorf("db can't be nil")
+	}
+	if r.logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	return r, nil
+}
+
+func (r *exportRepository) Insert(ctx context.Context, export *domain.DataExport) error {
+	if export == nil {
+		return fmt.Errorf("can't insert nil export")
+	}
+
+	_, err := r.db.ExecContext(ctx, `insert into data_exports (id, user_id, status, error, archive, created_at, completed_at)
+		values (?, ?, ?, nullif(?, ''), ?, ?, ?)`,
+		export.ID, export.UserID, export.Status, export.Error, export.Archive, formatTime(export.CreatedAt),
+		formatNullableTime(export.CompletedAt))
+	if err != nil {
+		r.logger.Error(ctx, "Error inserting export", "error", err)
+		return fmt.Errorf("error inserting export: %w", err)
+	}
+	return nil
+}
+
+func (r *exportRepository) Update(ctx context.Context, export *domain.DataExport) error {
+	if export == nil {
+		return fmt.Errorf("can't update nil export")
+	}
+
+	res, err := r.db.ExecContext(ctx, `update data_exports
+		set status = ?, error = nullif(?, ''), archive = ?, completed_at = ?
+		where id = ?`,
+		export.Status, export.Error, export.Archive, formatNullableTime(export.CompletedAt), export.ID)
+	if err != nil {
+		r.logger.Error(ctx, "Error updating export", "error", err)
+		return fmt.Errorf("error updating export: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error updating export: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: export not found", domain.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *exportRepository) Get(ctx context.Context, exportID string) (*domain.DataExport, error) {
+	row := r.db.QueryRowContext(ctx, `select id, user_id, status, coalesce(error, ''), archive, created_at, completed_at
+		from data_exports where id = ?`, exportID)
+	export, err := scanExport(row, true)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: export not found", domain.ErrNotFound)
+	}
+	if err != nil {
+		r.logger.Error(ctx, "Error getting export", "error", err)
+		return nil, fmt.Errorf("error getting export: %w", err)
+	}
+	return export, nil
+}
+
+func (r *exportRepository) ListByUser(ctx context.Context, userID string) ([]domain.DataExport, error) {
+	rows, err := r.db.QueryContext(ctx, `select id, user_id, status, coalesce(error, ''), created_at, completed_at
+		from data_exports where user_id = ? order by created_at`, userID)
+	if err != nil {
+		r.logger.Error(ctx, "Error listing exports", "error", err)
+		return nil, fmt.Errorf("error listing exports: %w", err)
+	}
+	defer rows.Close()
+
+	exports := []domain.DataExport{}
+	for rows.Next() {
+		export, err := scanExport(rows, false)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning export: %w", err)
+		}
+		exports = append(exports, *export)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing exports: %w", err)
+	}
+	return exports, nil
+}
+
+func (r *exportRepository) Delete(ctx context.Context, exportID string) error {
+	if _, err := r.db.ExecContext(ctx, "delete from data_exports where id = ?", exportID); err != nil {
+		r.logger.Error(ctx, "Error deleting export", "error", err)
+		return fmt.Errorf("error deleting export: %w", err)
+	}
+	return nil
+}
+
+func (r *exportRepository) DeleteByUser(ctx context.Context, userID string) error {
+	if userID == "" {
+		return fmt.Errorf("can't delete exports with empty userID")
+	}
+	if _, err := r.db.ExecContext(ctx, "delete from data_exports where user_id = ?", userID); err != nil {
+		r.logger.Error(ctx, "Error deleting exports", "error", err)
+		return fmt.Errorf("error deleting exports: %w", err)
+	}
+	return nil
+}
+
+func (r *exportRepository) DeleteCreatedBefore(ctx context.Context, t time.Time) error {
+	if _, err := r.db.ExecContext(ctx, "delete from data_exports where created_at < ?", formatTime(t)); err != nil {
+		r.logger.Error(ctx, "Error deleting expired exports", "error", err)
+		return fmt.Errorf("error deleting expired exports: %w", err)
+	}
+	return nil
+}
+
+// scanExport scans the columns of an export, along with its archive if
+// withArchive is set.
+func scanExport(row scanner, withArchive bool) (*domain.DataExport, error) {
+	var (
+		export      domain.DataExport
+		createdAt   string
+		completedAt sql.NullString
+	)
+	dest := []any{&export.ID, &export.UserID, &export.Status, &export.Error}
+	if withArchive {
+		dest = append(dest, &export.Archive)
+	}
+	dest = append(dest, &createdAt, &completedAt)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if export.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+	if completedAt.Valid {
+		t, err := parseTime(completedAt.String)
+		if err != nil {
+			return nil, err
+		}
+		export.CompletedAt = &t
+	}
+	return &export, nil
+}
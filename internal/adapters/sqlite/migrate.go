@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"stress-relief-ai-chat-back/internal/ports"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrate applies the embedded migrations that have not been applied yet, in
+// order of their file name. Each migration runs in its own transaction.
+func Migrate(ctx context.Context, db *sql.DB, logger ports.Logger) error {
+	_, err := db.ExecContext(ctx, `create table if not exists schema_migrations (
+		version    text primary key,
+		applied_at text not null
+	)`)
+	if err != nil {
+		return fmt.Errorf("could not create schema_migrations table: %w", err)
+	}
+
+	files, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return fmt.Errorf("could not list migrations: %w", err)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		version := strings.TrimSuffix(strings.TrimPrefix(file, "migrations/"), ".sql")
+		sql, err := migrations.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("could not read migration %s: %w", version, err)
+		}
+
+		applied, err := apply(ctx, db, version, string(sql))
+		if err != nil {
+			return fmt.Errorf("could not apply migration %s: %w", version, err)
+		}
+		if applied {
+			logger.Info(ctx, "Applied migration", "version", version)
+		}
+	}
+	return nil
+}
+
+// apply runs a migration unless it has already been applied. It reports
+// whether the migration was applied.
+func apply(ctx context.Context, db *sql.DB, version, migration string) (bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, "select exists(select 1 from schema_migrations where version = ?)", version).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, migration); err != nil {
+		return false, err
+	}
+	_, err = tx.ExecContext(ctx, "insert into schema_migrations (version, applied_at) values (?, ?)",
+		version, formatTime(time.Now()))
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
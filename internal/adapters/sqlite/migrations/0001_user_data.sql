@@ -0,0 +1,12 @@
+create table if not exists user_data (
+    user_id        text primary key,
+    thread_id      text,
+    plan           text,
+    last_active_at text,
+    retention_days integer,
+    key_id         text
+);
+
+create index if not exists user_data_last_active_at_idx
+    on user_data (last_active_at)
+    where thread_id is not null;
@@ -0,0 +1,4 @@
+create table if not exists user_tombstones (
+    key        text primary key,
+    created_at text not null
+);
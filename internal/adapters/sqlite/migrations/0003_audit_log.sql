@@ -0,0 +1,26 @@
+create table if not exists audit_log (
+    id             text primary key,
+    request_id     text,
+    actor          text not null,
+    action         text not null,
+    target_user_id text,
+    outcome        text not null,
+    details        text,
+    created_at     text not null
+);
+
+create index if not exists audit_log_created_at_idx on audit_log (created_at desc);
+create index if not exists audit_log_target_user_id_idx on audit_log (target_user_id, created_at desc);
+
+-- The audit log is append-only
+create trigger if not exists audit_log_no_update
+    before update on audit_log
+begin
+    select raise(abort, 'audit_log is append-only');
+end;
+
+create trigger if not exists audit_log_no_delete
+    before delete on audit_log
+begin
+    select raise(abort, 'audit_log is append-only');
+end;
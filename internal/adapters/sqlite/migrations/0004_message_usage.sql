@@ -0,0 +1,8 @@
+create table if not exists message_usage (
+    user_id text primary key,
+    day     text not null,
+    plan    text not null,
+    count   integer not null
+);
+
+create index if not exists message_usage_day_idx on message_usage (day);
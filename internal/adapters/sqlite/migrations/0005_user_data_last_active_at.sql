@@ -0,0 +1,5 @@
+-- Entries without a last activity were never selected for retention. Their
+-- retention starts now.
+update user_data
+set last_active_at = strftime('%Y-%m-%dT%H:%M:%S', 'now') || '.000000000Z'
+where last_active_at is null;
@@ -0,0 +1,11 @@
+create table if not exists safety_events (
+    id         text primary key,
+    user_id    text not null,
+    thread_id  text,
+    category   text not null,
+    details    text,
+    created_at text not null
+);
+
+create index if not exists safety_events_created_at_idx on safety_events (created_at desc);
+create index if not exists safety_events_user_id_idx on safety_events (user_id);
@@ -0,0 +1,12 @@
+create table if not exists data_exports (
+    id           text primary key,
+    user_id      text not null,
+    status       text not null,
+    error        text,
+    archive      blob,
+    created_at   text not null,
+    completed_at text
+);
+
+create index if not exists data_exports_user_id_idx on data_exports (user_id, created_at);
+create index if not exists data_exports_created_at_idx on data_exports (created_at);
@@ -0,0 +1,99 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/google/uuid"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+type safetyEventRepository struct {
+	db     *sql.DB
+	logger ports.Logger
+}
+
+func NewSafetyEventRepository(db *sql.DB, logger ports.Logger) (ports.SafetyEventRepository, error) {
+	r := &safetyEventRepository{
+		db:     db,
+		logger: logger,
+	}
+	if r.db == nil {
+		return nil, fmt.Errorf("db can't be nil")
+	}
+	if r.logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	return r, nil
+}
+
+func (r *safetyEventRepository) Record(ctx context.Context, event *domain.SafetyEvent) error {
+	if event == nil {
+		return fmt.Errorf("can't record nil event")
+	}
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+
+	_, err := r.db.ExecContext(ctx, `insert into safety_events (id, user_id, thread_id, category, details, created_at)
+		values (?, ?, nullif(?, ''), ?, nullif(?, ''), ?)`,
+		event.ID, event.UserID, event.ThreadID, event.Category, event.Details, formatTime(event.CreatedAt))
+	if err != nil {
+		r.logger.Error(ctx, "Error recording safety event", "error", err)
+		return fmt.Errorf("error recording safety event: %w", err)
+	}
+	return nil
+}
+
+func (r *safetyEventRepository) List(ctx context.Context, limit int) ([]domain.SafetyEvent, error) {
+	query := `select id, user_id, coalesce(thread_id, ''), category, coalesce(details, ''), created_at
+		from safety_events order by created_at desc`
+	var args []any
+	if limit > 0 {
+		query += " limit ?"
+		args = append(args, limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error(ctx, "Error listing safety events", "error", err)
+		return nil, fmt.Errorf("error listing safety events: %w", err)
+	}
+	defer rows.Close()
+
+	// An empty list is listed as such, not as null
+	events := []domain.SafetyEvent{}
+	for rows.Next() {
+		var (
+			e         domain.SafetyEvent
+			createdAt string
+		)
+		if err := rows.Scan(&e.ID, &e.UserID, &e.ThreadID, &e.Category, &e.Details, &createdAt); err != nil {
+			return nil, fmt.Errorf("error scanning safety event: %w", err)
+		}
+		if e.CreatedAt, err = parseTime(createdAt); err != nil {
+			return nil, fmt.Errorf("error scanning safety event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing safety events: %w", err)
+	}
+	return events, nil
+}
+
+func (r *safetyEventRepository) DeleteByUser(ctx context.Context, userID string) error {
+	if userID == "" {
+		return fmt.Errorf("can't delete safety events with empty userID")
+	}
+	if _, err := r.db.ExecContext(ctx, "delete from safety_events where user_id = ?", userID); err != nil {
+		r.logger.Error(ctx, "Error deleting safety events", "error", err)
+		return fmt.Errorf("error deleting safety events: %w", err)
+	}
+	return nil
+}
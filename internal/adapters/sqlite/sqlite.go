@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+	"net/url"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+// timeLayout is the layout times are stored in. Times are always stored in UTC
+// with a fixed number of fractional digits so that they sort as text.
+const timeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// Open opens the SQLite database at path, creating it if it doesn't exist.
+// The database is opened in WAL mode with a single connection, so writes
+// from concurrent requests are serialized instead of failing as busy.
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path can't be empty")
+	}
+
+	params := url.Values{}
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "foreign_keys(1)")
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("could not open database: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not open database: %w", err)
+	}
+	return db, nil
+}
+
+type healthChecker struct {
+	db *sql.DB
+}
+
+// NewHealthChecker creates a HealthChecker that pings the database.
+func NewHealthChecker(db *sql.DB) (ports.HealthChecker, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db can't be nil")
+	}
+	return &healthChecker{db: db}, nil
+}
+
+func (h *healthChecker) Name() string {
+	return "sqlite"
+}
+
+func (h *healthChecker) Check(ctx context.Context) error {
+	return h.db.PingContext(ctx)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+func formatNullableTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := formatTime(*t)
+	return &s
+}
+
+func parseTime(s string) (time.Time, error) {
+	t, err := time.Parse(timeLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid stored time %q: %w", s, err)
+	}
+	return t, nil
+}
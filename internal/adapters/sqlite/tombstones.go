@@ -0,0 +1,54 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+type tombstoneRepository struct {
+	db     *sql.DB
+	logger ports.Logger
+}
+
+func NewTombstoneRepository(db *sql.DB, logger ports.Logger) (ports.TombstoneRepository, error) {
+	r := &tombstoneRepository{
+		db:     db,
+		logger: logger,
+	}
+	if r.db == nil {
+		return nil, fmt.Errorf("db can't be nil")
+	}
+	if r.logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	return r, nil
+}
+
+func (r *tombstoneRepository) Add(ctx context.Context, key string) error {
+	if key == "" {
+		return fmt.Errorf("can't add tombstone with empty key")
+	}
+	_, err := r.db.ExecContext(ctx, "insert into user_tombstones (key, created_at) values (?, ?) on conflict do nothing",
+		key, formatTime(time.Now()))
+	if err != nil {
+		r.logger.Error(ctx, "Error adding tombstone", "error", err)
+		return fmt.Errorf("error adding tombstone: %w", err)
+	}
+	return nil
+}
+
+func (r *tombstoneRepository) Exists(ctx context.Context, key string) (bool, error) {
+	if key == "" {
+		return false, fmt.Errorf("can't look up tombstone with empty key")
+	}
+	var exists bool
+	err := r.db.QueryRowContext(ctx, "select exists(select 1 from user_tombstones where key = ?)", key).Scan(&exists)
+	if err != nil {
+		r.logger.Error(ctx, "Error getting tombstone", "error", err)
+		return false, fmt.Errorf("error getting tombstone: %w", err)
+	}
+	return exists, nil
+}
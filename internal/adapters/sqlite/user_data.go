@@ -0,0 +1,258 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"strings"
+	"time"
+)
+
+const userDataColumns = "user_id, thread_id, plan, last_active_at, retention_days, key_id"
+
+type userDataRepository struct {
+	db     *sql.DB
+	logger ports.Logger
+}
+
+func NewUserDataRepository(db *sql.DB, logger ports.Logger) (ports.UserDataAPIHandler, error) {
+	r := &userDataRepository{
+		db:     db,
+		logger: logger,
+	}
+	if r.db == nil {
+		return nil, fmt.Errorf("db can't be nil")
+	}
+	if r.logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	return r, nil
+}
+
+func (r *userDataRepository) GetByID(ctx context.Context, userID string) (*domain.UserData, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("can't get user with empty userID")
+	}
+
+	row := r.db.QueryRowContext(ctx, "select "+userDataColumns+" from user_data where user_id = ?", userID)
+	userData, err := scanUserData(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: user_data not found", domain.ErrNotFound)
+	}
+	if err != nil {
+		r.logger.Error(ctx, "Error getting user", "error", err)
+		return nil, fmt.Errorf("error getting user: %w", err)
+	}
+	return userData, nil
+}
+
+func (r *userDataRepository) Insert(ctx context.Context, userID string, userData *domain.UserData) error {
+	if userID == "" {
+		return fmt.Errorf("can't insert user with empty userID")
+	}
+	if userData == nil {
+		return fmt.Errorf("can't insert nil userData")
+	}
+
+	userData.UserID = userID
+	// Entries are created active, so that their retention has a start
+	lastActiveAt := userData.LastActiveAt
+	if lastActiveAt == nil {
+		now := time.Now()
+		lastActiveAt = &now
+	}
+	_, err := r.db.ExecContext(ctx, "insert into user_data ("+userDataColumns+") values (?, ?, ?, ?, ?, ?)",
+		userID, userData.ThreadID, nullablePlan(userData.Plan), formatNullableTime(lastActiveAt),
+		userData.RetentionDays, userData.KeyID)
+	if err != nil {
+		r.logger.Error(ctx, "Error inserting user", "error", err)
+		return fmt.Errorf("error inserting user: %w", err)
+	}
+	return nil
+}
+
+func (r *userDataRepository) Update(ctx context.Context, userID string, userData *domain.UserData) error {
+	if userID == "" {
+		return fmt.Errorf("can't update user with empty userID")
+	}
+	if userData == nil {
+		return fmt.Errorf("can't update nil userData")
+	}
+
+	// An empty plan or last activity is left untouched, as they are omitted
+	// from the PATCH sent to the Supabase table
+	userData.UserID = userID
+	res, err := r.db.ExecContext(ctx, `update user_data set
+		thread_id = ?2,
+		plan = coalesce(?3, plan),
+		last_active_at = coalesce(?4, last_active_at),
+		retention_days = ?5,
+		key_id = ?6
+		where user_id = ?1`,
+		userID, userData.ThreadID, nullablePlan(userData.Plan), formatNullableTime(userData.LastActiveAt),
+		userData.RetentionDays, userData.KeyID)
+	if err != nil {
+		r.logger.Error(ctx, "Error updating user", "error", err)
+		return fmt.Errorf("error updating user: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: user_data not found", domain.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *userDataRepository) Patch(ctx context.Context, userID string, patch domain.UserDataPatch) error {
+	if userID == "" {
+		return fmt.Errorf("can't patch user with empty userID")
+	}
+
+	var (
+		set  []string
+		args []any
+	)
+	switch {
+	case patch.ClearThreadID:
+		set = append(set, "thread_id = null", "key_id = null")
+	case patch.ThreadID != nil:
+		set = append(set, "thread_id = ?", "key_id = ?")
+		args = append(args, *patch.ThreadID, patch.KeyID)
+	}
+	if patch.LastActiveAt != nil {
+		set = append(set, "last_active_at = ?")
+		args = append(args, formatTime(*patch.LastActiveAt))
+	}
+	if patch.Plan != nil {
+		set = append(set, "plan = ?")
+		args = append(args, string(*patch.Plan))
+	}
+	if patch.RetentionDays != nil {
+		set = append(set, "retention_days = ?")
+		args = append(args, *patch.RetentionDays)
+	}
+	if len(set) == 0 {
+		return nil
+	}
+
+	where := "user_id = ?"
+	args = append(args, userID)
+	if patch.If != nil {
+		// is compares null values as equal
+		where += " and thread_id = ? and key_id is ?"
+		args = append(args, patch.If.ThreadID, patch.If.KeyID)
+		if !patch.If.InactiveBefore.IsZero() {
+			where += " and last_active_at < ?"
+			args = append(args, formatTime(patch.If.InactiveBefore))
+		}
+	}
+
+	res, err := r.db.ExecContext(ctx, "update user_data set "+strings.Join(set, ", ")+" where "+where, args...)
+	if err != nil {
+		r.logger.Error(ctx, "Error patching user", "error", err)
+		return fmt.Errorf("error patching user: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("%w: user_data not found", domain.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *userDataRepository) Delete(ctx context.Context, userID string) error {
+	if userID == "" {
+		return fmt.Errorf("can't delete user with empty userID")
+	}
+
+	if _, err := r.db.ExecContext(ctx, "delete from user_data where user_id = ?", userID); err != nil {
+		r.logger.Error(ctx, "Error deleting user", "error", err)
+		return fmt.Errorf("error deleting user: %w", err)
+	}
+	return nil
+}
+
+func (r *userDataRepository) List(ctx context.Context, filter domain.UserDataFilter) ([]domain.UserData, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if !filter.InactiveBefore.IsZero() {
+		conditions = append(conditions, "thread_id is not null", "last_active_at < ?")
+		args = append(args, formatTime(filter.InactiveBefore))
+	}
+	if filter.NotEncryptedWith != "" {
+		conditions = append(conditions, "thread_id is not null", "(key_id is null or key_id <> ?)")
+		args = append(args, filter.NotEncryptedWith)
+	}
+	if filter.AfterUserID != "" {
+		conditions = append(conditions, "user_id > ?")
+		args = append(args, filter.AfterUserID)
+	}
+
+	query := "select " + userDataColumns + " from user_data"
+	if len(conditions) > 0 {
+		query += " where " + strings.Join(conditions, " and ")
+	}
+	query += " order by user_id"
+	if filter.Limit > 0 {
+		query += " limit ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error(ctx, "Error listing users", "error", err)
+		return nil, fmt.Errorf("error listing users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []domain.UserData
+	for rows.Next() {
+		userData, err := scanUserData(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning user: %w", err)
+		}
+		users = append(users, *userData)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error(ctx, "Error listing users", "error", err)
+		return nil, fmt.Errorf("error listing users: %w", err)
+	}
+	return users, nil
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUserData(row scanner) (*domain.UserData, error) {
+	var (
+		userData     domain.UserData
+		plan         sql.NullString
+		lastActiveAt sql.NullString
+	)
+	err := row.Scan(&userData.UserID, &userData.ThreadID, &plan, &lastActiveAt, &userData.RetentionDays,
+		&userData.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	userData.Plan = domain.Plan(plan.String)
+	if lastActiveAt.Valid {
+		t, err := parseTime(lastActiveAt.String)
+		if err != nil {
+			return nil, err
+		}
+		userData.LastActiveAt = &t
+	}
+	return &userData, nil
+}
+
+func nullablePlan(p domain.Plan) *string {
+	if p == "" {
+		return nil
+	}
+	s := string(p)
+	return &s
+}
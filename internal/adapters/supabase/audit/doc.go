@@ -0,0 +1,17 @@
+// Package audit provides an implementation of the ports.AuditLogger interface
+// backed by the audit_log table of a Supabase project.
+//
+// The table is expected to be append-only: the service key should only be
+// granted INSERT and SELECT on it.
+//
+//	create table audit_log (
+//	  id uuid primary key,
+//	  request_id text,
+//	  actor text not null,
+//	  action text not null,
+//	  target_user_id text,
+//	  outcome text not null,
+//	  details text,
+//	  created_at timestamptz not null
+//	);
+package audit
@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"net/http"
+	"net/url"
+	"strconv"
+	"stress-relief-ai-chat-back/internal/adapters/supabase"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+const auditLogPath = "/rest/v1/audit_log"
+
+type handler struct {
+	client *supabase.Client
+	logger ports.Logger
+}
+
+func NewAuditLogger(client *supabase.Client, logger ports.Logger) (ports.AuditLogger, error) {
+	s := &handler{
+		client: client,
+		logger: logger,
+	}
+	if s.client == nil {
+		return nil, fmt.Errorf("client can't be nil")
+	}
+	if s.logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	return s, nil
+}
+
+func (s handler) Record(ctx context.Context, event *domain.AuditEvent) error {
+	if event == nil {
+		return fmt.Errorf("can't record nil event")
+	}
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+
+	err := s.client.Do(ctx, supabase.Request{
+		Method: http.MethodPost,
+		Path:   auditLogPath,
+		Body:   event,
+		Prefer: "return=minimal",
+	}, nil)
+	if err != nil {
+		s.logger.Error(ctx, "Error recording audit event", "error", err)
+		return fmt.Errorf("error recording audit event: %w", err)
+	}
+
+	return nil
+}
+
+func (s handler) Query(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEvent, error) {
+	params := url.Values{}
+	params.Set("select", "*")
+	params.Set("order", "created_at.desc")
+	if q.Limit > 0 {
+		params.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Actor != "" {
+		params.Set("actor", "eq."+q.Actor)
+	}
+	if q.Action != "" {
+		params.Set("action", "eq."+q.Action)
+	}
+	if q.TargetUserID != "" {
+		params.Set("target_user_id", "eq."+q.TargetUserID)
+	}
+	if !q.Since.IsZero() {
+		params.Add("created_at", "gte."+q.Since.UTC().Format(time.RFC3339Nano))
+	}
+	if !q.Until.IsZero() {
+		params.Add("created_at", "lte."+q.Until.UTC().Format(time.RFC3339Nano))
+	}
+
+	var events []domain.AuditEvent
+	err := s.client.Do(ctx, supabase.Request{
+		Method: http.MethodGet,
+		Path:   auditLogPath,
+		Query:  params,
+	}, &events)
+	if err != nil {
+		s.logger.Error(ctx, "Error querying audit log", "error", err)
+		return nil, fmt.Errorf("error querying audit log: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,227 @@
+package supabase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultTimeout bounds every request, on top of the request context.
+	defaultTimeout = 10 * time.Second
+	// maxErrorBody is how much of an error response body is read.
+	maxErrorBody = 64 << 10
+)
+
+// Client sends requests to the APIs of a Supabase project with the service
+// key. It reuses connections, so a single Client should be shared by all the
+// adapters of a project.
+type Client struct {
+	apiKey     string
+	http       *http.Client
+	logger     ports.Logger
+	metrics    ports.Metrics
+	projectURL string
+	tracer     ports.Tracer
+}
+
+// NewClient creates a Client for the project at projectURL. A zero timeout
+// uses a default of 10 seconds.
+func NewClient(apiKey, projectURL string, timeout time.Duration, logger ports.Logger, metrics ports.Metrics, tracer ports.Tracer) (*Client, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("apiKey can't be empty")
+	}
+	if projectURL == "" {
+		return nil, fmt.Errorf("projectURL can't be empty")
+	}
+	if _, err := url.ParseRequestURI(projectURL); err != nil {
+		return nil, fmt.Errorf("invalid projectURL: %w", err)
+	}
+	if logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	if metrics == nil {
+		return nil, fmt.Errorf("metrics can't be nil")
+	}
+	if tracer == nil {
+		return nil, fmt.Errorf("tracer can't be nil")
+	}
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 32
+	return &Client{
+		apiKey: apiKey,
+		http: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+		},
+		logger:     logger,
+		metrics:    metrics,
+		projectURL: strings.TrimSuffix(projectURL, "/"),
+		tracer:     tracer,
+	}, nil
+}
+
+// Request describes a request to a Supabase API.
+type Request struct {
+	Method string
+	// Path is the path of the endpoint, such as /rest/v1/user_data.
+	Path  string
+	Query url.Values
+	// Body is encoded as JSON when not nil.
+	Body any
+	// Prefer is sent as the PostgREST Prefer header when not empty.
+	Prefer string
+	// Operation names the request in the metrics and traces. It defaults to the method
+	// and table of PostgREST requests, such as get_user_data, and must be set
+	// for other paths, which may contain ids.
+	Operation string
+}
+
+func (r Request) operation() string {
+	if r.Operation != "" {
+		return r.Operation
+	}
+	if table, ok := strings.CutPrefix(r.Path, "/rest/v1/"); ok {
+		return strings.ToLower(r.Method) + "_" + table
+	}
+	return strings.ToLower(r.Method)
+}
+
+// Do sends the request and decodes the JSON response body into out, unless
+// out is nil. Responses other than 2xx are returned as a *StatusError.
+func (c *Client) Do(ctx context.Context, r Request, out any) (err error) {
+	start := time.Now()
+	// Paths named by an explicit operation may contain ids, such as the id
+	// of a user, so only the paths of tables are recorded
+	attrs := []any{"http.request.method", r.Method}
+	if r.Operation == "" {
+		attrs = append(attrs, "url.path", r.Path)
+	}
+	ctx, span := c.tracer.Start(ctx, "supabase."+r.operation(), attrs...)
+	defer func() {
+		c.metrics.ObserveStorageCall("supabase", r.operation(), time.Since(start), err)
+		span.End(err)
+	}()
+
+	endpoint := c.projectURL + r.Path
+	if len(r.Query) > 0 {
+		endpoint += "?" + r.Query.Encode()
+	}
+
+	var body io.Reader
+	if r.Body != nil {
+		data, err := json.Marshal(r.Body)
+		if err != nil {
+			return fmt.Errorf("error marshalling request body: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.Method, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("apikey", c.apiKey)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if r.Prefer != "" {
+		req.Header.Set("Prefer", r.Prefer)
+	}
+	c.tracer.Inject(ctx, headerCarrier(req.Header))
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("error sending request: %w", err)
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) {
+			return fmt.Errorf("%w: %s %s: %s", domain.ErrUnavailable, r.Method, r.Path, err.Error())
+		}
+		return fmt.Errorf("error sending request: %w", err)
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			c.logger.Error(ctx, "Error closing response body", "error", err)
+		}
+	}()
+
+	span.SetAttributes("http.response.status_code", res.StatusCode)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+		return newStatusError(res, data)
+	}
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, res.Body)
+		return nil
+	}
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %w", err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("error unmarshalling response body: %w", err)
+	}
+	return nil
+}
+
+// Name identifies the project in the status of the service.
+func (c *Client) Name() string {
+	return "supabase"
+}
+
+// Check checks that the project is reachable and accepts the service key,
+// using the health endpoint of Supabase Auth.
+func (c *Client) Check(ctx context.Context) error {
+	return c.Do(ctx, Request{
+		Method:    http.MethodGet,
+		Path:      "/auth/v1/health",
+		Operation: "health_check",
+	}, nil)
+}
+
+// headerCarrier propagates trace context in the headers of a request.
+type headerCarrier http.Header
+
+func (h headerCarrier) Get(key string) string {
+	return http.Header(h).Get(key)
+}
+
+func (h headerCarrier) Set(key, value string) {
+	http.Header(h).Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for key := range h {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Quote quotes a value for use inside a PostgREST logical filter such as
+// or=(a.eq.x,b.eq.y), where commas, dots and parentheses are reserved.
+func Quote(v string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`
+}
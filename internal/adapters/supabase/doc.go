@@ -0,0 +1,3 @@
+// Package supabase provides the HTTP client shared by the adapters of the
+// Supabase APIs in its subpackages.
+package supabase
@@ -0,0 +1,54 @@
+package supabase
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"stress-relief-ai-chat-back/internal/domain"
+)
+
+// StatusError is a non 2xx response from a Supabase API. It wraps the domain
+// error matching the status, if any.
+type StatusError struct {
+	StatusCode int
+	// Code, Message, Details and Hint come from the PostgREST error body.
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details string `json:"details"`
+	Hint    string `json:"hint"`
+}
+
+func newStatusError(res *http.Response, body []byte) *StatusError {
+	e := &StatusError{StatusCode: res.StatusCode}
+	if err := json.Unmarshal(body, e); err != nil || e.Message == "" {
+		e.Message = http.StatusText(res.StatusCode)
+	}
+	return e
+}
+
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("supabase: %d %s", e.StatusCode, e.Message)
+	if e.Code != "" {
+		msg += " (" + e.Code + ")"
+	}
+	if e.Details != "" {
+		msg += ": " + e.Details
+	}
+	return msg
+}
+
+// Unwrap maps the status to a domain error. Authentication failures are not
+// mapped to domain.ErrUnauthorized, as they mean the service key is wrong
+// rather than the user not being allowed.
+func (e *StatusError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusNotFound:
+		return domain.ErrNotFound
+	case e.StatusCode == http.StatusConflict:
+		return domain.ErrConflict
+	case e.StatusCode == http.StatusTooManyRequests, e.StatusCode >= 500:
+		return domain.ErrUnavailable
+	default:
+		return nil
+	}
+}
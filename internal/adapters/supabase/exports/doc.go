@@ -0,0 +1,4 @@
+// Package exports provides an implementation of the ports.ExportRepository
+// interface backed by the data_exports table of a Supabase project. The table
+// is created by supabase/migrations/20261019000300_data_exports.sql.
+package exports
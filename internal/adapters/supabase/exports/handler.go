@@ -0,0 +1,214 @@
+package exports
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"stress-relief-ai-chat-back/internal/adapters/supabase"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+const (
+	dataExportsPath = "/rest/v1/data_exports"
+	// listColumns are the columns of an export without its archive.
+	listColumns = "id,user_id,status,error,created_at,completed_at"
+)
+
+// row is an export as stored in the table. The archive is stored as text, in
+// base64 as []byte is encoded in JSON, since bytea columns are encoded in hex
+// by PostgREST.
+type row struct {
+	ID          string              `json:"id"`
+	UserID      string              `json:"user_id"`
+	Status      domain.ExportStatus `json:"status"`
+	Error       *string             `json:"error"`
+	Archive     []byte              `json:"archive"`
+	CreatedAt   time.Time           `json:"created_at"`
+	CompletedAt *time.Time          `json:"completed_at"`
+}
+
+func (r row) export() domain.DataExport {
+	export := domain.DataExport{
+		ID:          r.ID,
+		UserID:      r.UserID,
+		Status:      r.Status,
+		Archive:     r.Archive,
+		CreatedAt:   r.CreatedAt,
+		CompletedAt: r.CompletedAt,
+	}
+	if r.Error != nil {
+		export.Error = *r.Error
+	}
+	return export
+}
+
+type handler struct {
+	client *supabase.Client
+	logger ports.Logger
+}
+
+func NewExportRepository(client *supabase.Client, logger ports.Logger) (ports.ExportRepository, error) {
+	s := &handler{
+		client: client,
+		logger: logger,
+	}
+	if s.client == nil {
+		return nil, fmt.Errorf("client can't be nil")
+	}
+	if s.logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	return s, nil
+}
+
+func (s handler) Insert(ctx context.Context, export *domain.DataExport) error {
+	if export == nil {
+		return fmt.Errorf("can't insert nil export")
+	}
+
+	err := s.client.Do(ctx, supabase.Request{
+		Method: http.MethodPost,
+		Path:   dataExportsPath,
+		Body: row{
+			ID:          export.ID,
+			UserID:      export.UserID,
+			Status:      export.Status,
+			Error:       optional(export.Error),
+			Archive:     export.Archive,
+			CreatedAt:   export.CreatedAt,
+			CompletedAt: export.CompletedAt,
+		},
+		Prefer: "return=minimal",
+	}, nil)
+	if err != nil {
+		s.logger.Error(ctx, "Error inserting export", "error", err)
+		return fmt.Errorf("error inserting export: %w", err)
+	}
+	return nil
+}
+
+func (s handler) Update(ctx context.Context, export *domain.DataExport) error {
+	if export == nil {
+		return fmt.Errorf("can't update nil export")
+	}
+
+	// The updated rows are returned to tell a missing export apart
+	var rows []struct {
+		ID string `json:"id"`
+	}
+	err := s.client.Do(ctx, supabase.Request{
+		Method: http.MethodPatch,
+		Path:   dataExportsPath,
+		Query:  url.Values{"id": {"eq." + export.ID}, "select": {"id"}},
+		Body: map[string]any{
+			"status":       export.Status,
+			"error":        optional(export.Error),
+			"archive":      export.Archive,
+			"completed_at": export.CompletedAt,
+		},
+		Prefer: "return=representation",
+	}, &rows)
+	if err != nil {
+		s.logger.Error(ctx, "Error updating export", "error", err)
+		return fmt.Errorf("error updating export: %w", err)
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("%w: export not found", domain.ErrNotFound)
+	}
+	return nil
+}
+
+func (s handler) Get(ctx context.Context, exportID string) (*domain.DataExport, error) {
+	var rows []row
+	err := s.client.Do(ctx, supabase.Request{
+		Method: http.MethodGet,
+		Path:   dataExportsPath,
+		Query:  url.Values{"id": {"eq." + exportID}, "select": {"*"}},
+	}, &rows)
+	if err != nil {
+		s.logger.Error(ctx, "Error getting export", "error", err)
+		return nil, fmt.Errorf("error getting export: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: export not found", domain.ErrNotFound)
+	}
+	export := rows[0].export()
+	return &export, nil
+}
+
+func (s handler) ListByUser(ctx context.Context, userID string) ([]domain.DataExport, error) {
+	var rows []row
+	err := s.client.Do(ctx, supabase.Request{
+		Method: http.MethodGet,
+		Path:   dataExportsPath,
+		Query: url.Values{
+			"user_id": {"eq." + userID},
+			"select":  {listColumns},
+			"order":   {"created_at.asc"},
+		},
+	}, &rows)
+	if err != nil {
+		s.logger.Error(ctx, "Error listing exports", "error", err)
+		return nil, fmt.Errorf("error listing exports: %w", err)
+	}
+
+	exports := make([]domain.DataExport, len(rows))
+	for i, r := range rows {
+		exports[i] = r.export()
+	}
+	return exports, nil
+}
+
+func (s handler) Delete(ctx context.Context, exportID string) error {
+	err := s.client.Do(ctx, supabase.Request{
+		Method: http.MethodDelete,
+		Path:   dataExportsPath,
+		Query:  url.Values{"id": {"eq." + exportID}},
+	}, nil)
+	if err != nil {
+		s.logger.Error(ctx, "Error deleting export", "error", err)
+		return fmt.Errorf("error deleting export: %w", err)
+	}
+	return nil
+}
+
+func (s handler) DeleteByUser(ctx context.Context, userID string) error {
+	if userID == "" {
+		return fmt.Errorf("can't delete exports with empty userID")
+	}
+
+	err := s.client.Do(ctx, supabase.Request{
+		Method: http.MethodDelete,
+		Path:   dataExportsPath,
+		Query:  url.Values{"user_id": {"eq." + userID}},
+	}, nil)
+	if err != nil {
+		s.logger.Error(ctx, "Error deleting exports", "error", err)
+		return fmt.Errorf("error deleting exports: %w", err)
+	}
+	return nil
+}
+
+func (s handler) DeleteCreatedBefore(ctx context.Context, t time.Time) error {
+	err := s.client.Do(ctx, supabase.Request{
+		Method: http.MethodDelete,
+		Path:   dataExportsPath,
+		Query:  url.Values{"created_at": {"lt." + t.UTC().Format(time.RFC3339Nano)}},
+	}, nil)
+	if err != nil {
+		s.logger.Error(ctx, "Error deleting expired exports", "error", err)
+		return fmt.Errorf("error deleting expired exports: %w", err)
+	}
+	return nil
+}
+
+// optional returns nil for an empty value, which is stored as null.
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,3 @@
+// Package identity provides an implementation of the ports.IdentityProvider
+// interface backed by the admin API of Supabase Auth.
+package identity
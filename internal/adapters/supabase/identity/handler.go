@@ -0,0 +1,51 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"stress-relief-ai-chat-back/internal/adapters/supabase"
+	"stress-relief-ai-chat-back/internal/ports"
+)
+
+type handler struct {
+	client *supabase.Client
+	logger ports.Logger
+}
+
+// NewIdentityProvider creates an IdentityProvider for the Supabase project.
+// The client must use the service role key, as the admin API is not available
+// to the anon key.
+func NewIdentityProvider(client *supabase.Client, logger ports.Logger) (ports.IdentityProvider, error) {
+	s := &handler{
+		client: client,
+		logger: logger,
+	}
+	if s.client == nil {
+		return nil, fmt.Errorf("client can't be nil")
+	}
+	if s.logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	return s, nil
+}
+
+// DeleteUser deletes the auth user. A missing user is reported as an error
+// wrapping domain.ErrNotFound by the client.
+func (s handler) DeleteUser(ctx context.Context, userID string) error {
+	if userID == "" {
+		return fmt.Errorf("can't delete user with empty userID")
+	}
+
+	err := s.client.Do(ctx, supabase.Request{
+		Method:    http.MethodDelete,
+		Path:      "/auth/v1/admin/users/" + url.PathEscape(userID),
+		Operation: "delete_auth_user",
+	}, nil)
+	if err != nil {
+		s.logger.Error(ctx, "Error deleting auth user", "error", err)
+		return fmt.Errorf("error deleting auth user: %w", err)
+	}
+	return nil
+}
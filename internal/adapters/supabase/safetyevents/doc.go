@@ -0,0 +1,5 @@
+// Package safetyevents provides an implementation of the
+// ports.SafetyEventRepository interface backed by the safety_events table of
+// a Supabase project. The table is created by
+// supabase/migrations/20261019000200_safety_events.sql.
+package safetyevents
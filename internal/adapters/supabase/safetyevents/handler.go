@@ -0,0 +1,139 @@
+package safetyevents
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"net/http"
+	"net/url"
+	"strconv"
+	"stress-relief-ai-chat-back/internal/adapters/supabase"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+const safetyEventsPath = "/rest/v1/safety_events"
+
+// row is a safety event as stored in the table. The JSON of domain.SafetyEvent
+// is the one of the API.
+type row struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	ThreadID  *string   `json:"thread_id"`
+	Category  string    `json:"category"`
+	Details   *string   `json:"details"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type handler struct {
+	client *supabase.Client
+	logger ports.Logger
+}
+
+func NewSafetyEventRepository(client *supabase.Client, logger ports.Logger) (ports.SafetyEventRepository, error) {
+	s := &handler{
+		client: client,
+		logger: logger,
+	}
+	if s.client == nil {
+		return nil, fmt.Errorf("client can't be nil")
+	}
+	if s.logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	return s, nil
+}
+
+func (s handler) Record(ctx context.Context, event *domain.SafetyEvent) error {
+	if event == nil {
+		return fmt.Errorf("can't record nil event")
+	}
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+
+	err := s.client.Do(ctx, supabase.Request{
+		Method: http.MethodPost,
+		Path:   safetyEventsPath,
+		Body: row{
+			ID:        event.ID,
+			UserID:    event.UserID,
+			ThreadID:  optional(event.ThreadID),
+			Category:  event.Category,
+			Details:   optional(event.Details),
+			CreatedAt: event.CreatedAt,
+		},
+		Prefer: "return=minimal",
+	}, nil)
+	if err != nil {
+		s.logger.Error(ctx, "Error recording safety event", "error", err)
+		return fmt.Errorf("error recording safety event: %w", err)
+	}
+	return nil
+}
+
+func (s handler) List(ctx context.Context, limit int) ([]domain.SafetyEvent, error) {
+	params := url.Values{}
+	params.Set("select", "*")
+	params.Set("order", "created_at.desc")
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+
+	var rows []row
+	err := s.client.Do(ctx, supabase.Request{
+		Method: http.MethodGet,
+		Path:   safetyEventsPath,
+		Query:  params,
+	}, &rows)
+	if err != nil {
+		s.logger.Error(ctx, "Error listing safety events", "error", err)
+		return nil, fmt.Errorf("error listing safety events: %w", err)
+	}
+
+	events := make([]domain.SafetyEvent, len(rows))
+	for i, r := range rows {
+		events[i] = domain.SafetyEvent{
+			ID:        r.ID,
+			UserID:    r.UserID,
+			Category:  r.Category,
+			CreatedAt: r.CreatedAt,
+		}
+		if r.ThreadID != nil {
+			events[i].ThreadID = *r.ThreadID
+		}
+		if r.Details != nil {
+			events[i].Details = *r.Details
+		}
+	}
+	return events, nil
+}
+
+func (s handler) DeleteByUser(ctx context.Context, userID string) error {
+	if userID == "" {
+		return fmt.Errorf("can't delete safety events with empty userID")
+	}
+
+	err := s.client.Do(ctx, supabase.Request{
+		Method: http.MethodDelete,
+		Path:   safetyEventsPath,
+		Query:  url.Values{"user_id": {"eq." + userID}},
+	}, nil)
+	if err != nil {
+		s.logger.Error(ctx, "Error deleting safety events", "error", err)
+		return fmt.Errorf("error deleting safety events: %w", err)
+	}
+	return nil
+}
+
+// optional returns nil for an empty value, which is stored as null.
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
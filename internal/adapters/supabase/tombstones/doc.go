@@ -0,0 +1,8 @@
+// Package tombstones provides an implementation of the ports.TombstoneRepository
+// interface backed by the user_tombstones table of a Supabase project.
+//
+//	create table user_tombstones (
+//	  key text primary key,
+//	  created_at timestamptz not null default now()
+//	);
+package tombstones
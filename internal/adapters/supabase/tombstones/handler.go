@@ -0,0 +1,70 @@
+package tombstones
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"stress-relief-ai-chat-back/internal/adapters/supabase"
+	"stress-relief-ai-chat-back/internal/ports"
+)
+
+const tombstonesPath = "/rest/v1/user_tombstones"
+
+type handler struct {
+	client *supabase.Client
+	logger ports.Logger
+}
+
+func NewTombstoneRepository(client *supabase.Client, logger ports.Logger) (ports.TombstoneRepository, error) {
+	s := &handler{
+		client: client,
+		logger: logger,
+	}
+	if s.client == nil {
+		return nil, fmt.Errorf("client can't be nil")
+	}
+	if s.logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	return s, nil
+}
+
+func (s handler) Add(ctx context.Context, key string) error {
+	if key == "" {
+		return fmt.Errorf("can't add tombstone with empty key")
+	}
+
+	err := s.client.Do(ctx, supabase.Request{
+		Method: http.MethodPost,
+		Path:   tombstonesPath,
+		Body:   map[string]string{"key": key},
+		// Adding the same tombstone twice is not an error
+		Prefer: "resolution=ignore-duplicates,return=minimal",
+	}, nil)
+	if err != nil {
+		s.logger.Error(ctx, "Error adding tombstone", "error", err)
+		return fmt.Errorf("error adding tombstone: %w", err)
+	}
+	return nil
+}
+
+func (s handler) Exists(ctx context.Context, key string) (bool, error) {
+	if key == "" {
+		return false, fmt.Errorf("can't look up tombstone with empty key")
+	}
+
+	var rows []struct {
+		Key string `json:"key"`
+	}
+	err := s.client.Do(ctx, supabase.Request{
+		Method: http.MethodGet,
+		Path:   tombstonesPath,
+		Query:  url.Values{"select": {"key"}, "key": {"eq." + key}},
+	}, &rows)
+	if err != nil {
+		s.logger.Error(ctx, "Error getting tombstone", "error", err)
+		return false, fmt.Errorf("error getting tombstone: %w", err)
+	}
+	return len(rows) > 0, nil
+}
@@ -0,0 +1,6 @@
+// Package usage provides an implementation of the ports.UsageRepository
+// interface backed by the message_usage table of a Supabase project. The
+// counters are updated with the consume_message and refund_message functions,
+// so that checking and counting a message is atomic. The table and functions
+// are created by supabase/migrations/20261019000000_message_usage.sql.
+package usage
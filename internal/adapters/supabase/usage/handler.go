@@ -0,0 +1,125 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"stress-relief-ai-chat-back/internal/adapters/supabase"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+)
+
+const (
+	usagePath   = "/rest/v1/message_usage"
+	consumePath = "/rest/v1/rpc/consume_message"
+	refundPath  = "/rest/v1/rpc/refund_message"
+	summaryPath = "/rest/v1/rpc/message_usage_summary"
+)
+
+type handler struct {
+	client *supabase.Client
+	logger ports.Logger
+}
+
+func NewUsageRepository(client *supabase.Client, logger ports.Logger) (ports.UsageRepository, error) {
+	s := &handler{
+		client: client,
+		logger: logger,
+	}
+	if s.client == nil {
+		return nil, fmt.Errorf("client can't be nil")
+	}
+	if s.logger == nil {
+		return nil, fmt.Errorf("logger can't be nil")
+	}
+	return s, nil
+}
+
+func (s handler) Increment(ctx context.Context, userID, day string, plan domain.Plan, limit int) (int, bool, error) {
+	if userID == "" {
+		return 0, false, fmt.Errorf("can't count message with empty userID")
+	}
+
+	// The function returns null when the limit is reached
+	var count *int
+	err := s.client.Do(ctx, supabase.Request{
+		Method: http.MethodPost,
+		Path:   consumePath,
+		Body: map[string]any{
+			"p_user_id": userID,
+			"p_day":     day,
+			"p_plan":    plan,
+			"p_limit":   limit,
+		},
+		Operation: "consume_message",
+	}, &count)
+	if err != nil {
+		s.logger.Error(ctx, "Error counting message", "error", err)
+		return 0, false, fmt.Errorf("error counting message: %w", err)
+	}
+	if count == nil {
+		return limit, false, nil
+	}
+	return *count, true, nil
+}
+
+func (s handler) Decrement(ctx context.Context, userID, day string) error {
+	err := s.client.Do(ctx, supabase.Request{
+		Method:    http.MethodPost,
+		Path:      refundPath,
+		Body:      map[string]string{"p_user_id": userID, "p_day": day},
+		Operation: "refund_message",
+	}, nil)
+	if err != nil {
+		s.logger.Error(ctx, "Error uncounting message", "error", err)
+		return fmt.Errorf("error uncounting message: %w", err)
+	}
+	return nil
+}
+
+func (s handler) Delete(ctx context.Context, userID string) error {
+	if userID == "" {
+		return fmt.Errorf("can't delete usage with empty userID")
+	}
+
+	err := s.client.Do(ctx, supabase.Request{
+		Method: http.MethodDelete,
+		Path:   usagePath,
+		Query:  url.Values{"user_id": {"eq." + userID}},
+	}, nil)
+	if err != nil {
+		s.logger.Error(ctx, "Error deleting usage", "error", err)
+		return fmt.Errorf("error deleting usage: %w", err)
+	}
+	return nil
+}
+
+func (s handler) Summary(ctx context.Context, day string) (*domain.UsageSummary, error) {
+	var rows []struct {
+		Plan     domain.Plan `json:"plan"`
+		Users    int         `json:"users"`
+		Messages int         `json:"messages"`
+	}
+	err := s.client.Do(ctx, supabase.Request{
+		Method:    http.MethodPost,
+		Path:      summaryPath,
+		Body:      map[string]string{"p_day": day},
+		Operation: "message_usage_summary",
+	}, &rows)
+	if err != nil {
+		s.logger.Error(ctx, "Error summarizing usage", "error", err)
+		return nil, fmt.Errorf("error summarizing usage: %w", err)
+	}
+
+	summary := &domain.UsageSummary{
+		Day:            day,
+		MessagesByPlan: make(map[domain.Plan]int),
+	}
+	for _, row := range rows {
+		summary.ActiveUsers += row.Users
+		summary.TotalMessages += row.Messages
+		summary.MessagesByPlan[row.Plan] += row.Messages
+	}
+	return summary, nil
+}
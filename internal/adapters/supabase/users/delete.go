@@ -0,0 +1,27 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"stress-relief-ai-chat-back/internal/adapters/supabase"
+)
+
+func (s handler) Delete(ctx context.Context, userID string) error {
+	if userID == "" {
+		s.logger.Debug(ctx, "Can't delete user with empty userID")
+		return fmt.Errorf("can't delete user with empty userID")
+	}
+
+	err := s.client.Do(ctx, supabase.Request{
+		Method: http.MethodDelete,
+		Path:   userDataPath,
+		Query:  byUserID(userID),
+	}, nil)
+	if err != nil {
+		s.logger.Error(ctx, "Error deleting user", "error", err)
+		return fmt.Errorf("error deleting user: %w", err)
+	}
+
+	return nil
+}
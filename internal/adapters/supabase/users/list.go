@@ -0,0 +1,44 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"stress-relief-ai-chat-back/internal/adapters/supabase"
+	"stress-relief-ai-chat-back/internal/domain"
+	"time"
+)
+
+func (s handler) List(ctx context.Context, filter domain.UserDataFilter) ([]domain.UserData, error) {
+	params := url.Values{}
+	params.Set("select", "*")
+	params.Set("order", "user_id.asc")
+	if !filter.InactiveBefore.IsZero() {
+		params.Set("thread_id", "not.is.null")
+		params.Set("last_active_at", "lt."+filter.InactiveBefore.UTC().Format(time.RFC3339Nano))
+	}
+	if filter.NotEncryptedWith != "" {
+		params.Set("thread_id", "not.is.null")
+		params.Set("or", fmt.Sprintf("(key_id.is.null,key_id.neq.%s)", supabase.Quote(filter.NotEncryptedWith)))
+	}
+	if filter.AfterUserID != "" {
+		params.Set("user_id", "gt."+filter.AfterUserID)
+	}
+	if filter.Limit > 0 {
+		params.Set("limit", strconv.Itoa(filter.Limit))
+	}
+
+	var users []domain.UserData
+	err := s.client.Do(ctx, supabase.Request{
+		Method: http.MethodGet,
+		Path:   userDataPath,
+		Query:  params,
+	}, &users)
+	if err != nil {
+		s.logger.Error(ctx, "Error listing users", "error", err)
+		return nil, fmt.Errorf("error listing users: %w", err)
+	}
+	return users, nil
+}
@@ -0,0 +1,74 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"stress-relief-ai-chat-back/internal/adapters/supabase"
+	"stress-relief-ai-chat-back/internal/domain"
+	"time"
+)
+
+func (s handler) Patch(ctx context.Context, userID string, patch domain.UserDataPatch) error {
+	if userID == "" {
+		s.logger.Debug(ctx, "Can't patch user with empty userID")
+		return fmt.Errorf("can't patch user with empty userID")
+	}
+	body := make(map[string]any)
+	switch {
+	case patch.ClearThreadID:
+		body["thread_id"] = nil
+		body["key_id"] = nil
+	case patch.ThreadID != nil:
+		body["thread_id"] = *patch.ThreadID
+		body["key_id"] = patch.KeyID
+	}
+	if patch.LastActiveAt != nil {
+		body["last_active_at"] = *patch.LastActiveAt
+	}
+	if patch.Plan != nil {
+		body["plan"] = *patch.Plan
+	}
+	if patch.RetentionDays != nil {
+		body["retention_days"] = *patch.RetentionDays
+	}
+	if len(body) == 0 {
+		return nil
+	}
+
+	// Only the fields set in the patch are sent, the others are left as
+	// they are. The patched rows are returned to tell a missing entry, or one
+	// not meeting the condition, apart.
+	query := byUserID(userID)
+	query.Set("select", "user_id")
+	if patch.If != nil {
+		query.Set("thread_id", "eq."+patch.If.ThreadID)
+		if patch.If.KeyID == nil {
+			query.Set("key_id", "is.null")
+		} else {
+			query.Set("key_id", "eq."+*patch.If.KeyID)
+		}
+		if !patch.If.InactiveBefore.IsZero() {
+			query.Set("last_active_at", "lt."+patch.If.InactiveBefore.UTC().Format(time.RFC3339Nano))
+		}
+	}
+	var rows []struct {
+		UserID string `json:"user_id"`
+	}
+	err := s.client.Do(ctx, supabase.Request{
+		Method: http.MethodPatch,
+		Path:   userDataPath,
+		Query:  query,
+		Body:   body,
+		Prefer: "return=representation",
+	}, &rows)
+	if err != nil {
+		s.logger.Error(ctx, "Error patching user", "error", err)
+		return fmt.Errorf("error patching user: %w", err)
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("%w: user_data not found", domain.ErrNotFound)
+	}
+
+	return nil
+}
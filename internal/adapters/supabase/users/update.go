@@ -0,0 +1,44 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"stress-relief-ai-chat-back/internal/adapters/supabase"
+	"stress-relief-ai-chat-back/internal/domain"
+)
+
+func (s handler) Update(ctx context.Context, userID string, userData *domain.UserData) error {
+	if userID == "" {
+		s.logger.Debug(ctx, "Can't update user with empty userID")
+		return fmt.Errorf("can't update user with empty userID")
+	}
+	if userData == nil {
+		s.logger.Debug(ctx, "Can't update nil userData")
+		return fmt.Errorf("can't update nil userData")
+	}
+
+	// The updated rows are returned to tell a missing entry apart
+	userData.UserID = userID
+	query := byUserID(userID)
+	query.Set("select", "user_id")
+	var rows []struct {
+		UserID string `json:"user_id"`
+	}
+	err := s.client.Do(ctx, supabase.Request{
+		Method: http.MethodPatch,
+		Path:   userDataPath,
+		Query:  query,
+		Body:   userData,
+		Prefer: "return=representation",
+	}, &rows)
+	if err != nil {
+		s.logger.Error(ctx, "Error updating user", "error", err)
+		return fmt.Errorf("error updating user: %w", err)
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("%w: user_data not found", domain.ErrNotFound)
+	}
+
+	return nil
+}
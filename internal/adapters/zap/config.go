@@ -0,0 +1,93 @@
+package zap
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"time"
+)
+
+// Config holds the settings of the logger.
+type Config struct {
+	// Level is the minimum level logged: debug, info, warn or error.
+	Level string
+	// Encoding is either json or console.
+	Encoding string
+	// Development makes warnings include stack traces and DPanic panic.
+	Development bool
+	// SamplingInitial and SamplingThereafter limit how many identical lines
+	// are logged per second: the first SamplingInitial ones, then every
+	// SamplingThereafter-th. Sampling is disabled when SamplingInitial is zero.
+	SamplingInitial    int
+	SamplingThereafter int
+	// OutputPaths are the sinks log lines are written to, such as stdout or a
+	// file path. Defaults to stdout.
+	OutputPaths []string
+	// ErrorOutputPaths are the sinks internal logger errors are written to.
+	// Defaults to stderr.
+	ErrorOutputPaths []string
+	// RedactKeys are added to the field names whose values are redacted, see
+	// DefaultRedactKeys.
+	RedactKeys []string
+	// RedactValues are secrets that are masked wherever they appear in a log
+	// line, such as API keys.
+	RedactValues []string
+}
+
+// build creates the zap logger described by the config.
+func (c Config) build() (*zap.Logger, zap.AtomicLevel, error) {
+	level := zap.NewAtomicLevel()
+	if c.Level != "" {
+		if err := level.UnmarshalText([]byte(c.Level)); err != nil {
+			return nil, level, fmt.Errorf("invalid level %q: %w", c.Level, err)
+		}
+	}
+
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	if c.Development {
+		encoderConfig = zap.NewDevelopmentEncoderConfig()
+	}
+	var encoder zapcore.Encoder
+	switch c.Encoding {
+	case "", "json":
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	case "console":
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	default:
+		return nil, level, fmt.Errorf("invalid encoding %q", c.Encoding)
+	}
+	redactor := newRedactor(append(DefaultRedactKeys, c.RedactKeys...), c.RedactValues)
+	encoder = newRedactingEncoder(encoder, redactor)
+
+	outputPaths := c.OutputPaths
+	if len(outputPaths) == 0 {
+		outputPaths = []string{"stdout"}
+	}
+	sink, closeSink, err := zap.Open(outputPaths...)
+	if err != nil {
+		return nil, level, fmt.Errorf("could not open output paths: %w", err)
+	}
+	errorOutputPaths := c.ErrorOutputPaths
+	if len(errorOutputPaths) == 0 {
+		errorOutputPaths = []string{"stderr"}
+	}
+	errSink, _, err := zap.Open(errorOutputPaths...)
+	if err != nil {
+		closeSink()
+		return nil, level, fmt.Errorf("could not open error output paths: %w", err)
+	}
+
+	core := newRedactingCore(zapcore.NewCore(encoder, sink, level), redactor)
+	if c.SamplingInitial > 0 {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, c.SamplingInitial, c.SamplingThereafter)
+	}
+
+	opts := []zap.Option{zap.ErrorOutput(errSink), zap.AddCaller(), zap.AddCallerSkip(1)}
+	if c.Development {
+		opts = append(opts, zap.Development(), zap.AddStacktrace(zapcore.WarnLevel))
+	} else {
+		opts = append(opts, zap.AddStacktrace(zapcore.ErrorLevel))
+	}
+	return zap.New(core, opts...), level, nil
+}
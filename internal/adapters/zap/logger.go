@@ -3,8 +3,11 @@ package zap
 import (
 	"context"
 	"errors"
+	"fmt"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"log"
+	"stress-relief-ai-chat-back/internal/domain"
 )
 
 const (
@@ -15,6 +18,7 @@ const (
 // It uses zap.SugaredLogger as the underlying logging implementation.
 type Logger struct {
 	sugar *zap.SugaredLogger
+	level zap.AtomicLevel
 }
 
 // NewLogger creates a new application logger.
@@ -22,22 +26,41 @@ type Logger struct {
 // It returns an error if the logger cannot be initialized.
 func NewLogger(env string) (*Logger, error) {
 	// Choose logger type based on application environment
-	conf := zap.NewProductionConfig()
+	config := Config{Level: "info", Encoding: "json", SamplingInitial: 100, SamplingThereafter: 100}
 	if env == "development" {
-		conf = zap.NewDevelopmentConfig()
+		config = Config{Level: "debug", Encoding: "console", Development: true}
 	}
+	return NewLoggerFromConfig(config)
+}
 
-	// Override any custom config
-	l, err := conf.Build(zap.AddCallerSkip(1))
+// NewLoggerFromConfig creates a new application logger with the given
+// settings.
+func NewLoggerFromConfig(config Config) (*Logger, error) {
+	l, level, err := config.build()
 	if err != nil {
 		return nil, err
 	}
-	logger := l.Sugar()
 	return &Logger{
-		sugar: logger,
+		sugar: l.Sugar(),
+		level: level,
 	}, nil
 }
 
+// Level returns the minimum level currently logged.
+func (l *Logger) Level() string {
+	return l.level.String()
+}
+
+// SetLevel changes the minimum level logged, taking effect immediately.
+func (l *Logger) SetLevel(level string) error {
+	var lvl zapcore.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid level %q: %w", level, err)
+	}
+	l.level.SetLevel(lvl)
+	return nil
+}
+
 // Close cleans up the logger resources.
 // For zap logger it flushes the logger buffer, if any.
 // It should generally be called before main exits.
@@ -58,7 +81,7 @@ func (l *Logger) Debug(ctx context.Context, msg string, args ...interface{}) {
 		log.Println(FailedLogMsg)
 		return
 	}
-	l.sugar.Debugw(msg, args...)
+	l.sugar.Debugw(msg, withContext(ctx, args)...)
 }
 
 // Info implements sigma.Logger.Info interface by invoking the underlying logging
@@ -68,7 +91,7 @@ func (l *Logger) Info(ctx context.Context, msg string, args ...interface{}) {
 		log.Println(FailedLogMsg)
 		return
 	}
-	l.sugar.Infow(msg, args...)
+	l.sugar.Infow(msg, withContext(ctx, args)...)
 }
 
 // Warn implements sigma.Logger.Warn interface by invoking the underlying logging
@@ -78,7 +101,7 @@ func (l *Logger) Warn(ctx context.Context, msg string, args ...interface{}) {
 		log.Println(FailedLogMsg)
 		return
 	}
-	l.sugar.Warnw(msg, args...)
+	l.sugar.Warnw(msg, withContext(ctx, args)...)
 }
 
 // Error implements sigma.Logger.Error interface by invoking the underlying logging
@@ -88,7 +111,7 @@ func (l *Logger) Error(ctx context.Context, msg string, args ...interface{}) {
 		log.Println(FailedLogMsg)
 		return
 	}
-	l.sugar.Errorw(msg, args...)
+	l.sugar.Errorw(msg, withContext(ctx, args)...)
 }
 
 // Fatal implements sigma.Logger.Fatal interface by invoking the underlying logging
@@ -98,5 +121,31 @@ func (l *Logger) Fatal(ctx context.Context, msg string, args ...interface{}) {
 		log.Println(FailedLogMsg)
 		return
 	}
-	l.sugar.Fatalw(msg, args...)
+	l.sugar.Fatalw(msg, withContext(ctx, args)...)
+}
+
+// withContext prepends the ids carried by ctx, such as the request id, to the
+// key/value pairs of a log line. Keys already passed explicitly win.
+func withContext(ctx context.Context, args []interface{}) []interface{} {
+	fields := domain.LogFields(ctx)
+	if len(fields) == 0 {
+		return args
+	}
+
+	merged := make([]interface{}, 0, len(fields)+len(args))
+	for i := 0; i+1 < len(fields); i += 2 {
+		if !hasKey(args, fields[i]) {
+			merged = append(merged, fields[i], fields[i+1])
+		}
+	}
+	return append(merged, args...)
+}
+
+func hasKey(args []interface{}, key interface{}) bool {
+	for i := 0; i < len(args); i += 2 {
+		if k, ok := args[i].(string); ok && k == key {
+			return true
+		}
+	}
+	return false
 }
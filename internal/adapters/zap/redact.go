@@ -0,0 +1,122 @@
+package zap
+
+import (
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"strings"
+)
+
+// redacted replaces the values that must not reach the logs.
+const redacted = "[REDACTED]"
+
+// minRedactValueLength is the length under which secret values are not
+// masked, as short values would mask unrelated text.
+const minRedactValueLength = 8
+
+// DefaultRedactKeys are the field names, matched case-insensitively as
+// substrings with dashes read as underscores, whose values are always
+// redacted.
+var DefaultRedactKeys = []string{"authorization", "password", "secret", "token", "api_key", "apikey", "cookie"}
+
+type redactor struct {
+	keys   []string
+	values *strings.Replacer
+}
+
+func newRedactor(keys, values []string) *redactor {
+	r := &redactor{}
+	for _, k := range keys {
+		if k != "" {
+			r.keys = append(r.keys, normalizeKey(k))
+		}
+	}
+	var pairs []string
+	for _, v := range values {
+		if len(v) >= minRedactValueLength {
+			pairs = append(pairs, v, redacted)
+		}
+	}
+	if len(pairs) > 0 {
+		r.values = strings.NewReplacer(pairs...)
+	}
+	return r
+}
+
+// normalizeKey lowers key and reads dashes as underscores, so that header
+// names such as X-Api-Key match api_key.
+func normalizeKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "-", "_")
+}
+
+func (r *redactor) sensitiveKey(key string) bool {
+	key = normalizeKey(key)
+	for _, k := range r.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *redactor) redactString(s string) string {
+	if r.values == nil {
+		return s
+	}
+	return r.values.Replace(s)
+}
+
+// redactFields returns fields with the values of sensitive fields replaced,
+// and secret values masked in the string and error fields.
+func (r *redactor) redactFields(fields []zapcore.Field) []zapcore.Field {
+	safe := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		switch {
+		case r.sensitiveKey(f.Key):
+			f = zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: redacted}
+		case f.Type == zapcore.StringType:
+			f.String = r.redactString(f.String)
+		case f.Type == zapcore.ErrorType:
+			if err, ok := f.Interface.(error); ok {
+				f = zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: r.redactString(err.Error())}
+			}
+		}
+		safe[i] = f
+	}
+	return safe
+}
+
+// redactingEncoder masks the values of sensitive fields, and secret values
+// anywhere in the message or the string and error fields, before encoding.
+type redactingEncoder struct {
+	zapcore.Encoder
+	redactor *redactor
+}
+
+func newRedactingEncoder(enc zapcore.Encoder, r *redactor) zapcore.Encoder {
+	return &redactingEncoder{Encoder: enc, redactor: r}
+}
+
+func (e *redactingEncoder) Clone() zapcore.Encoder {
+	return &redactingEncoder{Encoder: e.Encoder.Clone(), redactor: e.redactor}
+}
+
+func (e *redactingEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	entry.Message = e.redactor.redactString(entry.Message)
+	entry.Stack = e.redactor.redactString(entry.Stack)
+	return e.Encoder.EncodeEntry(entry, e.redactor.redactFields(fields))
+}
+
+// redactingCore masks the fields added to the logger context with With, which
+// are encoded once by the encoder of the core rather than on every entry.
+type redactingCore struct {
+	zapcore.Core
+	redactor *redactor
+}
+
+func newRedactingCore(core zapcore.Core, r *redactor) zapcore.Core {
+	return &redactingCore{Core: core, redactor: r}
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(c.redactor.redactFields(fields)), redactor: c.redactor}
+}
@@ -0,0 +1,138 @@
+package zap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"stress-relief-ai-chat-back/internal/domain"
+	"strings"
+	"testing"
+)
+
+const testSecret = "sk-test-0123456789"
+
+// newTestLogger returns a logger writing JSON lines to a file, and a function
+// returning the lines written so far.
+func newTestLogger(t *testing.T, config Config) (*Logger, func() []map[string]any) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "log.jsonl")
+	config.Level = "debug"
+	config.Encoding = "json"
+	config.OutputPaths = []string{path}
+	l, err := NewLoggerFromConfig(config)
+	if err != nil {
+		t.Fatalf("NewLoggerFromConfig: %v", err)
+	}
+
+	return l, func() []map[string]any {
+		t.Helper()
+		_ = l.Close()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		var lines []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			var entry map[string]any
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatalf("Unmarshal %s: %v", line, err)
+			}
+			lines = append(lines, entry)
+		}
+		return lines
+	}
+}
+
+func TestRedactsSensitiveKeys(t *testing.T) {
+	tests := []struct {
+		key   string
+		value any
+	}{
+		{"token", "eyJhbGciOi"},
+		{"access_token", "eyJhbGciOi"},
+		{"Authorization", "Bearer eyJhbGciOi"},
+		{"client_secret", "hunter2"},
+		{"X-Api-Key", "key"},
+		{"apiKey", "key"},
+		{"password", "hunter2"},
+		{"cookie", "session=1"},
+		{"token_bytes", []byte("eyJhbGciOi")},
+		{"secrets", map[string]string{"key": "value"}},
+		{"refresh_token_count", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			l, lines := newTestLogger(t, Config{})
+			l.Info(context.Background(), "message", tt.key, tt.value, "user", "alice")
+
+			entry := lines()[0]
+			if entry[tt.key] != redacted {
+				t.Fatalf("%s: got %v, want %s", tt.key, entry[tt.key], redacted)
+			}
+			if entry["user"] != "alice" {
+				t.Fatalf("user: got %v, want alice", entry["user"])
+			}
+		})
+	}
+}
+
+func TestRedactsSecretValues(t *testing.T) {
+	l, lines := newTestLogger(t, Config{RedactValues: []string{testSecret, "short"}})
+	l.Error(context.Background(), "calling with "+testSecret,
+		"url", "https://api.example.com?key="+testSecret,
+		"error", errors.New("unauthorized key "+testSecret),
+		"note", "short")
+
+	entry := lines()[0]
+	for _, key := range []string{"msg", "url", "error"} {
+		value, _ := entry[key].(string)
+		if strings.Contains(value, testSecret) || !strings.Contains(value, redacted) {
+			t.Fatalf("%s: got %q, want the secret masked", key, value)
+		}
+	}
+	// Values shorter than minRedactValueLength are not masked
+	if entry["note"] != "short" {
+		t.Fatalf("note: got %v, want short", entry["note"])
+	}
+}
+
+func TestRedactsContextFields(t *testing.T) {
+	t.Run("ids of the context", func(t *testing.T) {
+		l, lines := newTestLogger(t, Config{RedactValues: []string{testSecret}})
+		ctx := domain.WithRequestID(context.Background(), "req-"+testSecret)
+		l.Info(ctx, "message")
+
+		entry := lines()[0]
+		if value, _ := entry["request_id"].(string); value != "req-"+redacted {
+			t.Fatalf("request_id: got %q, want the secret masked", value)
+		}
+	})
+
+	t.Run("fields of the logger", func(t *testing.T) {
+		l, lines := newTestLogger(t, Config{RedactKeys: []string{"session"}, RedactValues: []string{testSecret}})
+		l.sugar = l.sugar.With(
+			"token", "eyJhbGciOi",
+			"session_id", 42,
+			"authorization", []byte("Bearer eyJhbGciOi"),
+			"client_secret", map[string]string{"key": "value"},
+			"endpoint", "https://api.example.com?key="+testSecret,
+			"user", "alice",
+		)
+		l.Info(context.Background(), "message")
+
+		entry := lines()[0]
+		for _, key := range []string{"token", "session_id", "authorization", "client_secret"} {
+			if entry[key] != redacted {
+				t.Fatalf("%s: got %v, want %s", key, entry[key], redacted)
+			}
+		}
+		if value, _ := entry["endpoint"].(string); strings.Contains(value, testSecret) {
+			t.Fatalf("endpoint: got %q, want the secret masked", value)
+		}
+		if entry["user"] != "alice" {
+			t.Fatalf("user: got %v, want alice", entry["user"])
+		}
+	})
+}
@@ -0,0 +1,201 @@
+package account
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+type service struct {
+	auditLogger      ports.AuditLogger
+	chatAdapter      ports.ChatHandler
+	exportService    ports.ExportService
+	identityProvider ports.IdentityProvider
+	logger           ports.Logger
+	quotaService     ports.QuotaService
+	receiptSecret    []byte
+	safetyEvents     ports.SafetyEventRepository
+	tombstones       ports.TombstoneRepository
+	userDataHandler  ports.UserDataAPIHandler
+}
+
+// NewAccountService creates an AccountService. Deletion receipts are signed
+// with receiptSecret.
+func NewAccountService(receiptSecret string, a ports.AuditLogger, chatAdapter ports.ChatHandler, e ports.ExportService,
+	ip ports.IdentityProvider, l ports.Logger, q ports.QuotaService, se ports.SafetyEventRepository,
+	t ports.TombstoneRepository, u ports.UserDataAPIHandler) ports.AccountService {
+	s := &service{
+		auditLogger:      a,
+		chatAdapter:      chatAdapter,
+		exportService:    e,
+		identityProvider: ip,
+		logger:           l,
+		quotaService:     q,
+		receiptSecret:    []byte(receiptSecret),
+		safetyEvents:     se,
+		tombstones:       t,
+		userDataHandler:  u,
+	}
+
+	if len(s.receiptSecret) == 0 {
+		panic("Cannot create service without a receipt secret")
+	}
+	if s.auditLogger == nil {
+		panic("Cannot create service without an AuditLogger")
+	}
+	if s.chatAdapter == nil {
+		panic("Cannot create service without a ChatHandler")
+	}
+	if s.exportService == nil {
+		panic("Cannot create service without an ExportService")
+	}
+	if s.identityProvider == nil {
+		panic("Cannot create service without an IdentityProvider")
+	}
+	if s.logger == nil {
+		panic("Cannot create service without a Logger")
+	}
+	if s.quotaService == nil {
+		panic("Cannot create service without a QuotaService")
+	}
+	if s.safetyEvents == nil {
+		panic("Cannot create service without a SafetyEventRepository")
+	}
+	if s.tombstones == nil {
+		panic("Cannot create service without a TombstoneRepository")
+	}
+	if s.userDataHandler == nil {
+		panic("Cannot create service without a UserDataAPIHandler")
+	}
+
+	return s
+}
+
+func (s *service) DeleteAccount(ctx context.Context, userID string) (*domain.DeletionReceipt, error) {
+	receipt, err := s.erase(ctx, userID, true)
+	// The audit log outlives the account, so the event only identifies the
+	// user by the hash their tombstone is keyed by
+	erasedUser := domain.TombstoneKey(userID)
+	s.recordAudit(ctx, domain.NewAuditEvent(ctx, erasedUser, domain.AuditActionAccountDelete, erasedUser, err))
+	return receipt, domain.WrapUnavailable(err)
+}
+
+func (s *service) PurgeUserData(ctx context.Context, userID string) (*domain.DeletionReceipt, error) {
+	receipt, err := s.erase(ctx, userID, false)
+	s.recordAudit(ctx, domain.NewAuditEvent(ctx, "identity-provider", domain.AuditActionAccountDelete, domain.TombstoneKey(userID), err))
+	return receipt, domain.WrapUnavailable(err)
+}
+
+func (s *service) VerifyReceipt(ctx context.Context, receipt *domain.DeletionReceipt) bool {
+	if receipt == nil || receipt.Signature == "" {
+		return false
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(receipt.Signature)
+	if err != nil {
+		return false
+	}
+	expected, err := s.sign(receipt)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(signature, expected)
+}
+
+// erase deletes everything stored about the user. Every step is idempotent,
+// so a failed deletion can simply be retried.
+func (s *service) erase(ctx context.Context, userID string, deleteIdentity bool) (*domain.DeletionReceipt, error) {
+	if userID == "" {
+		return nil, errors.New("userID cannot be empty")
+	}
+
+	// The tombstone goes first, so requests in flight can't recreate the data
+	// while it is being deleted
+	if err := s.tombstones.Add(ctx, domain.TombstoneKey(userID)); err != nil {
+		s.logger.Error(ctx, "could not add tombstone", "error", err.Error())
+		return nil, fmt.Errorf("could not add tombstone: %w", err)
+	}
+
+	userData, err := s.userDataHandler.GetByID(ctx, userID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("could not get user_data information: %w", err)
+	}
+	if userData != nil && userData.ThreadID != nil {
+		err := s.chatAdapter.DeleteThread(ctx, *userData.ThreadID)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return nil, fmt.Errorf("could not delete thread: %w", err)
+		}
+	}
+	if userData != nil {
+		if err := s.userDataHandler.Delete(ctx, userID); err != nil {
+			return nil, fmt.Errorf("could not delete user_data information: %w", err)
+		}
+	}
+
+	if err := s.quotaService.ResetUsage(ctx, userID); err != nil {
+		return nil, fmt.Errorf("could not reset usage: %w", err)
+	}
+	if err := s.exportService.Discard(ctx, userID); err != nil {
+		return nil, fmt.Errorf("could not discard exports: %w", err)
+	}
+	if err := s.safetyEvents.DeleteByUser(ctx, userID); err != nil {
+		return nil, fmt.Errorf("could not delete safety events: %w", err)
+	}
+
+	erased := []string{domain.RecordConversation, domain.RecordUserData, domain.RecordUsage,
+		domain.RecordExports, domain.RecordSafetyEvents}
+	// Purges are triggered by the identity provider after deleting the
+	// identity, and deployments without an identity store hold none. The
+	// receipt only lists the identity when it was erased here.
+	if deleteIdentity {
+		err := s.identityProvider.DeleteUser(ctx, userID)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return nil, fmt.Errorf("could not delete identity: %w", err)
+		}
+		if err == nil {
+			erased = append(erased, domain.RecordIdentity)
+		}
+	}
+
+	receipt := &domain.DeletionReceipt{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		DeletedAt: time.Now().UTC(),
+		Erased:    erased,
+		Retained:  []string{domain.RecordAuditLog},
+	}
+	signature, err := s.sign(receipt)
+	if err != nil {
+		return nil, fmt.Errorf("could not sign deletion receipt: %w", err)
+	}
+	receipt.Signature = base64.RawURLEncoding.EncodeToString(signature)
+
+	s.logger.Info(ctx, "account deleted", "receipt_id", receipt.ID)
+	return receipt, nil
+}
+
+// sign computes the HMAC of the receipt, ignoring its current signature.
+func (s *service) sign(receipt *domain.DeletionReceipt) ([]byte, error) {
+	unsigned := *receipt
+	unsigned.Signature = ""
+	payload, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, s.receiptSecret)
+	mac.Write(payload)
+	return mac.Sum(nil), nil
+}
+
+func (s *service) recordAudit(ctx context.Context, event *domain.AuditEvent) {
+	if err := s.auditLogger.Record(ctx, event); err != nil {
+		s.logger.Error(ctx, "could not record audit event", "action", event.Action, "error", err.Error())
+	}
+}
@@ -0,0 +1,117 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+)
+
+type service struct {
+	chatAdapter     ports.ChatHandler
+	logger          ports.Logger
+	quotaService    ports.QuotaService
+	safetyEvents    ports.SafetyEventRepository
+	userDataHandler ports.UserDataAPIHandler
+}
+
+func NewAdminService(chatAdapter ports.ChatHandler, l ports.Logger, q ports.QuotaService, se ports.SafetyEventRepository, u ports.UserDataAPIHandler) ports.AdminService {
+	s := &service{
+		chatAdapter:     chatAdapter,
+		logger:          l,
+		quotaService:    q,
+		safetyEvents:    se,
+		userDataHandler: u,
+	}
+
+	if s.chatAdapter == nil {
+		panic("Cannot create service without a ChatHandler")
+	}
+	if s.logger == nil {
+		panic("Cannot create service without a Logger")
+	}
+	if s.quotaService == nil {
+		panic("Cannot create service without a QuotaService")
+	}
+	if s.safetyEvents == nil {
+		panic("Cannot create service without a SafetyEventRepository")
+	}
+	if s.userDataHandler == nil {
+		panic("Cannot create service without a UserDataAPIHandler")
+	}
+
+	return s
+}
+
+func (s *service) GetUser(ctx context.Context, userID string) (*domain.UserData, error) {
+	if userID == "" {
+		return nil, errors.New("userID cannot be empty")
+	}
+	userData, err := s.userDataHandler.GetByID(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, domain.WrapError(domain.ErrNotFound, "User not found", err)
+	}
+	if err != nil {
+		return nil, domain.WrapUnavailable(fmt.Errorf("could not get user_data information: %w", err))
+	}
+	return userData, nil
+}
+
+func (s *service) ResetThread(ctx context.Context, userID string) (*domain.UserData, error) {
+	userData, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if userData.ThreadID == nil {
+		return userData, nil
+	}
+
+	// Only the thread that was read is unlinked, so that a thread linked
+	// since isn't reset unseen
+	threadID := *userData.ThreadID
+	err = s.userDataHandler.Patch(ctx, userID, domain.UserDataPatch{
+		ClearThreadID: true,
+		If:            &domain.UserDataCondition{ThreadID: threadID},
+	})
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, domain.WrapError(domain.ErrConflict, "The thread changed while it was reset, please retry", err)
+	}
+	if err != nil {
+		s.logger.Warn(ctx, "could not update user_data information", "error", err.Error())
+		return nil, domain.WrapUnavailable(fmt.Errorf("could not update user_data information: %w", err))
+	}
+
+	if err := s.chatAdapter.DeleteThread(ctx, threadID); err != nil {
+		// The thread is unlinked anyway so the user gets a fresh conversation
+		s.logger.Warn(ctx, "could not delete thread", "thread_id", threadID, "error", err.Error())
+	}
+	userData.ThreadID = nil
+	userData.KeyID = nil
+	return userData, nil
+}
+
+func (s *service) SetPlan(ctx context.Context, userID string, plan domain.Plan) (*domain.UserData, error) {
+	userData, err := s.quotaService.SetPlan(ctx, userID, plan)
+	return userData, domain.WrapUnavailable(err)
+}
+
+func (s *service) ResetUsage(ctx context.Context, userID string) error {
+	if userID == "" {
+		return errors.New("userID cannot be empty")
+	}
+	return domain.WrapUnavailable(s.quotaService.ResetUsage(ctx, userID))
+}
+
+func (s *service) Usage(ctx context.Context) (*domain.UsageSummary, error) {
+	summary, err := s.quotaService.Usage(ctx)
+	return summary, domain.WrapUnavailable(err)
+}
+
+func (s *service) SafetyEvents(ctx context.Context, limit int) ([]domain.SafetyEvent, error) {
+	events, err := s.safetyEvents.List(ctx, limit)
+	if err != nil {
+		return nil, domain.WrapUnavailable(fmt.Errorf("could not list safety events: %w", err))
+	}
+	return events, nil
+}
@@ -6,18 +6,48 @@ import (
 	"fmt"
 	"stress-relief-ai-chat-back/internal/domain"
 	"stress-relief-ai-chat-back/internal/ports"
+	"sync"
+	"time"
 )
 
+// activityResolution is how precisely the last activity of users is tracked.
+const activityResolution = time.Hour
+
+// errShuttingDown rejects the messages received while the service is drained.
+var errShuttingDown = domain.WrapError(domain.ErrUnavailable, "", errors.New("shutting down"))
+
 type service struct {
+	auditLogger     ports.AuditLogger
 	chatAdapter     ports.ChatHandler
 	logger          ports.Logger
+	metrics         ports.Metrics
+	quotaService    ports.QuotaService
+	safety          ports.SafetyClassifier
+	safetyEvents    ports.SafetyEventRepository
+	tombstones      ports.TombstoneRepository
+	tracer          ports.Tracer
 	userDataHandler ports.UserDataAPIHandler
+
+	// mu guards the tracking of the messages being processed, so that the
+	// service can be drained
+	mu       sync.Mutex
+	inFlight int
+	draining bool
+	drained  chan struct{}
 }
 
-func NewChatService(chatAdapter ports.ChatHandler, l ports.Logger, u ports.UserDataAPIHandler) ports.ChatService {
+func NewChatService(chatAdapter ports.ChatHandler, l ports.Logger, u ports.UserDataAPIHandler, q ports.QuotaService, a ports.AuditLogger, t ports.TombstoneRepository, m ports.Metrics, tr ports.Tracer,
+	sc ports.SafetyClassifier, se ports.SafetyEventRepository) ports.ChatService {
 	ch := &service{
+		auditLogger:     a,
 		chatAdapter:     chatAdapter,
 		logger:          l,
+		metrics:         m,
+		quotaService:    q,
+		safety:          sc,
+		safetyEvents:    se,
+		tombstones:      t,
+		tracer:          tr,
 		userDataHandler: u,
 	}
 
@@ -30,14 +60,77 @@ func NewChatService(chatAdapter ports.ChatHandler, l ports.Logger, u ports.UserD
 	if ch.userDataHandler == nil {
 		panic("Cannot create service without a UserDataAPIHandler")
 	}
+	if ch.quotaService == nil {
+		panic("Cannot create service without a QuotaService")
+	}
+	if ch.auditLogger == nil {
+		panic("Cannot create service without an AuditLogger")
+	}
+	if ch.tombstones == nil {
+		panic("Cannot create service without a TombstoneRepository")
+	}
+	if ch.metrics == nil {
+		panic("Cannot create service without a Metrics")
+	}
+	if ch.tracer == nil {
+		panic("Cannot create service without a Tracer")
+	}
+	if ch.safety == nil {
+		panic("Cannot create service without a SafetyClassifier")
+	}
+	if ch.safetyEvents == nil {
+		panic("Cannot create service without a SafetyEventRepository")
+	}
 
 	return ch
 }
 
-func (s *service) ProcessMessage(ctx context.Context, message *domain.ChatMessage, userID string) (*domain.ChatResponse, error) {
+func (s *service) ProcessMessage(ctx context.Context, message *domain.ChatMessage, principal *domain.Principal) (*domain.ChatResponse, error) {
+	// Spans are exported to a third party, so they don't identify the user
+	ctx, span := s.tracer.Start(ctx, "chat.ProcessMessage", "anonymous", principal.IsAnonymous)
+	var (
+		resp *domain.ChatResponse
+		err  error
+	)
+	if s.begin() {
+		func() {
+			defer s.end()
+			resp, err = s.processMessage(ctx, message, principal.UserID, principal.QuotaID())
+		}()
+	} else {
+		err = errShuttingDown
+	}
+
+	outcome := "failed"
+	switch {
+	case err == nil:
+		outcome = "processed"
+	case errors.Is(err, domain.ErrQuotaExceeded):
+		outcome = "quota_exceeded"
+	case errors.Is(err, domain.ErrAccountDeleted):
+		outcome = "account_deleted"
+	case errors.Is(err, errShuttingDown):
+		outcome = "shutting_down"
+	}
+	s.metrics.ObserveMessage(outcome)
+	span.SetAttributes("outcome", outcome)
+	if outcome == "failed" {
+		span.End(err)
+	} else {
+		// Rejected messages are expected, not failures of the service
+		span.End(nil)
+	}
+	return resp, domain.WrapUnavailable(err)
+}
+
+// processMessage answers the message of userID, counted against the quota of
+// quotaKey.
+func (s *service) processMessage(ctx context.Context, message *domain.ChatMessage, userID, quotaKey string) (*domain.ChatResponse, error) {
 	if message == nil {
 		return nil, errors.New("message cannot be nil")
 	}
+	ctx = domain.WithUserID(ctx, userID)
+
 	// Get the user_data information from the database, to get the threadID if exists
 	userData, err := s.userDataHandler.GetByID(ctx, userID)
 	if err != nil && !errors.Is(err, domain.ErrNotFound) {
@@ -45,28 +138,192 @@ func (s *service) ProcessMessage(ctx context.Context, message *domain.ChatMessag
 		return nil, fmt.Errorf("could not get user_data information: %w", err)
 	}
 
+	quota, err := s.quotaService.Consume(ctx, quotaKey, userData.EffectivePlan())
+	if err != nil {
+		s.logger.Info(ctx, "message rejected by quota", "user_id", userID, "error", err.Error())
+		if errors.Is(err, domain.ErrQuotaExceeded) {
+			return nil, domain.WrapError(domain.ErrQuotaExceeded, "", err)
+		}
+		return nil, fmt.Errorf("could not consume quota: %w", err)
+	}
+
 	var threadId *string
-	if userData != nil {
+	if userData != nil && userData.ThreadID != nil {
 		threadId = userData.ThreadID
+		ctx = domain.WithThreadID(ctx, *threadId)
 	}
+	flag := s.classify(ctx, message)
 	chatResponse, err := s.chatAdapter.ProcessMessage(ctx, message, threadId)
+	if flag != nil {
+		// The message may have started a thread, which is where a human
+		// would look into it
+		flaggedThreadID := threadId
+		if err == nil {
+			flaggedThreadID = &chatResponse.ThreadID
+		}
+		s.recordSafetyEvent(ctx, userID, flaggedThreadID, flag)
+	}
 	if err != nil {
+		s.quotaService.Refund(ctx, quotaKey)
 		s.logger.Error(ctx, "error processing message", "error", err.Error())
 		return nil, fmt.Errorf("error processing message: %w", err)
 	}
+	chatResponse.Quota = quota
 
 	// Update the user_data information with the new threadID, if needed
-	if userData == nil {
-		s.logger.Debug(ctx, "user_data information not found, creating new entry")
-		err := s.userDataHandler.Insert(ctx, userID, &domain.UserData{
-			UserID:   userID,
-			ThreadID: &chatResponse.ThreadID,
-		})
+	if threadId == nil {
+		ctx = domain.WithThreadID(ctx, chatResponse.ThreadID)
+
+		// The account may have been deleted while the message was processed,
+		// in which case the new thread must not be linked to it
+		if err := s.checkNotDeleted(ctx, userID); err != nil {
+			if err := s.chatAdapter.DeleteThread(ctx, chatResponse.ThreadID); err != nil {
+				s.logger.Error(ctx, "could not delete thread of deleted account", "error", err.Error())
+			}
+			return nil, err
+		}
+
+		err := s.saveThread(ctx, userID, userData, chatResponse.ThreadID)
+		s.recordAudit(ctx, domain.NewAuditEvent(ctx, userID, domain.AuditActionThreadCreate, userID, err))
 		if err != nil {
 			s.logger.Warn(ctx, "could not update user_data information", "error", err.Error())
 			return nil, fmt.Errorf("could not update user_data information: %w", err)
 		}
+	} else if s.shouldTouch(userData) {
+		if err := s.touch(ctx, userID, userData); err != nil {
+			// Only the retention of the conversation depends on it, so the
+			// response is not worth failing for
+			s.logger.Warn(ctx, "could not update last activity", "error", err.Error())
+		}
 	}
 
 	return chatResponse, nil
 }
+
+// classify returns the safety flag of the message, or nil. A failure of the
+// classifier doesn't stop the message from being answered.
+func (s *service) classify(ctx context.Context, message *domain.ChatMessage) *domain.SafetyFlag {
+	flag, err := s.safety.Classify(ctx, message.Content)
+	if err != nil {
+		s.logger.Error(ctx, "could not classify message", "error", err.Error())
+		return nil
+	}
+	return flag
+}
+
+// recordSafetyEvent raises a safety event for the flagged message of the
+// user. The message itself is not stored.
+func (s *service) recordSafetyEvent(ctx context.Context, userID string, threadID *string, flag *domain.SafetyFlag) {
+	event := &domain.SafetyEvent{
+		UserID:   userID,
+		Category: flag.Category,
+		Details:  flag.Reason,
+	}
+	if threadID != nil {
+		event.ThreadID = *threadID
+	}
+	if err := s.safetyEvents.Record(ctx, event); err != nil {
+		s.logger.Error(ctx, "could not record safety event", "category", flag.Category, "error", err.Error())
+		return
+	}
+	s.logger.Warn(ctx, "message flagged for safety review", "category", flag.Category)
+}
+
+func (s *service) Drain(ctx context.Context) int {
+	s.mu.Lock()
+	if !s.draining {
+		s.draining = true
+		s.drained = make(chan struct{})
+		if s.inFlight == 0 {
+			close(s.drained)
+		}
+	}
+	drained := s.drained
+	s.mu.Unlock()
+
+	select {
+	case <-drained:
+		return 0
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.inFlight
+	}
+}
+
+// begin records that a message is being processed. It reports false if the
+// service is draining, in which case the message must be rejected.
+func (s *service) begin() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.draining {
+		return false
+	}
+	s.inFlight++
+	return true
+}
+
+// end records that a message has been processed.
+func (s *service) end() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight--
+	if s.draining && s.inFlight == 0 {
+		close(s.drained)
+	}
+}
+
+// checkNotDeleted returns an error of kind domain.ErrAccountDeleted if the
+// account of the user has been deleted. Deleted accounts can't authenticate,
+// so it only guards against deletions while a message is processed.
+func (s *service) checkNotDeleted(ctx context.Context, userID string) error {
+	deleted, err := s.tombstones.Exists(ctx, domain.TombstoneKey(userID))
+	if err != nil {
+		s.logger.Warn(ctx, "could not check tombstone", "error", err.Error())
+		return fmt.Errorf("could not check tombstone: %w", err)
+	}
+	if deleted {
+		return domain.NewError(domain.ErrAccountDeleted, "")
+	}
+	return nil
+}
+
+// saveThread links a newly created thread to the user, creating their
+// user_data entry if it does not exist yet.
+func (s *service) saveThread(ctx context.Context, userID string, userData *domain.UserData, threadID string) error {
+	now := time.Now().UTC()
+	if userData == nil {
+		s.logger.Debug(ctx, "user_data information not found, creating new entry")
+		return s.userDataHandler.Insert(ctx, userID, &domain.UserData{
+			UserID:       userID,
+			ThreadID:     &threadID,
+			LastActiveAt: &now,
+		})
+	}
+	userData.ThreadID = &threadID
+	userData.LastActiveAt = &now
+	return s.userDataHandler.Patch(ctx, userID, domain.UserDataPatch{
+		ThreadID:     &threadID,
+		LastActiveAt: &now,
+	})
+}
+
+// shouldTouch reports whether the last activity of the user is stale enough to
+// be updated. Activity is only tracked with a coarse resolution to avoid a
+// write on every message.
+func (s *service) shouldTouch(userData *domain.UserData) bool {
+	return userData.LastActiveAt == nil || time.Since(*userData.LastActiveAt) > activityResolution
+}
+
+// touch records that the user has just been active.
+func (s *service) touch(ctx context.Context, userID string, userData *domain.UserData) error {
+	now := time.Now().UTC()
+	userData.LastActiveAt = &now
+	return s.userDataHandler.Patch(ctx, userID, domain.UserDataPatch{LastActiveAt: &now})
+}
+
+func (s *service) recordAudit(ctx context.Context, event *domain.AuditEvent) {
+	if err := s.auditLogger.Record(ctx, event); err != nil {
+		s.logger.Error(ctx, "could not record audit event", "action", event.Action, "error", err.Error())
+	}
+}
@@ -0,0 +1,80 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/domain"
+	"strings"
+	"time"
+)
+
+// archive packs the export in a zip file with a machine readable JSON document
+// and a human readable Markdown transcript of the conversation.
+func archive(data *domain.UserDataExport) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	jsonData, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling export: %w", err)
+	}
+
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{"export.json", jsonData},
+		{"conversation.md", []byte(markdown(data))},
+	}
+	for _, f := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     f.name,
+			Method:   zip.Deflate,
+			Modified: data.GeneratedAt,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error adding %s to archive: %w", f.name, err)
+		}
+		if _, err := w.Write(f.content); err != nil {
+			return nil, fmt.Errorf("error writing %s to archive: %w", f.name, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("error closing archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func markdown(data *domain.UserDataExport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Your Stress Relief AI Chat data\n\n")
+	fmt.Fprintf(&b, "- User ID: `%s`\n", data.UserID)
+	fmt.Fprintf(&b, "- Generated at: %s\n", data.GeneratedAt.Format(time.RFC1123))
+	if data.UserData != nil {
+		fmt.Fprintf(&b, "- Plan: %s\n", data.UserData.EffectivePlan())
+	}
+
+	fmt.Fprintf(&b, "\n## Conversation\n\n")
+	if len(data.Messages) == 0 {
+		fmt.Fprintf(&b, "_No messages._\n")
+	}
+	for _, m := range data.Messages {
+		author := "You"
+		if m.Role == domain.MessageRoleAssistant {
+			author = "Assistant"
+		}
+		fmt.Fprintf(&b, "### %s — %s\n\n%s\n\n", author, m.CreatedAt.Format(time.RFC1123), m.Content)
+	}
+
+	fmt.Fprintf(&b, "## Activity\n\n")
+	if len(data.AuditEvents) == 0 {
+		fmt.Fprintf(&b, "_No recorded activity._\n")
+	}
+	for _, e := range data.AuditEvents {
+		fmt.Fprintf(&b, "- %s: %s (%s)\n", e.CreatedAt.Format(time.RFC1123), e.Action, e.Outcome)
+	}
+	return b.String()
+}
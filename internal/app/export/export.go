@@ -0,0 +1,257 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"sync"
+	"time"
+)
+
+const (
+	// exportTimeout bounds how long generating a single export may take.
+	exportTimeout = 10 * time.Minute
+	// abandonedAfter is how long after its creation a pending export is
+	// deemed abandoned, e.g. because the instance generating it stopped.
+	abandonedAfter = exportTimeout + time.Minute
+	// exportTTL is how long a generated export can be downloaded.
+	exportTTL = time.Hour
+)
+
+// Config holds the limits of the exports.
+type Config struct {
+	// MaxPerUser is how many exports a user can keep. Starting another one
+	// discards their oldest.
+	MaxPerUser int
+	// MaxSize is how many bytes an archive can take. Larger exports fail.
+	MaxSize int64
+}
+
+type service struct {
+	auditLogger     ports.AuditLogger
+	chatAdapter     ports.ChatHandler
+	config          Config
+	exports         ports.ExportRepository
+	logger          ports.Logger
+	userDataHandler ports.UserDataAPIHandler
+
+	mu sync.Mutex
+	// running holds the exports generated by this instance, closing their
+	// channel once stored.
+	running map[string]chan struct{}
+}
+
+// NewExportService creates an ExportService that generates exports in the
+// background and stores them in e, within the limits of config, until they
+// expire. Any instance sharing e can serve the exports.
+func NewExportService(config Config, a ports.AuditLogger, chatAdapter ports.ChatHandler, e ports.ExportRepository,
+	l ports.Logger, u ports.UserDataAPIHandler) ports.ExportService {
+	s := &service{
+		auditLogger:     a,
+		chatAdapter:     chatAdapter,
+		config:          config,
+		exports:         e,
+		logger:          l,
+		userDataHandler: u,
+		running:         make(map[string]chan struct{}),
+	}
+
+	if s.config.MaxPerUser <= 0 {
+		panic("Cannot create service without a positive MaxPerUser")
+	}
+	if s.config.MaxSize <= 0 {
+		panic("Cannot create service without a positive MaxSize")
+	}
+
+	if s.auditLogger == nil {
+		panic("Cannot create service without an AuditLogger")
+	}
+	if s.chatAdapter == nil {
+		panic("Cannot create service without a ChatHandler")
+	}
+	if s.exports == nil {
+		panic("Cannot create service without an ExportRepository")
+	}
+	if s.logger == nil {
+		panic("Cannot create service without a Logger")
+	}
+	if s.userDataHandler == nil {
+		panic("Cannot create service without a UserDataAPIHandler")
+	}
+
+	return s
+}
+
+func (s *service) Start(ctx context.Context, userID string, wait time.Duration) (*domain.DataExport, error) {
+	if userID == "" {
+		return nil, errors.New("userID cannot be empty")
+	}
+
+	exportID, done, err := s.start(ctx, userID)
+	if err != nil {
+		return nil, domain.WrapUnavailable(err)
+	}
+
+	// Exports generated by another instance are returned right away
+	if done != nil {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-done:
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+	return s.Get(ctx, userID, exportID)
+}
+
+// start stores a new pending export for the user and begins generating it,
+// unless one is already pending. It returns the id of the export, and the
+// channel closed once it is stored if this instance generates it.
+func (s *service) start(ctx context.Context, userID string) (string, <-chan struct{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	if err := s.exports.DeleteCreatedBefore(ctx, now.Add(-exportTTL)); err != nil {
+		return "", nil, fmt.Errorf("could not delete expired exports: %w", err)
+	}
+	exports, err := s.exports.ListByUser(ctx, userID)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not list exports: %w", err)
+	}
+
+	// An export already being generated has the same content as a new one
+	for _, export := range exports {
+		if export.Status == domain.ExportStatusPending && now.Sub(export.CreatedAt) < abandonedAfter {
+			return export.ID, s.running[export.ID], nil
+		}
+	}
+
+	for _, export := range exports[:max(len(exports)-(s.config.MaxPerUser-1), 0)] {
+		if err := s.exports.Delete(ctx, export.ID); err != nil {
+			return "", nil, fmt.Errorf("could not delete export: %w", err)
+		}
+	}
+
+	export := &domain.DataExport{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Status:    domain.ExportStatusPending,
+		CreatedAt: now,
+	}
+	if err := s.exports.Insert(ctx, export); err != nil {
+		return "", nil, fmt.Errorf("could not store export: %w", err)
+	}
+	done := make(chan struct{})
+	s.running[export.ID] = done
+
+	// The export outlives the request, so it only keeps the values of its
+	// context
+	go s.run(context.WithoutCancel(ctx), export, done)
+	return export.ID, done, nil
+}
+
+func (s *service) Get(ctx context.Context, userID, exportID string) (*domain.DataExport, error) {
+	export, err := s.exports.Get(ctx, exportID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, domain.NewError(domain.ErrNotFound, "Export not found")
+	}
+	if err != nil {
+		return nil, domain.WrapUnavailable(err)
+	}
+	if export.UserID != userID || time.Since(export.CreatedAt) > exportTTL {
+		return nil, domain.NewError(domain.ErrNotFound, "Export not found")
+	}
+	if export.Status == domain.ExportStatusPending && time.Since(export.CreatedAt) > abandonedAfter {
+		export.Status = domain.ExportStatusFailed
+		export.Error = "The export could not be generated"
+	}
+	return export, nil
+}
+
+func (s *service) Discard(ctx context.Context, userID string) error {
+	if err := s.exports.DeleteByUser(ctx, userID); err != nil {
+		return domain.WrapUnavailable(err)
+	}
+	return nil
+}
+
+func (s *service) run(ctx context.Context, export *domain.DataExport, done chan struct{}) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, export.ID)
+		s.mu.Unlock()
+		close(done)
+	}()
+
+	genCtx, cancel := context.WithTimeout(ctx, exportTimeout)
+	defer cancel()
+
+	userID := export.UserID
+	start := time.Now()
+	archive, err := s.generate(genCtx, userID)
+	s.recordAudit(ctx, domain.NewAuditEvent(ctx, userID, domain.AuditActionDataExport, userID, err))
+
+	now := time.Now().UTC()
+	export.CompletedAt = &now
+	switch size := int64(len(archive)); {
+	case err != nil:
+		s.logger.Error(ctx, "could not generate data export", "export_id", export.ID, "error", err.Error())
+		export.Status = domain.ExportStatusFailed
+		export.Error = "The export could not be generated"
+	case size > s.config.MaxSize:
+		s.logger.Error(ctx, "data export is larger than the exports kept", "export_id", export.ID, "size", size)
+		export.Status = domain.ExportStatusFailed
+		export.Error = "The export is too large to be downloaded"
+	default:
+		s.logger.Info(ctx, "data export generated", "export_id", export.ID, "time", time.Since(start).String())
+		export.Status = domain.ExportStatusReady
+		export.Archive = archive
+	}
+
+	err = s.exports.Update(ctx, export)
+	if errors.Is(err, domain.ErrNotFound) {
+		s.logger.Debug(ctx, "data export discarded while it was generated", "export_id", export.ID)
+	} else if err != nil {
+		s.logger.Error(ctx, "could not store data export", "export_id", export.ID, "error", err.Error())
+	}
+}
+
+// generate gathers everything stored about the user and packs it in a zip
+// archive.
+func (s *service) generate(ctx context.Context, userID string) ([]byte, error) {
+	data := &domain.UserDataExport{
+		UserID:      userID,
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	userData, err := s.userDataHandler.GetByID(ctx, userID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("could not get user_data information: %w", err)
+	}
+	data.UserData = userData
+
+	if userData != nil && userData.ThreadID != nil {
+		data.Messages, err = s.chatAdapter.ListMessages(ctx, *userData.ThreadID)
+		if err != nil {
+			return nil, fmt.Errorf("could not list messages: %w", err)
+		}
+	}
+
+	data.AuditEvents, err = s.auditLogger.Query(ctx, domain.AuditQuery{TargetUserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("could not query audit log: %w", err)
+	}
+
+	return archive(data)
+}
+
+func (s *service) recordAudit(ctx context.Context, event *domain.AuditEvent) {
+	if err := s.auditLogger.Record(ctx, event); err != nil {
+		s.logger.Error(ctx, "could not record audit event", "action", event.Action, "error", err.Error())
+	}
+}
@@ -0,0 +1,152 @@
+package health
+
+import (
+	"context"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config holds the settings of the health checks.
+type Config struct {
+	// Version and Commit identify the build of the service.
+	Version string
+	Commit  string
+	// CacheTTL is how long the result of a check is reused, so that frequent
+	// probes don't load the dependencies.
+	CacheTTL time.Duration
+	// Timeout bounds each check.
+	Timeout time.Duration
+}
+
+type service struct {
+	config       Config
+	dependencies []*dependency
+	logger       ports.Logger
+	shuttingDown atomic.Bool
+	startedAt    time.Time
+	now          func() time.Time
+}
+
+// dependency holds the last result of the checks of a dependency. Checks of
+// the same dependency are serialized, so that concurrent probes share the
+// result of a single check.
+type dependency struct {
+	checker ports.HealthChecker
+	mu      sync.Mutex
+	status  domain.DependencyStatus
+}
+
+func NewHealthService(config Config, checkers []ports.HealthChecker, l ports.Logger) ports.HealthService {
+	s := &service{
+		config:    config,
+		logger:    l,
+		startedAt: time.Now().UTC(),
+		now:       time.Now,
+	}
+
+	if s.config.CacheTTL < 0 {
+		panic("Cannot create service with a negative cache TTL")
+	}
+	if s.config.Timeout <= 0 {
+		panic("Cannot create service without a positive timeout")
+	}
+	if s.logger == nil {
+		panic("Cannot create service without a Logger")
+	}
+	for _, checker := range checkers {
+		if checker == nil {
+			panic("Cannot create service with a nil HealthChecker")
+		}
+		s.dependencies = append(s.dependencies, &dependency{checker: checker})
+	}
+	return s
+}
+
+func (s *service) Ready(ctx context.Context) bool {
+	if s.shuttingDown.Load() {
+		return false
+	}
+	for _, status := range s.checkAll(ctx) {
+		if !status.Up() {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *service) Status(ctx context.Context) *domain.SystemStatus {
+	statuses := s.checkAll(ctx)
+	ready := !s.shuttingDown.Load()
+	for _, status := range statuses {
+		ready = ready && status.Up()
+	}
+	return &domain.SystemStatus{
+		Version:       s.config.Version,
+		Commit:        s.config.Commit,
+		StartedAt:     s.startedAt,
+		UptimeSeconds: int64(s.now().Sub(s.startedAt).Seconds()),
+		Ready:         ready,
+		Dependencies:  statuses,
+	}
+}
+
+func (s *service) ShutDown() {
+	if !s.shuttingDown.Swap(true) {
+		s.logger.Info(context.Background(), "Shutting down, reporting not ready")
+	}
+}
+
+// checkAll checks all the dependencies concurrently, reusing the results that
+// are not older than the cache TTL.
+func (s *service) checkAll(ctx context.Context) []domain.DependencyStatus {
+	statuses := make([]domain.DependencyStatus, len(s.dependencies))
+	var wg sync.WaitGroup
+	for i, d := range s.dependencies {
+		wg.Add(1)
+		go func(i int, d *dependency) {
+			defer wg.Done()
+			statuses[i] = s.check(ctx, d)
+		}(i, d)
+	}
+	wg.Wait()
+	return statuses
+}
+
+func (s *service) check(ctx context.Context, d *dependency) domain.DependencyStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.status.CheckedAt.IsZero() && s.now().Sub(d.status.CheckedAt) < s.config.CacheTTL {
+		return d.status
+	}
+
+	// The result is shared with other probes, so it must not depend on
+	// whether the caller gave up
+	checkCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.config.Timeout)
+	defer cancel()
+	start := s.now()
+	err := d.checker.Check(checkCtx)
+
+	previous := d.status
+	d.status = domain.DependencyStatus{
+		Name:      d.checker.Name(),
+		Status:    domain.DependencyStatusUp,
+		LatencyMs: s.now().Sub(start).Milliseconds(),
+		CheckedAt: start.UTC(),
+		Error:     err,
+	}
+	if err != nil {
+		d.status.Status = domain.DependencyStatusDown
+	}
+
+	switch {
+	case err != nil && previous.Status != domain.DependencyStatusDown:
+		s.logger.Warn(ctx, "Dependency is down", "dependency", d.status.Name, "error", err.Error())
+	case err == nil && previous.Status == domain.DependencyStatusDown:
+		s.logger.Info(ctx, "Dependency is up again", "dependency", d.status.Name)
+	}
+	return d.status
+}
@@ -0,0 +1,141 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+// unlimited is the limit used for plans without a cap on messages.
+const unlimited = -1
+
+type service struct {
+	limits          map[domain.Plan]int
+	logger          ports.Logger
+	usage           ports.UsageRepository
+	userDataHandler ports.UserDataAPIHandler
+	now             func() time.Time
+}
+
+// NewQuotaService creates a QuotaService that keeps per user daily message
+// counters in the UsageRepository, so that they are shared by all the
+// replicas and survive restarts. Free users can send freeDailyLimit messages
+// per day (UTC), premium users are unlimited.
+func NewQuotaService(freeDailyLimit int, l ports.Logger, usage ports.UsageRepository, u ports.UserDataAPIHandler) ports.QuotaService {
+	s := &service{
+		limits: map[domain.Plan]int{
+			domain.PlanFree:    freeDailyLimit,
+			domain.PlanPremium: unlimited,
+		},
+		logger:          l,
+		usage:           usage,
+		userDataHandler: u,
+		now:             time.Now,
+	}
+
+	if freeDailyLimit < 0 {
+		panic("Cannot create quota service with a negative free daily limit")
+	}
+	if s.logger == nil {
+		panic("Cannot create quota service without a Logger")
+	}
+	if s.usage == nil {
+		panic("Cannot create quota service without a UsageRepository")
+	}
+	if s.userDataHandler == nil {
+		panic("Cannot create quota service without a UserDataAPIHandler")
+	}
+
+	return s
+}
+
+func (s *service) Consume(ctx context.Context, userID string, plan domain.Plan) (*domain.QuotaStatus, error) {
+	if userID == "" {
+		return nil, errors.New("userID cannot be empty")
+	}
+	limit, ok := s.limits[plan]
+	if !ok {
+		s.logger.Warn(ctx, "unknown plan, falling back to free", "plan", plan)
+		plan, limit = domain.PlanFree, s.limits[domain.PlanFree]
+	}
+
+	now := s.now().UTC()
+	status := &domain.QuotaStatus{
+		Plan:    plan,
+		Limit:   limit,
+		ResetAt: startOfNextDay(now),
+	}
+	if limit == 0 {
+		return nil, &domain.QuotaExceededError{Status: *status}
+	}
+
+	count, ok, err := s.usage.Increment(ctx, userID, now.Format(time.DateOnly), plan, limit)
+	if err != nil {
+		return nil, fmt.Errorf("could not count message: %w", err)
+	}
+	if !ok {
+		return nil, &domain.QuotaExceededError{Status: *status}
+	}
+
+	status.Remaining = unlimited
+	if limit != unlimited {
+		status.Remaining = max(limit-count, 0)
+	}
+	return status, nil
+}
+
+func (s *service) Refund(ctx context.Context, userID string) {
+	if err := s.usage.Decrement(ctx, userID, s.now().UTC().Format(time.DateOnly)); err != nil {
+		s.logger.Warn(ctx, "could not refund message", "error", err.Error())
+	}
+}
+
+func (s *service) ResetUsage(ctx context.Context, userID string) error {
+	if err := s.usage.Delete(ctx, userID); err != nil {
+		return fmt.Errorf("could not reset usage: %w", err)
+	}
+	return nil
+}
+
+func (s *service) Usage(ctx context.Context) (*domain.UsageSummary, error) {
+	summary, err := s.usage.Summary(ctx, s.now().UTC().Format(time.DateOnly))
+	if err != nil {
+		return nil, fmt.Errorf("could not get usage: %w", err)
+	}
+	return summary, nil
+}
+
+func (s *service) SetPlan(ctx context.Context, userID string, plan domain.Plan) (*domain.UserData, error) {
+	if userID == "" {
+		return nil, errors.New("userID cannot be empty")
+	}
+	if !plan.Valid() {
+		return nil, fmt.Errorf("invalid plan %q", plan)
+	}
+
+	// Only the plan is written, so that the changes made to the entry
+	// concurrently are kept
+	err := s.userDataHandler.Patch(ctx, userID, domain.UserDataPatch{Plan: &plan})
+	if errors.Is(err, domain.ErrNotFound) {
+		err = s.userDataHandler.Insert(ctx, userID, &domain.UserData{UserID: userID, Plan: plan})
+	}
+	if err != nil {
+		s.logger.Warn(ctx, "could not save plan", "error", err.Error())
+		return nil, fmt.Errorf("could not save plan: %w", err)
+	}
+
+	userData, err := s.userDataHandler.GetByID(ctx, userID)
+	if err != nil {
+		s.logger.Warn(ctx, "could not get user_data information", "error", err.Error())
+		return nil, fmt.Errorf("could not get user_data information: %w", err)
+	}
+	return userData, nil
+}
+
+func startOfNextDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,214 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+const (
+	// batchSize is how many user_data entries are scanned per page.
+	batchSize = 100
+	// maxRetentionDays bounds the retention users can opt into.
+	maxRetentionDays = 3650
+	// auditActor is the actor recorded for purges.
+	auditActor = "retention-scheduler"
+)
+
+// Config holds the settings of the retention policy.
+type Config struct {
+	// Retention is how long conversations are kept after the last message.
+	Retention time.Duration
+	// Interval is how often the purge runs.
+	Interval time.Duration
+	// DryRun only logs the conversations that would be purged.
+	DryRun bool
+}
+
+type service struct {
+	auditLogger     ports.AuditLogger
+	chatAdapter     ports.ChatHandler
+	config          Config
+	logger          ports.Logger
+	userDataHandler ports.UserDataAPIHandler
+	now             func() time.Time
+}
+
+func NewRetentionService(config Config, a ports.AuditLogger, chatAdapter ports.ChatHandler, l ports.Logger, u ports.UserDataAPIHandler) ports.RetentionService {
+	s := &service{
+		auditLogger:     a,
+		chatAdapter:     chatAdapter,
+		config:          config,
+		logger:          l,
+		userDataHandler: u,
+		now:             time.Now,
+	}
+
+	if s.config.Retention <= 0 {
+		panic("Cannot create service without a positive retention")
+	}
+	if s.config.Interval <= 0 {
+		panic("Cannot create service without a positive interval")
+	}
+	if s.auditLogger == nil {
+		panic("Cannot create service without an AuditLogger")
+	}
+	if s.chatAdapter == nil {
+		panic("Cannot create service without a ChatHandler")
+	}
+	if s.logger == nil {
+		panic("Cannot create service without a Logger")
+	}
+	if s.userDataHandler == nil {
+		panic("Cannot create service without a UserDataAPIHandler")
+	}
+
+	return s
+}
+
+func (s *service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Purge(ctx); err != nil {
+			s.logger.Error(ctx, "retention purge failed", "error", err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *service) Purge(ctx context.Context) (*domain.PurgeReport, error) {
+	now := s.now().UTC()
+	report := &domain.PurgeReport{
+		DryRun:    s.config.DryRun,
+		StartedAt: now,
+		Cutoff:    now.Add(-s.config.Retention),
+	}
+
+	filter := domain.UserDataFilter{
+		InactiveBefore: report.Cutoff,
+		Limit:          batchSize,
+	}
+	for {
+		page, err := s.userDataHandler.List(ctx, filter)
+		if err != nil {
+			return report, fmt.Errorf("could not list inactive user_data: %w", err)
+		}
+
+		for i := range page {
+			s.purge(ctx, &page[i], now, report)
+		}
+
+		if len(page) < batchSize {
+			break
+		}
+		filter.AfterUserID = page[len(page)-1].UserID
+	}
+
+	s.logger.Info(ctx, "retention purge finished",
+		"dry_run", report.DryRun,
+		"cutoff", report.Cutoff,
+		"scanned", report.Scanned,
+		"purged", report.Purged,
+		"retained", report.Retained,
+		"skipped", report.Skipped,
+		"failed", report.Failed)
+	return report, nil
+}
+
+// purge deletes the conversation of an inactive user unless they opted into a
+// longer retention, counting the outcome in report.
+func (s *service) purge(ctx context.Context, userData *domain.UserData, now time.Time, report *domain.PurgeReport) {
+	report.Scanned++
+	if userData.ThreadID == nil || userData.LastActiveAt == nil {
+		return
+	}
+	inactiveBefore := report.Cutoff
+	if days := userData.RetentionDays; days != nil {
+		if cutoff := now.AddDate(0, 0, -*days); cutoff.Before(inactiveBefore) {
+			inactiveBefore = cutoff
+		}
+	}
+	if !userData.LastActiveAt.Before(inactiveBefore) {
+		report.Retained++
+		return
+	}
+
+	if s.config.DryRun {
+		s.logger.Info(ctx, "conversation would be purged", "user_id", userData.UserID,
+			"thread_id", *userData.ThreadID, "last_active_at", userData.LastActiveAt)
+		report.Purged++
+		return
+	}
+
+	// The thread is unlinked before it is deleted, and only if the user is
+	// still inactive with the thread that was listed, so that a user who sent
+	// a message since keeps their conversation
+	threadID := *userData.ThreadID
+	err := s.userDataHandler.Patch(ctx, userData.UserID, domain.UserDataPatch{
+		ClearThreadID: true,
+		If:            &domain.UserDataCondition{ThreadID: threadID, InactiveBefore: inactiveBefore},
+	})
+	if errors.Is(err, domain.ErrNotFound) {
+		s.logger.Debug(ctx, "conversation changed since it was listed, not purged", "user_id", userData.UserID)
+		report.Skipped++
+		return
+	}
+	if err == nil {
+		err = s.chatAdapter.DeleteThread(ctx, threadID)
+		if errors.Is(err, domain.ErrNotFound) {
+			err = nil
+		}
+	}
+
+	event := domain.NewAuditEvent(ctx, auditActor, domain.AuditActionThreadPurge, userData.UserID, err)
+	if err := s.auditLogger.Record(ctx, event); err != nil {
+		s.logger.Error(ctx, "could not record audit event", "action", event.Action, "error", err.Error())
+	}
+
+	if err != nil {
+		s.logger.Warn(ctx, "could not purge conversation", "user_id", userData.UserID, "error", err.Error())
+		report.Failed++
+		return
+	}
+	s.logger.Info(ctx, "conversation purged", "user_id", userData.UserID, "thread_id", threadID)
+	report.Purged++
+}
+
+func (s *service) SetUserRetention(ctx context.Context, userID string, days int) (*domain.UserData, error) {
+	if userID == "" {
+		return nil, errors.New("userID cannot be empty")
+	}
+	minDays := int(s.config.Retention.Hours() / 24)
+	if days < minDays || days > maxRetentionDays {
+		return nil, domain.NewError(domain.ErrInvalidInput, fmt.Sprintf("Retention must be between %d and %d days", minDays, maxRetentionDays))
+	}
+
+	// Only the retention is written, so that the changes made to the entry
+	// concurrently are kept
+	err := s.userDataHandler.Patch(ctx, userID, domain.UserDataPatch{RetentionDays: &days})
+	if errors.Is(err, domain.ErrNotFound) {
+		err = s.userDataHandler.Insert(ctx, userID, &domain.UserData{UserID: userID, RetentionDays: &days})
+	}
+
+	event := domain.NewAuditEvent(ctx, userID, domain.AuditActionRetentionSet, userID, err)
+	if err := s.auditLogger.Record(ctx, event); err != nil {
+		s.logger.Error(ctx, "could not record audit event", "action", event.Action, "error", err.Error())
+	}
+	if err != nil {
+		return nil, domain.WrapUnavailable(fmt.Errorf("could not save retention: %w", err))
+	}
+
+	userData, err := s.userDataHandler.GetByID(ctx, userID)
+	if err != nil {
+		return nil, domain.WrapUnavailable(fmt.Errorf("could not get user_data information: %w", err))
+	}
+	return userData, nil
+}
@@ -0,0 +1,165 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+)
+
+type service struct {
+	chatAdapter     ports.ChatHandler
+	guestTokens     ports.GuestTokenProvider
+	logger          ports.Logger
+	tombstones      ports.TombstoneRepository
+	userDataHandler ports.UserDataAPIHandler
+}
+
+func NewSessionService(chatAdapter ports.ChatHandler, g ports.GuestTokenProvider, l ports.Logger, t ports.TombstoneRepository, u ports.UserDataAPIHandler) ports.SessionService {
+	s := &service{
+		chatAdapter:     chatAdapter,
+		guestTokens:     g,
+		logger:          l,
+		tombstones:      t,
+		userDataHandler: u,
+	}
+
+	if s.chatAdapter == nil {
+		panic("Cannot create service without a ChatHandler")
+	}
+	if s.guestTokens == nil {
+		panic("Cannot create service without a GuestTokenProvider")
+	}
+	if s.logger == nil {
+		panic("Cannot create service without a Logger")
+	}
+	if s.tombstones == nil {
+		panic("Cannot create service without a TombstoneRepository")
+	}
+	if s.userDataHandler == nil {
+		panic("Cannot create service without a UserDataAPIHandler")
+	}
+
+	return s
+}
+
+func (s *service) CreateGuest(ctx context.Context, clientKey string) (*domain.GuestSession, error) {
+	userID := uuid.NewString()
+	token, expiresAt, err := s.guestTokens.Issue(ctx, userID, clientKey)
+	if err != nil {
+		s.logger.Error(ctx, "could not issue guest token", "error", err.Error())
+		return nil, fmt.Errorf("could not issue guest token: %w", err)
+	}
+
+	s.logger.Debug(ctx, "guest session created", "user_id", userID)
+	return &domain.GuestSession{
+		UserID:    userID,
+		Token:     token,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (s *service) LinkGuest(ctx context.Context, principal *domain.Principal, guestToken string) (*domain.UserData, error) {
+	userData, err := s.linkGuest(ctx, principal, guestToken)
+	return userData, domain.WrapUnavailable(err)
+}
+
+// cantLink returns the error of a guest session that can't be linked to the
+// account of the principal for the reason err.
+func cantLink(err error) error {
+	return domain.WrapError(domain.ErrForbidden, "Guest session can't be linked to this account", err)
+}
+
+func (s *service) linkGuest(ctx context.Context, principal *domain.Principal, guestToken string) (*domain.UserData, error) {
+	if principal == nil || principal.IsAnonymous {
+		return nil, cantLink(errors.New("guest sessions can only be linked to a registered account"))
+	}
+	guest, err := s.guestTokens.Authenticate(ctx, guestToken)
+	if err != nil {
+		return nil, cantLink(fmt.Errorf("invalid guest token: %w", err))
+	}
+	linked, err := s.tombstones.Exists(ctx, domain.TombstoneKey(guest.UserID))
+	if err != nil {
+		s.logger.Warn(ctx, "could not check guest tombstone", "error", err.Error())
+		return nil, fmt.Errorf("could not check guest tombstone: %w", err)
+	}
+	if linked {
+		return nil, cantLink(errors.New("guest session was already linked"))
+	}
+	userID := principal.UserID
+
+	guestData, err := s.getUserData(ctx, guest.UserID)
+	if err != nil {
+		return nil, err
+	}
+	userData, err := s.getUserData(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if guestData == nil || guestData.ThreadID == nil {
+		s.logger.Debug(ctx, "guest session has no conversation to link", "guest_id", guest.UserID)
+	} else if userData == nil {
+		userData = &domain.UserData{UserID: userID, ThreadID: guestData.ThreadID, LastActiveAt: guestData.LastActiveAt}
+		if err := s.userDataHandler.Insert(ctx, userID, userData); err != nil {
+			s.logger.Warn(ctx, "could not insert user_data information", "error", err.Error())
+			return nil, fmt.Errorf("could not insert user_data information: %w", err)
+		}
+	} else if userData.ThreadID == nil {
+		userData.ThreadID = guestData.ThreadID
+		userData.LastActiveAt = guestData.LastActiveAt
+		err := s.userDataHandler.Patch(ctx, userID, domain.UserDataPatch{
+			ThreadID:     guestData.ThreadID,
+			LastActiveAt: guestData.LastActiveAt,
+		})
+		if err != nil {
+			s.logger.Warn(ctx, "could not update user_data information", "error", err.Error())
+			return nil, fmt.Errorf("could not update user_data information: %w", err)
+		}
+	} else {
+		// Both have a conversation, so the guest's messages are copied at the
+		// end of the user's thread and the guest thread is discarded
+		history, err := s.chatAdapter.ListMessages(ctx, *guestData.ThreadID)
+		if err != nil {
+			return nil, fmt.Errorf("could not list guest messages: %w", err)
+		}
+		if err := s.chatAdapter.AppendMessages(ctx, *userData.ThreadID, history); err != nil {
+			return nil, fmt.Errorf("could not append guest messages: %w", err)
+		}
+		if err := s.chatAdapter.DeleteThread(ctx, *guestData.ThreadID); err != nil {
+			// The history is already merged, a leftover thread is not worth failing for
+			s.logger.Warn(ctx, "could not delete guest thread", "thread_id", *guestData.ThreadID, "error", err.Error())
+		}
+	}
+
+	// The guest token is revoked before its data is deleted, so it can't be
+	// used to start a new conversation in the meantime
+	if err := s.tombstones.Add(ctx, domain.TombstoneKey(guest.UserID)); err != nil {
+		s.logger.Error(ctx, "could not add guest tombstone", "error", err.Error())
+		return nil, fmt.Errorf("could not add guest tombstone: %w", err)
+	}
+	if guestData != nil {
+		if err := s.userDataHandler.Delete(ctx, guest.UserID); err != nil {
+			s.logger.Warn(ctx, "could not delete guest user_data information", "error", err.Error())
+			return nil, fmt.Errorf("could not delete guest user_data information: %w", err)
+		}
+	}
+
+	s.logger.Info(ctx, "guest session linked", "guest_id", guest.UserID, "user_id", userID)
+	if userData == nil {
+		userData = &domain.UserData{UserID: userID}
+	}
+	return userData, nil
+}
+
+// getUserData returns the user_data of userID, or nil if there is none.
+func (s *service) getUserData(ctx context.Context, userID string) (*domain.UserData, error) {
+	userData, err := s.userDataHandler.GetByID(ctx, userID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		s.logger.Warn(ctx, "could not get user_data information", "error", err.Error())
+		return nil, fmt.Errorf("could not get user_data information: %w", err)
+	}
+	return userData, nil
+}
@@ -0,0 +1,135 @@
+package shutdown
+
+import (
+	"context"
+	"fmt"
+	"stress-relief-ai-chat-back/internal/domain"
+	"stress-relief-ai-chat-back/internal/ports"
+	"time"
+)
+
+// Config holds the settings of the shutdown.
+type Config struct {
+	// ReadinessDelay is how long requests are still accepted once the service
+	// reports that it is not ready, so that load balancers stop routing to it
+	// before the listener closes.
+	ReadinessDelay time.Duration
+	// DrainTimeout is how long the messages being processed are given to be
+	// answered.
+	DrainTimeout time.Duration
+	// CancelTimeout bounds the cancellation of the runs of the messages left,
+	// and how long their requests are then given to fail.
+	CancelTimeout time.Duration
+}
+
+type service struct {
+	chatService   ports.ChatService
+	config        Config
+	healthService ports.HealthService
+	logger        ports.Logger
+	// runCanceller is optional, runs are not cancelled when it is nil.
+	runCanceller ports.RunCanceller
+	server       ports.Server
+}
+
+func NewShutdownService(config Config, chatService ports.ChatService, h ports.HealthService, l ports.Logger, r ports.RunCanceller, server ports.Server) ports.ShutdownService {
+	s := &service{
+		chatService:   chatService,
+		config:        config,
+		healthService: h,
+		logger:        l,
+		runCanceller:  r,
+		server:        server,
+	}
+
+	if s.config.ReadinessDelay < 0 {
+		panic("Cannot create service with a negative readiness delay")
+	}
+	if s.config.DrainTimeout <= 0 {
+		panic("Cannot create service without a positive drain timeout")
+	}
+	if s.config.CancelTimeout <= 0 {
+		panic("Cannot create service without a positive cancel timeout")
+	}
+	if s.chatService == nil {
+		panic("Cannot create service without a ChatService")
+	}
+	if s.healthService == nil {
+		panic("Cannot create service without a HealthService")
+	}
+	if s.logger == nil {
+		panic("Cannot create service without a Logger")
+	}
+	if s.server == nil {
+		panic("Cannot create service without a Server")
+	}
+	return s
+}
+
+// Shutdown reports that the service is not ready, waits for the readiness
+// delay, then stops accepting requests and lets the messages being processed
+// be answered, up to the drain timeout. The runs of the messages left are then
+// cancelled, so that they don't complete unseen, and their requests are
+// given the cancel timeout to fail. The logger is flushed last.
+func (s *service) Shutdown(ctx context.Context) *domain.ShutdownReport {
+	report := &domain.ShutdownReport{}
+	s.healthService.ShutDown()
+	s.logger.Info(ctx, "Shutting down", "readiness_delay", s.config.ReadinessDelay.String(),
+		"drain_timeout", s.config.DrainTimeout.String())
+	if s.config.ReadinessDelay > 0 {
+		timer := time.NewTimer(s.config.ReadinessDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	// The server waits for the requests in progress, which include the
+	// messages being drained, so it is given until all of them are done
+	serverCtx, cancelServer := context.WithTimeout(ctx, s.config.DrainTimeout+s.config.CancelTimeout)
+	defer cancelServer()
+	serverDone := make(chan error, 1)
+	go func() {
+		serverDone <- s.server.ShutdownWithContext(serverCtx)
+	}()
+
+	drainCtx, cancelDrain := context.WithTimeout(ctx, s.config.DrainTimeout)
+	report.AbandonedMessages = s.chatService.Drain(drainCtx)
+	cancelDrain()
+
+	if report.AbandonedMessages > 0 && s.runCanceller != nil {
+		cancelCtx, cancel := context.WithTimeout(ctx, s.config.CancelTimeout)
+		runs, err := s.runCanceller.CancelActiveRuns(cancelCtx)
+		cancel()
+		report.CancelledRuns = runs
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Errorf("could not cancel runs: %w", err))
+		}
+	}
+
+	if err := <-serverDone; err != nil {
+		report.Errors = append(report.Errors, fmt.Errorf("could not shut down server: %w", err))
+	}
+
+	s.logReport(ctx, report)
+	if err := s.logger.Close(); err != nil {
+		report.Errors = append(report.Errors, fmt.Errorf("could not flush logger: %w", err))
+	}
+	return report
+}
+
+func (s *service) logReport(ctx context.Context, report *domain.ShutdownReport) {
+	if report.Clean() {
+		s.logger.Info(ctx, "Shut down cleanly")
+		return
+	}
+	errs := make([]string, len(report.Errors))
+	for i, err := range report.Errors {
+		errs[i] = err.Error()
+	}
+	s.logger.Warn(ctx, "Shut down abandoning work",
+		"abandoned_messages", report.AbandonedMessages,
+		"cancelled_runs", report.CancelledRuns,
+		"errors", errs)
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"stress-relief-ai-chat-back/internal/domain"
+	"time"
+)
+
+// Config holds the settings of the service. The desc tag of each field is
+// its documentation, written to the example env file.
+type Config struct {
+	Server     Server     `yaml:"server"`
+	API        API        `yaml:"api"`
+	RateLimits RateLimits `yaml:"rate_limits"`
+	Log        Log        `yaml:"log"`
+	Metrics    Metrics    `yaml:"metrics"`
+	Tracing    Tracing    `yaml:"tracing"`
+	Health     Health     `yaml:"health"`
+	LLM        LLM        `yaml:"llm"`
+	Supabase   Supabase   `yaml:"supabase"`
+	Storage    Storage    `yaml:"storage"`
+	UserCache  UserCache  `yaml:"user_cache"`
+	Encryption Encryption `yaml:"encryption"`
+	Audit      Audit      `yaml:"audit"`
+	Quota      Quota      `yaml:"quota"`
+	Retention  Retention  `yaml:"retention"`
+	Guest      Guest      `yaml:"guest"`
+	Account    Account    `yaml:"account"`
+	Export     Export     `yaml:"export"`
+	Shutdown   Shutdown   `yaml:"shutdown"`
+}
+
+// Server holds the settings of the HTTP server.
+type Server struct {
+	Port              int      `yaml:"port" env:"PORT" default:"8080" desc:"Port the HTTP server listens on."`
+	AdminAPIKey       string   `yaml:"admin_api_key" env:"ADMIN_API_KEY" secret:"true" desc:"Grants access to the admin routes when sent in the X-Admin-API-Key header. Disabled when empty."`
+	AdminRole         string   `yaml:"admin_role" env:"ADMIN_ROLE" desc:"Application role that grants access to the admin routes. Disabled when empty."`
+	AuthWebhookSecret string   `yaml:"auth_webhook_secret" env:"AUTH_WEBHOOK_SECRET" secret:"true" desc:"Authenticates the Supabase Auth webhook. The webhook is disabled when empty."`
+	ProxyHeader       string   `yaml:"proxy_header" env:"PROXY_HEADER" desc:"Header the reverse proxy sets to the client address, such as X-Real-IP. The first address of X-Forwarded-For is sent by the client, so it is only safe when the proxy overwrites the header. Client addresses are taken from the connection when empty."`
+	TrustedProxies    []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES" desc:"Comma separated addresses or CIDR ranges of the reverse proxies whose PROXY_HEADER is trusted. Required with PROXY_HEADER."`
+}
+
+// API holds the settings of the versions of the API.
+type API struct {
+	LegacySunset   time.Time `yaml:"legacy_sunset" env:"API_LEGACY_SUNSET" desc:"When the unversioned /api routes, deprecated aliases of /api/v1, stop being served, as an RFC 3339 timestamp. Announced in the Sunset header, not announced when empty."`
+	V1DeprecatedAt time.Time `yaml:"v1_deprecated_at" env:"API_V1_DEPRECATED_AT" desc:"When /api/v1 was deprecated in favor of /api/v2, as an RFC 3339 timestamp. Not deprecated when empty."`
+	V1Sunset       time.Time `yaml:"v1_sunset" env:"API_V1_SUNSET" desc:"When /api/v1 stops being served, as an RFC 3339 timestamp. Not announced when empty."`
+}
+
+// RateLimits holds the rate limit of each route group, and where they are
+// counted.
+type RateLimits struct {
+	Store    string           `yaml:"store" env:"RATE_LIMIT_STORE" default:"memory" desc:"Where rate limits are counted: memory, per instance, or postgres, shared by the instances using the same database."`
+	Messages domain.RateLimit `yaml:"messages" env:"RATE_LIMIT_MESSAGES" default:"20/1m" desc:"Rate limit of the chat routes, in the form <limit>/<period>."`
+	Admin    domain.RateLimit `yaml:"admin" env:"RATE_LIMIT_ADMIN" default:"60/1m" desc:"Rate limit of the admin routes."`
+	Me       domain.RateLimit `yaml:"me" env:"RATE_LIMIT_ME" default:"30/1m" desc:"Rate limit of the routes on the data of the authenticated user."`
+	Public   domain.RateLimit `yaml:"public" env:"RATE_LIMIT_PUBLIC" default:"30/1m" desc:"Rate limit of the unauthenticated routes."`
+	Sessions domain.RateLimit `yaml:"sessions" env:"RATE_LIMIT_SESSIONS" default:"5/1m" desc:"Rate limit of the guest session routes."`
+}
+
+// Log holds the settings of the logger.
+type Log struct {
+	Level            string   `yaml:"level" env:"LOG_LEVEL" default:"info" desc:"Minimum level logged: debug, info, warn or error."`
+	Encoding         string   `yaml:"encoding" env:"LOG_ENCODING" default:"json" desc:"Either json or console."`
+	Development      bool     `yaml:"development" env:"LOG_DEVELOPMENT" desc:"Makes warnings include stack traces."`
+	Sampling         string   `yaml:"sampling" env:"LOG_SAMPLING" default:"100/100" desc:"Identical lines logged per second, in the form <initial>/<thereafter>, or off."`
+	OutputPaths      []string `yaml:"output_paths" env:"LOG_OUTPUT_PATHS" desc:"Comma separated sinks log lines are written to. Defaults to stdout."`
+	ErrorOutputPaths []string `yaml:"error_output_paths" env:"LOG_ERROR_OUTPUT_PATHS" desc:"Comma separated sinks internal logger errors are written to. Defaults to stderr."`
+	RedactKeys       []string `yaml:"redact_keys" env:"LOG_REDACT_KEYS" desc:"Comma separated field names whose values are redacted, on top of the defaults."`
+}
+
+// Metrics holds the settings of the Prometheus metrics.
+type Metrics struct {
+	Enabled bool `yaml:"enabled" env:"METRICS_ENABLED" default:"true" desc:"Exposes Prometheus metrics at /metrics on METRICS_PORT."`
+	Port    int  `yaml:"port" env:"METRICS_PORT" default:"9090" desc:"Port metrics are served on, apart from the public routes. Don't expose it publicly."`
+}
+
+// Tracing holds the settings of the traces.
+type Tracing struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" default:"none" desc:"Where spans are sent: none, or otlp to export them to OTEL_EXPORTER_OTLP_ENDPOINT."`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" default:"1" desc:"Fraction of the traces started by the service that are sampled."`
+}
+
+// Health holds the settings of the dependency checks.
+type Health struct {
+	CheckTTL     time.Duration `yaml:"check_ttl" env:"HEALTH_CHECK_TTL" default:"10s" desc:"How long the result of a dependency check is reused."`
+	CheckTimeout time.Duration `yaml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" default:"3s" desc:"Bounds each dependency check."`
+}
+
+// LLM holds the settings of the LLM provider.
+type LLM struct {
+	Provider          string `yaml:"provider" env:"LLM_PROVIDER" default:"openai" desc:"Either openai or fake, which answers with scripted replies."`
+	OpenAIAPIKey      string `yaml:"openai_api_key" env:"OPENAI_API_KEY" secret:"true" desc:"API key of OpenAI. Required with the openai provider."`
+	OpenAIAssistantID string `yaml:"openai_assistant_id" env:"OPENAI_ASSISTANT_ID" desc:"Assistant answering the messages. Required with the openai provider."`
+	FakeFixtures      string `yaml:"fake_fixtures" env:"FAKE_LLM_FIXTURES" desc:"YAML file with the scripted replies of the fake provider."`
+}
+
+// Supabase holds the settings of the Supabase project.
+type Supabase struct {
+	URL       string        `yaml:"url" env:"SUPABASE_URL" desc:"URL of the Supabase project. Enables Supabase Auth tokens and identities."`
+	APIKey    string        `yaml:"api_key" env:"SUPABASE_API_KEY" secret:"true" desc:"Service key of the Supabase project."`
+	JWTSecret string        `yaml:"jwt_secret" env:"SUPABASE_JWT_SECRET" secret:"true" desc:"Secret of the HS256 access tokens of the project. Only asymmetric tokens are accepted when empty."`
+	Timeout   time.Duration `yaml:"timeout" env:"SUPABASE_TIMEOUT" default:"10s" desc:"Bounds every request to Supabase."`
+}
+
+// Storage holds the settings of the user data storage.
+type Storage struct {
+	Driver      string `yaml:"driver" env:"STORAGE_DRIVER" default:"supabase" desc:"Where user data is stored: supabase, postgres or sqlite."`
+	DatabaseURL string `yaml:"database_url" env:"DATABASE_URL" secret:"true" desc:"Connection string of the postgres storage."`
+	AutoMigrate bool   `yaml:"auto_migrate" env:"DATABASE_AUTO_MIGRATE" desc:"Migrates the postgres storage on startup."`
+	SQLitePath  string `yaml:"sqlite_path" env:"SQLITE_PATH" default:"stress-relief.db" desc:"Path of the sqlite storage."`
+}
+
+// UserCache holds the settings of the user data cache.
+type UserCache struct {
+	Store       string        `yaml:"store" env:"USER_CACHE_STORE" default:"memory" desc:"Where user data lookups are cached: memory, per instance, or redis, shared by the instances using the same server so a write on one instance is seen by all of them."`
+	TTL         time.Duration `yaml:"ttl" env:"USER_CACHE_TTL" default:"1m" desc:"How long user data lookups are cached. Zero disables the cache."`
+	NegativeTTL time.Duration `yaml:"negative_ttl" env:"USER_CACHE_NEGATIVE_TTL" default:"30s" desc:"How long missing user data is cached."`
+	Size        int           `yaml:"size" env:"USER_CACHE_SIZE" default:"10000" desc:"Maximum number of entries cached in memory."`
+	RedisURL    string        `yaml:"redis_url" env:"USER_CACHE_REDIS_URL" secret:"true" desc:"URL of the Redis server of USER_CACHE_STORE=redis, such as redis://:password@localhost:6379/0."`
+}
+
+// Encryption holds the keys the stored user data is encrypted with.
+type Encryption struct {
+	Keys        string `yaml:"keys" env:"ENCRYPTION_KEYS" secret:"true" desc:"Keys encrypting the stored user data, in the form <id>:<base64 key>,... User data is stored in plaintext when empty."`
+	ActiveKeyID string `yaml:"active_key_id" env:"ENCRYPTION_ACTIVE_KEY_ID" desc:"Id of the key new data is encrypted with."`
+}
+
+// Audit holds the settings of the audit log.
+type Audit struct {
+	Store string `yaml:"store" env:"AUDIT_LOG_STORE" desc:"Where the audit log is stored: supabase, postgres, sqlite or file. Defaults to the storage driver."`
+	Path  string `yaml:"path" env:"AUDIT_LOG_PATH" default:"audit.log.jsonl" desc:"Path of the file audit log store."`
+}
+
+// Quota holds the message quotas of the plans.
+type Quota struct {
+	FreeDailyMessages int `yaml:"free_daily_messages" env:"FREE_PLAN_DAILY_MESSAGES" default:"20" desc:"Messages free users can send per day."`
+}
+
+// Retention holds the settings of the retention policy.
+type Retention struct {
+	Days     int           `yaml:"days" env:"RETENTION_DAYS" default:"90" desc:"Days conversations are kept after the last message."`
+	Interval time.Duration `yaml:"interval" env:"RETENTION_INTERVAL" default:"24h" desc:"How often expired conversations are purged."`
+	DryRun   bool          `yaml:"dry_run" env:"RETENTION_DRY_RUN" desc:"Only logs the conversations that would be purged."`
+}
+
+// Guest holds the settings of the guest sessions.
+type Guest struct {
+	TokenSecret string        `yaml:"token_secret" env:"GUEST_TOKEN_SECRET" secret:"true" desc:"Signs the tokens of guest sessions. Guest sessions are disabled when empty."`
+	TokenTTL    time.Duration `yaml:"token_ttl" env:"GUEST_TOKEN_TTL" default:"24h" desc:"How long guest tokens are valid."`
+}
+
+// Account holds the settings of the account deletions.
+type Account struct {
+	DeletionReceiptSecret string `yaml:"deletion_receipt_secret" env:"DELETION_RECEIPT_SECRET" secret:"true" desc:"Signs the receipts of account deletions."`
+}
+
+// Export holds the settings of the data exports, which are kept until
+// downloaded.
+type Export struct {
+	Store        string `yaml:"store" env:"EXPORT_STORE" desc:"Where the exports are stored: memory, supabase, postgres or sqlite. Defaults to the storage driver. memory only fits a single instance, as exports are served by the instance that generated them and lost on restart."`
+	MaxPerUser   int    `yaml:"max_per_user" env:"EXPORT_MAX_PER_USER" default:"2" desc:"Exports a user can keep. Starting another one discards their oldest."`
+	MaxSize      int    `yaml:"max_size_mb" env:"EXPORT_MAX_SIZE_MB" default:"64" desc:"Megabytes an export archive can take. Larger exports fail."`
+	MaxTotalSize int    `yaml:"max_total_size_mb" env:"EXPORT_MAX_TOTAL_SIZE_MB" default:"256" desc:"Megabytes of export archives kept with EXPORT_STORE=memory. The oldest archives are discarded to make room for new ones."`
+}
+
+// Shutdown holds the settings of the graceful shutdown.
+type Shutdown struct {
+	ReadinessDelay time.Duration `yaml:"readiness_delay" env:"SHUTDOWN_READINESS_DELAY" default:"5s" desc:"How long requests are still accepted on shutdown once readiness reports not ready, for load balancers to notice."`
+	DrainTimeout   time.Duration `yaml:"drain_timeout" env:"SHUTDOWN_DRAIN_TIMEOUT" default:"25s" desc:"How long the messages being processed are given to be answered on shutdown."`
+	CancelTimeout  time.Duration `yaml:"cancel_timeout" env:"SHUTDOWN_CANCEL_TIMEOUT" default:"5s" desc:"How long the runs of the messages left are given to be cancelled."`
+}
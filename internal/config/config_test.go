@@ -0,0 +1,187 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// validEnv is a minimal valid configuration.
+var validEnv = map[string]string{
+	"STORAGE_DRIVER":          "sqlite",
+	"SQLITE_PATH":             "data.db",
+	"LLM_PROVIDER":            "fake",
+	"GUEST_TOKEN_SECRET":      "abcdefghijklmnopqrstuvwxyz123456",
+	"DELETION_RECEIPT_SECRET": "receipt-secret",
+}
+
+// setEnv clears every setting from the environment of the test, then sets
+// validEnv overridden by env. Empty values count as unset.
+func setEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	t.Setenv("CONFIG_FILE", "")
+	for _, f := range fields(&Config{}) {
+		t.Setenv(f.env, "")
+		if f.secret {
+			t.Setenv(f.env+fileEnvSuffix, "")
+		}
+	}
+	for k, v := range validEnv {
+		t.Setenv(k, v)
+	}
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestValidConfig(t *testing.T) {
+	setEnv(t, nil)
+	c, err := Load(Options{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+}
+
+func TestLoadInvalidValues(t *testing.T) {
+	tests := []struct {
+		env, value string
+	}{
+		{"PORT", "http"},
+		{"METRICS_ENABLED", "maybe"},
+		{"HEALTH_CHECK_TTL", "5"},
+		{"TRACING_SAMPLE_RATIO", "half"},
+		{"RATE_LIMIT_MESSAGES", "20"},
+		{"API_V1_SUNSET", "tomorrow"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			setEnv(t, map[string]string{tt.env: tt.value})
+			_, err := Load(Options{})
+			if err == nil || !strings.Contains(err.Error(), tt.env+":") {
+				t.Fatalf("Load with %s=%s: got %v, want an error about %s", tt.env, tt.value, err, tt.env)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"port out of range", map[string]string{"PORT": "70000"}, "PORT: must be between 1 and 65535"},
+		{"metrics port of the server", map[string]string{"PORT": "9090", "METRICS_PORT": "9090"}, "METRICS_PORT: must differ from PORT"},
+		{"proxy header without proxies", map[string]string{"PROXY_HEADER": "X-Real-IP"}, "TRUSTED_PROXIES: required with PROXY_HEADER"},
+		{"invalid trusted proxy", map[string]string{"PROXY_HEADER": "X-Real-IP", "TRUSTED_PROXIES": "10.0.0.0/8,proxy"}, `TRUSTED_PROXIES: "proxy" is not an address or CIDR range`},
+		{"unknown log level", map[string]string{"LOG_LEVEL": "trace"}, "LOG_LEVEL: must be one of"},
+		{"sample ratio out of range", map[string]string{"TRACING_SAMPLE_RATIO": "2"}, "TRACING_SAMPLE_RATIO: must be between 0 and 1"},
+		{"openai without key", map[string]string{"LLM_PROVIDER": "openai", "OPENAI_ASSISTANT_ID": "asst"}, "OPENAI_API_KEY: required with LLM_PROVIDER=openai"},
+		{"unknown storage driver", map[string]string{"STORAGE_DRIVER": "mysql"}, "STORAGE_DRIVER: must be one of"},
+		{"postgres without url", map[string]string{"STORAGE_DRIVER": "postgres"}, "DATABASE_URL: required with STORAGE_DRIVER=postgres"},
+		{"relative supabase url", map[string]string{"SUPABASE_URL": "project.supabase.co", "SUPABASE_API_KEY": "key"}, "SUPABASE_URL: must be an absolute URL"},
+		{"redis cache without url", map[string]string{"USER_CACHE_STORE": "redis"}, "USER_CACHE_REDIS_URL: required with USER_CACHE_STORE=redis"},
+		{"audit log of another driver", map[string]string{"AUDIT_LOG_STORE": "postgres"}, "AUDIT_LOG_STORE: postgres requires STORAGE_DRIVER=postgres"},
+		{"exports of another driver", map[string]string{"EXPORT_STORE": "postgres"}, "EXPORT_STORE: postgres requires STORAGE_DRIVER=postgres"},
+		{"export larger than the memory store", map[string]string{"EXPORT_STORE": "memory", "EXPORT_MAX_SIZE_MB": "512"}, "EXPORT_MAX_SIZE_MB: can't exceed EXPORT_MAX_TOTAL_SIZE_MB"},
+		{"no authentication", map[string]string{"GUEST_TOKEN_SECRET": ""}, "either SUPABASE_URL or GUEST_TOKEN_SECRET must be set"},
+		{"missing receipt secret", map[string]string{"DELETION_RECEIPT_SECRET": ""}, "DELETION_RECEIPT_SECRET: required"},
+		{"negative drain timeout", map[string]string{"SHUTDOWN_DRAIN_TIMEOUT": "-1s"}, "SHUTDOWN_DRAIN_TIMEOUT: must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, tt.env)
+			c, err := Load(Options{})
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			err = c.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Validate: got %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecretFromFile(t *testing.T) {
+	path := writeFile(t, "secret", "from-file\n")
+	configFile := writeFile(t, "config.yaml", "guest:\n  token_secret: from-yaml\n")
+
+	t.Run("file read without its newline", func(t *testing.T) {
+		setEnv(t, map[string]string{"GUEST_TOKEN_SECRET": "", "GUEST_TOKEN_SECRET_FILE": path})
+		c, err := Load(Options{})
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if c.Guest.TokenSecret != "from-file" {
+			t.Fatalf("TokenSecret: got %q, want from-file", c.Guest.TokenSecret)
+		}
+	})
+
+	t.Run("file above the config file", func(t *testing.T) {
+		setEnv(t, map[string]string{"GUEST_TOKEN_SECRET": "", "GUEST_TOKEN_SECRET_FILE": path})
+		c, err := Load(Options{ConfigFile: configFile})
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if c.Guest.TokenSecret != "from-file" {
+			t.Fatalf("TokenSecret: got %q, want from-file", c.Guest.TokenSecret)
+		}
+	})
+
+	t.Run("both the variable and the file", func(t *testing.T) {
+		setEnv(t, map[string]string{"GUEST_TOKEN_SECRET": "from-env", "GUEST_TOKEN_SECRET_FILE": path})
+		_, err := Load(Options{})
+		if err == nil || !strings.Contains(err.Error(), "GUEST_TOKEN_SECRET: can't be set along with GUEST_TOKEN_SECRET_FILE") {
+			t.Fatalf("Load: got %v, want an error about both being set", err)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "missing")
+		setEnv(t, map[string]string{"GUEST_TOKEN_SECRET": "", "GUEST_TOKEN_SECRET_FILE": missing})
+		_, err := Load(Options{})
+		if err == nil || !strings.Contains(err.Error(), "GUEST_TOKEN_SECRET_FILE:") {
+			t.Fatalf("Load: got %v, want an error about the file", err)
+		}
+	})
+
+	t.Run("not a secret", func(t *testing.T) {
+		setEnv(t, map[string]string{"SQLITE_PATH_FILE": path})
+		c, err := Load(Options{})
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if c.Storage.SQLitePath != validEnv["SQLITE_PATH"] {
+			t.Fatalf("SQLitePath: got %q, want the variable", c.Storage.SQLitePath)
+		}
+	})
+}
+
+func TestExampleEnvUpToDate(t *testing.T) {
+	want, err := os.ReadFile("../../example.env")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	var got bytes.Buffer
+	if err := WriteExampleEnv(&got); err != nil {
+		t.Fatalf("WriteExampleEnv: %v", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Fatalf("example.env is out of date, run `make generate-example-env`")
+	}
+}
@@ -0,0 +1,22 @@
+// Package config loads the settings of the service into a typed Config.
+//
+// Every setting is read from an environment variable, named by the env tag of
+// its field. Values are looked up in order of precedence:
+//
+//  1. the environment variable, when it is not empty;
+//  2. for secrets, the file named by the environment variable suffixed with
+//     _FILE, such as OPENAI_API_KEY_FILE, as mounted by Docker or Kubernetes
+//     secrets;
+//  3. the YAML file named by CONFIG_FILE, keyed by the yaml tags of the
+//     fields;
+//  4. the default tag of the field.
+//
+// A .env file, when there is one, is loaded into the environment first
+// without overriding the variables already set. A YAML file looks like:
+//
+//	llm:
+//	  provider: fake
+//	storage:
+//	  driver: sqlite
+//	  sqlite_path: /var/lib/stress-relief/data.db
+package config
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// external are the variables that are not read into a Config, but by Load
+// itself or by the libraries the service uses.
+var external = []struct{ env, desc string }{
+	{"CONFIG_FILE", "YAML file the settings are read from, below the environment."},
+	{"OTEL_EXPORTER_OTLP_ENDPOINT", "Base URL spans are exported to with TRACING_EXPORTER=otlp, such as http://localhost:4318."},
+}
+
+// WriteExampleEnv writes an env file listing every setting with its
+// documentation and default, and no value.
+func WriteExampleEnv(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# Generated by `make generate-example-env`, do not edit.")
+	for _, v := range external {
+		fmt.Fprintf(bw, "\n# %s\n%s=\n", v.desc, v.env)
+	}
+	for _, f := range fields(&Config{}) {
+		fmt.Fprintf(bw, "\n# %s\n", f.desc)
+		if f.def != "" {
+			fmt.Fprintf(bw, "# Default: %s\n", f.def)
+		}
+		if f.secret {
+			fmt.Fprintf(bw, "# Secret, can be read from the file named by %s%s instead.\n", f.env, fileEnvSuffix)
+		}
+		fmt.Fprintf(bw, "%s=\n", f.env)
+	}
+	return bw.Flush()
+}
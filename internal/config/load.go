@@ -0,0 +1,214 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/fs"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fileEnvSuffix suffixes the variables naming a file a secret is read from.
+const fileEnvSuffix = "_FILE"
+
+// Options tells Load where to read the configuration from, on top of the
+// environment.
+type Options struct {
+	// EnvFile is loaded into the environment when it exists. Not loaded when
+	// empty.
+	EnvFile string
+	// ConfigFile is the YAML file to read. Defaults to CONFIG_FILE, not read
+	// when both are empty.
+	ConfigFile string
+}
+
+// Load reads the configuration. It returns an error aggregating every value
+// that could not be read; the configuration still has to be validated.
+func Load(opts Options) (*Config, error) {
+	if opts.EnvFile != "" {
+		if err := godotenv.Load(opts.EnvFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("could not load %s: %w", opts.EnvFile, err)
+		}
+	}
+
+	c := &Config{}
+	var errs []error
+	for _, f := range fields(c) {
+		if f.def == "" {
+			continue
+		}
+		if err := setValue(f.value, f.def); err != nil {
+			// Defaults are part of the code, so this is a programming error
+			panic(fmt.Sprintf("invalid default of %s: %s", f.env, err))
+		}
+	}
+
+	configFile := opts.ConfigFile
+	if configFile == "" {
+		configFile = os.Getenv("CONFIG_FILE")
+	}
+	if configFile != "" {
+		if err := readYAML(configFile, c); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, f := range fields(c) {
+		value, ok, err := lookup(f)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := setValue(f.value, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.env, err))
+		}
+	}
+	return c, errors.Join(errs...)
+}
+
+// readYAML reads the YAML file at path into c. Unknown keys are rejected, so
+// that a misspelled setting doesn't go unnoticed.
+func readYAML(path string, c *Config) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not open config file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("could not read config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// lookup returns the value of a field set in the environment, either directly
+// or, for secrets, in a file. Empty variables count as unset, like the blank
+// entries of the example env file.
+func lookup(f field) (string, bool, error) {
+	value := os.Getenv(f.env)
+	if !f.secret {
+		return value, value != "", nil
+	}
+
+	path := os.Getenv(f.env + fileEnvSuffix)
+	if path == "" {
+		return value, value != "", nil
+	}
+	if value != "" {
+		return "", false, fmt.Errorf("%s: can't be set along with %s%s", f.env, f.env, fileEnvSuffix)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("%s%s: %w", f.env, fileEnvSuffix, err)
+	}
+	// Files usually end with a newline that is not part of the secret
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// field is a setting of the configuration.
+type field struct {
+	env    string
+	def    string
+	desc   string
+	secret bool
+	value  reflect.Value
+}
+
+// fields returns the settings of c, in the order they are declared.
+func fields(c *Config) []field {
+	var list []field
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			env := sf.Tag.Get("env")
+			if env == "" {
+				if sf.Type.Kind() == reflect.Struct {
+					walk(v.Field(i))
+				}
+				continue
+			}
+			list = append(list, field{
+				env:    env,
+				def:    sf.Tag.Get("default"),
+				desc:   sf.Tag.Get("desc"),
+				secret: sf.Tag.Get("secret") == "true",
+				value:  v.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(c).Elem())
+	return list
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setValue parses s into v according to the type of v.
+func setValue(v reflect.Value, s string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		v.Set(reflect.ValueOf(splitList(s)))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// splitList splits a comma separated value, ignoring empty items.
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
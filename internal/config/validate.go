@@ -0,0 +1,221 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Validate checks the configuration as a whole, returning an error that lists
+// every invalid setting.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.check(c.Server.Port > 0 && c.Server.Port <= 65535, "PORT: must be between 1 and 65535")
+	if c.Metrics.Enabled {
+		v.check(c.Metrics.Port > 0 && c.Metrics.Port <= 65535, "METRICS_PORT: must be between 1 and 65535")
+		v.check(c.Metrics.Port != c.Server.Port, "METRICS_PORT: must differ from PORT")
+	}
+
+	if c.Server.ProxyHeader != "" {
+		v.check(len(c.Server.TrustedProxies) > 0, "TRUSTED_PROXIES: required with PROXY_HEADER")
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		_, _, err := net.ParseCIDR(proxy)
+		v.check(err == nil || net.ParseIP(proxy) != nil, "TRUSTED_PROXIES: %q is not an address or CIDR range", proxy)
+	}
+
+	if !c.API.V1Sunset.IsZero() {
+		v.check(!c.API.V1DeprecatedAt.IsZero(), "API_V1_SUNSET: requires API_V1_DEPRECATED_AT")
+		v.check(!c.API.V1Sunset.Before(c.API.V1DeprecatedAt), "API_V1_SUNSET: can't be before API_V1_DEPRECATED_AT")
+	}
+
+	v.oneOf("LOG_LEVEL", c.Log.Level, "debug", "info", "warn", "error")
+	v.oneOf("LOG_ENCODING", c.Log.Encoding, "json", "console")
+	if _, _, err := c.Log.SamplingRates(); err != nil {
+		v.add("LOG_SAMPLING: %s", err)
+	}
+
+	v.oneOf("TRACING_EXPORTER", c.Tracing.Exporter, "none", "otlp")
+	v.check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO: must be between 0 and 1")
+
+	v.check(c.Health.CheckTTL >= 0, "HEALTH_CHECK_TTL: can't be negative")
+	v.check(c.Health.CheckTimeout > 0, "HEALTH_CHECK_TIMEOUT: must be positive")
+
+	v.oneOf("LLM_PROVIDER", c.LLM.Provider, "openai", "fake")
+	if c.LLM.Provider == "openai" {
+		v.required("OPENAI_API_KEY", c.LLM.OpenAIAPIKey, "with LLM_PROVIDER=openai")
+		v.required("OPENAI_ASSISTANT_ID", c.LLM.OpenAIAssistantID, "with LLM_PROVIDER=openai")
+	}
+
+	if c.Supabase.URL != "" {
+		if u, err := url.ParseRequestURI(c.Supabase.URL); err != nil || u.Host == "" {
+			v.add("SUPABASE_URL: must be an absolute URL")
+		}
+		v.required("SUPABASE_API_KEY", c.Supabase.APIKey, "with SUPABASE_URL")
+	}
+	v.check(c.Supabase.Timeout > 0, "SUPABASE_TIMEOUT: must be positive")
+
+	v.oneOf("STORAGE_DRIVER", c.Storage.Driver, "supabase", "postgres", "sqlite")
+	switch c.Storage.Driver {
+	case "supabase":
+		v.required("SUPABASE_URL", c.Supabase.URL, "with STORAGE_DRIVER=supabase")
+	case "postgres":
+		v.required("DATABASE_URL", c.Storage.DatabaseURL, "with STORAGE_DRIVER=postgres")
+	case "sqlite":
+		v.required("SQLITE_PATH", c.Storage.SQLitePath, "with STORAGE_DRIVER=sqlite")
+	}
+
+	v.check(c.UserCache.TTL >= 0, "USER_CACHE_TTL: can't be negative")
+	v.check(c.UserCache.NegativeTTL >= 0, "USER_CACHE_NEGATIVE_TTL: can't be negative")
+	v.oneOf("USER_CACHE_STORE", c.UserCache.Store, "memory", "redis")
+	switch c.UserCache.Store {
+	case "memory":
+		v.check(c.UserCache.TTL == 0 || c.UserCache.Size > 0, "USER_CACHE_SIZE: must be positive")
+	case "redis":
+		v.required("USER_CACHE_REDIS_URL", c.UserCache.RedisURL, "with USER_CACHE_STORE=redis")
+	}
+
+	if c.Encryption.Keys != "" {
+		v.required("ENCRYPTION_ACTIVE_KEY_ID", c.Encryption.ActiveKeyID, "with ENCRYPTION_KEYS")
+	}
+
+	switch store := c.AuditStore(); store {
+	case "supabase":
+		v.required("SUPABASE_URL", c.Supabase.URL, "with AUDIT_LOG_STORE=supabase")
+	case "postgres", "sqlite":
+		v.check(c.Storage.Driver == store, "AUDIT_LOG_STORE: %s requires STORAGE_DRIVER=%s", store, store)
+	case "file":
+		v.required("AUDIT_LOG_PATH", c.Audit.Path, "with AUDIT_LOG_STORE=file")
+	default:
+		// An invalid storage driver has already been reported
+		if c.Audit.Store != "" {
+			v.oneOf("AUDIT_LOG_STORE", store, "supabase", "postgres", "sqlite", "file")
+		}
+	}
+
+	v.oneOf("RATE_LIMIT_STORE", c.RateLimits.Store, "memory", "postgres")
+	if c.RateLimits.Store == "postgres" {
+		v.check(c.Storage.Driver == "postgres", "RATE_LIMIT_STORE: postgres requires STORAGE_DRIVER=postgres")
+	}
+
+	v.check(c.Quota.FreeDailyMessages >= 0, "FREE_PLAN_DAILY_MESSAGES: can't be negative")
+	v.check(c.Retention.Days > 0, "RETENTION_DAYS: must be positive")
+	v.check(c.Retention.Interval > 0, "RETENTION_INTERVAL: must be positive")
+
+	v.check(c.Supabase.URL != "" || c.Guest.TokenSecret != "", "either SUPABASE_URL or GUEST_TOKEN_SECRET must be set")
+	v.check(c.Guest.TokenTTL > 0, "GUEST_TOKEN_TTL: must be positive")
+
+	v.required("DELETION_RECEIPT_SECRET", c.Account.DeletionReceiptSecret, "")
+
+	switch store := c.ExportStore(); store {
+	case "memory":
+		v.check(c.Export.MaxTotalSize > 0, "EXPORT_MAX_TOTAL_SIZE_MB: must be positive")
+		v.check(c.Export.MaxSize <= c.Export.MaxTotalSize, "EXPORT_MAX_SIZE_MB: can't exceed EXPORT_MAX_TOTAL_SIZE_MB")
+	case "supabase":
+		v.required("SUPABASE_URL", c.Supabase.URL, "with EXPORT_STORE=supabase")
+	case "postgres", "sqlite":
+		v.check(c.Storage.Driver == store, "EXPORT_STORE: %s requires STORAGE_DRIVER=%s", store, store)
+	default:
+		// An invalid storage driver has already been reported
+		if c.Export.Store != "" {
+			v.oneOf("EXPORT_STORE", store, "memory", "supabase", "postgres", "sqlite")
+		}
+	}
+	v.check(c.Export.MaxPerUser > 0, "EXPORT_MAX_PER_USER: must be positive")
+	v.check(c.Export.MaxSize > 0, "EXPORT_MAX_SIZE_MB: must be positive")
+
+	v.check(c.Shutdown.ReadinessDelay >= 0, "SHUTDOWN_READINESS_DELAY: can't be negative")
+	v.check(c.Shutdown.DrainTimeout > 0, "SHUTDOWN_DRAIN_TIMEOUT: must be positive")
+	v.check(c.Shutdown.CancelTimeout > 0, "SHUTDOWN_CANCEL_TIMEOUT: must be positive")
+
+	return errors.Join(v.errs...)
+}
+
+// AuditStore returns where the audit log is stored, alongside the user data
+// unless configured otherwise.
+func (c *Config) AuditStore() string {
+	if c.Audit.Store == "" {
+		return c.Storage.Driver
+	}
+	return c.Audit.Store
+}
+
+// ExportStore returns where the data exports are stored, alongside the user
+// data unless configured otherwise.
+func (c *Config) ExportStore() string {
+	if c.Export.Store == "" {
+		return c.Storage.Driver
+	}
+	return c.Export.Store
+}
+
+// SamplingRates returns the log sampling rates, both zero when sampling is
+// off.
+func (l Log) SamplingRates() (initial, thereafter int, err error) {
+	if l.Sampling == "off" {
+		return 0, 0, nil
+	}
+	if _, err := fmt.Sscanf(l.Sampling, "%d/%d", &initial, &thereafter); err != nil {
+		return 0, 0, fmt.Errorf("invalid sampling %q: expected <initial>/<thereafter> or off", l.Sampling)
+	}
+	if initial <= 0 || thereafter <= 0 {
+		return 0, 0, fmt.Errorf("invalid sampling %q: rates must be positive", l.Sampling)
+	}
+	return initial, thereafter, nil
+}
+
+// Secrets returns the values of the secret settings that are set, so that
+// they can be masked in the logs.
+func (c *Config) Secrets() []string {
+	var secrets []string
+	for _, f := range fields(c) {
+		if f.secret && f.value.String() != "" {
+			secrets = append(secrets, f.value.String())
+		}
+	}
+	// Each key is a secret on its own, as it may be logged without the others
+	for _, key := range splitList(c.Encryption.Keys) {
+		if _, secret, ok := strings.Cut(key, ":"); ok {
+			secrets = append(secrets, secret)
+		}
+	}
+	return secrets
+}
+
+// validator collects the errors of a validation.
+type validator struct {
+	errs []error
+}
+
+func (v *validator) add(format string, args ...any) {
+	v.errs = append(v.errs, fmt.Errorf(format, args...))
+}
+
+func (v *validator) check(ok bool, format string, args ...any) {
+	if !ok {
+		v.add(format, args...)
+	}
+}
+
+func (v *validator) required(env, value, condition string) {
+	if value != "" {
+		return
+	}
+	if condition == "" {
+		v.add("%s: required", env)
+		return
+	}
+	v.add("%s: required %s", env, condition)
+}
+
+func (v *validator) oneOf(env, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.add("%s: must be one of %s, got %q", env, strings.Join(allowed, ", "), value)
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// DeletionReceipt is given to a user once all their data has been erased. It
+// is signed so the user can later prove the deletion took place.
+type DeletionReceipt struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	DeletedAt time.Time `json:"deletedAt"`
+	// Erased lists the kinds of records that were deleted.
+	Erased []string `json:"erased"`
+	// Retained lists the kinds of records that were kept, e.g. for
+	// accountability.
+	Retained  []string `json:"retained"`
+	Signature string   `json:"signature"`
+}
+
+// Kinds of records listed in a deletion receipt.
+const (
+	RecordConversation = "conversation"
+	RecordUserData     = "user_data"
+	RecordUsage        = "usage"
+	RecordExports      = "exports"
+	RecordSafetyEvents = "safety_events"
+	RecordIdentity     = "identity"
+	RecordAuditLog     = "audit_log"
+)
+
+// TombstoneKey returns the key the tombstone of a deleted user is stored
+// under. It is a hash of the user id, so tombstones don't keep identifiers of
+// deleted users around.
+func TombstoneKey(userID string) string {
+	sum := sha256.Sum256([]byte(userID))
+	return hex.EncodeToString(sum[:])
+}
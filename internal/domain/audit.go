@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Actions recorded in the audit log.
+const (
+	AuditActionThreadCreate  = "thread.create"
+	AuditActionThreadReset   = "thread.reset"
+	AuditActionThreadPurge   = "thread.purge"
+	AuditActionRetentionSet  = "retention.set"
+	AuditActionDataExport    = "data.export"
+	AuditActionAccountDelete = "account.delete"
+	AuditActionUserLookup    = "admin.user_lookup"
+	AuditActionPlanChange    = "admin.plan_change"
+	AuditActionUsageReset    = "admin.usage_reset"
+	AuditActionUsageView     = "admin.usage_view"
+	AuditActionSafetyView    = "admin.safety_events_view"
+	AuditActionAuditLogQuery = "admin.audit_log_query"
+	AuditActionLogLevelSet   = "admin.log_level_set"
+)
+
+// Outcomes of an audited action.
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// AuditEvent records who did what to which user's data.
+type AuditEvent struct {
+	ID        string `json:"id"`
+	RequestID string `json:"request_id,omitempty"`
+	// Actor is the user id of whoever performed the action, or a fixed
+	// identifier for non user actors such as the admin API key.
+	Actor        string    `json:"actor"`
+	Action       string    `json:"action"`
+	TargetUserID string    `json:"target_user_id,omitempty"`
+	Outcome      string    `json:"outcome"`
+	Details      string    `json:"details,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AuditQuery filters the events returned from the audit log. Empty fields
+// are not filtered on.
+type AuditQuery struct {
+	Actor        string
+	Action       string
+	TargetUserID string
+	Since        time.Time
+	Until        time.Time
+	Limit        int
+}
+
+// Matches reports whether the event passes the filters of the query.
+func (q AuditQuery) Matches(e *AuditEvent) bool {
+	return (q.Actor == "" || e.Actor == q.Actor) &&
+		(q.Action == "" || e.Action == q.Action) &&
+		(q.TargetUserID == "" || e.TargetUserID == q.TargetUserID) &&
+		(q.Since.IsZero() || !e.CreatedAt.Before(q.Since)) &&
+		(q.Until.IsZero() || !e.CreatedAt.After(q.Until))
+}
+
+// NewAuditEvent creates an event for action with an outcome derived from err,
+// tagged with the request id carried by ctx.
+func NewAuditEvent(ctx context.Context, actor, action, targetUserID string, err error) *AuditEvent {
+	e := &AuditEvent{
+		RequestID:    RequestID(ctx),
+		Actor:        actor,
+		Action:       action,
+		TargetUserID: targetUserID,
+		Outcome:      AuditOutcomeSuccess,
+	}
+	if err != nil {
+		e.Outcome = AuditOutcomeFailure
+		e.Details = err.Error()
+	}
+	return e
+}
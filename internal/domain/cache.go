@@ -0,0 +1,22 @@
+package domain
+
+// CacheStats counts the outcomes of the lookups made through a cache.
+type CacheStats struct {
+	Hits uint64 `json:"hits"`
+	// NegativeHits are hits on a cached "not found".
+	NegativeHits  uint64 `json:"negativeHits"`
+	Misses        uint64 `json:"misses"`
+	Invalidations uint64 `json:"invalidations"`
+	// Errors counts the failed cache operations, after which the lookup falls
+	// back to the cached store.
+	Errors uint64 `json:"errors"`
+}
+
+// HitRatio returns the share of lookups answered from the cache.
+func (s CacheStats) HitRatio() float64 {
+	total := s.Hits + s.NegativeHits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits+s.NegativeHits) / float64(total)
+}
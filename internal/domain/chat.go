@@ -1,6 +1,9 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 // ChatMessage represents a message sent by the end user.
 type ChatMessage struct {
@@ -21,4 +24,21 @@ func (chM *ChatMessage) Validate() error {
 type ChatResponse struct {
 	Content  string `json:"content"`
 	ThreadID string `json:"threadId"`
+	// Quota is the quota status of the user after processing the message. It is
+	// not part of the response body, the HTTP layer exposes it as headers.
+	Quota *QuotaStatus `json:"-"`
 }
+
+// ThreadMessage is a message stored in a conversation thread.
+type ThreadMessage struct {
+	ID        string    `json:"id"`
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Roles of the messages in a conversation thread.
+const (
+	MessageRoleUser      = "user"
+	MessageRoleAssistant = "assistant"
+)
@@ -0,0 +1,87 @@
+package domain
+
+import "context"
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	userIDKey
+	threadIDKey
+	runIDKey
+)
+
+// WithRequestID returns a copy of ctx carrying the id of the request being
+// served.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the request id carried by ctx, or an empty string.
+func RequestID(ctx context.Context) string {
+	return stringValue(ctx, requestIDKey)
+}
+
+// WithUserID returns a copy of ctx carrying the id of the user the work is
+// done for.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserID returns the user id carried by ctx, or an empty string.
+func UserID(ctx context.Context) string {
+	return stringValue(ctx, userIDKey)
+}
+
+// WithThreadID returns a copy of ctx carrying the id of the conversation
+// thread the work is done on.
+func WithThreadID(ctx context.Context, threadID string) context.Context {
+	return context.WithValue(ctx, threadIDKey, threadID)
+}
+
+// ThreadID returns the thread id carried by ctx, or an empty string.
+func ThreadID(ctx context.Context) string {
+	return stringValue(ctx, threadIDKey)
+}
+
+// WithRunID returns a copy of ctx carrying the id of the assistant run the
+// work is done for.
+func WithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, runIDKey, runID)
+}
+
+// RunID returns the run id carried by ctx, or an empty string.
+func RunID(ctx context.Context) string {
+	return stringValue(ctx, runIDKey)
+}
+
+// LogFields returns the ids carried by ctx as key/value pairs, to be added to
+// every log line written while serving a request.
+func LogFields(ctx context.Context) []interface{} {
+	if ctx == nil {
+		return nil
+	}
+	var fields []interface{}
+	for _, f := range []struct {
+		name string
+		key  contextKey
+	}{
+		{"request_id", requestIDKey},
+		{"user_id", userIDKey},
+		{"thread_id", threadIDKey},
+		{"run_id", runIDKey},
+	} {
+		if v := stringValue(ctx, f.key); v != "" {
+			fields = append(fields, f.name, v)
+		}
+	}
+	return fields
+}
+
+func stringValue(ctx context.Context, key contextKey) string {
+	if ctx == nil {
+		return ""
+	}
+	v, _ := ctx.Value(key).(string)
+	return v
+}
@@ -3,3 +3,142 @@ package domain
 import "errors"
 
 var ErrNotFound = errors.New("not found")
+
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+var ErrUnauthorized = errors.New("unauthorized")
+
+// ErrForbidden is returned when an authenticated caller is not allowed to
+// perform an operation.
+var ErrForbidden = errors.New("forbidden")
+
+// ErrInvalidInput is returned when the input of an operation is malformed or
+// out of the accepted range.
+var ErrInvalidInput = errors.New("invalid input")
+
+// ErrRateLimited is returned when a caller sends requests faster than allowed.
+var ErrRateLimited = errors.New("rate limited")
+
+// ErrAccountDeleted is returned when operating on the data of a user whose
+// account has been deleted.
+var ErrAccountDeleted = errors.New("account deleted")
+
+// ErrConflict is returned when a write conflicts with the stored data, such as
+// inserting a record that already exists.
+var ErrConflict = errors.New("conflict")
+
+// ErrUnavailable is returned when a dependency can't serve the request right
+// now. Retrying later may succeed.
+var ErrUnavailable = errors.New("unavailable")
+
+// ErrorKind describes how the errors matching one of the errors above are
+// reported to users.
+type ErrorKind struct {
+	// Err is the error matched with errors.Is.
+	Err error
+	// Code identifies the kind in API responses. It is stable, clients may
+	// rely on it.
+	Code string
+	// Message is shown to users when the error carries no message of its own.
+	Message string
+	// Retryable reports whether sending the same request later may succeed.
+	Retryable bool
+}
+
+// ErrorKindInternal is the kind of the errors that wrap no Error of a kind of
+// the catalog. Their details are never shown to users.
+var ErrorKindInternal = ErrorKind{Code: "internal", Message: "Oops! Something went wrong"}
+
+// ErrorKinds is the catalog of the errors reported to users. An Error whose
+// kind matches several kinds is reported as the first one.
+var ErrorKinds = []ErrorKind{
+	{Err: ErrInvalidInput, Code: "invalid_input", Message: "Invalid request"},
+	{Err: ErrUnauthorized, Code: "unauthorized", Message: "Authentication required"},
+	{Err: ErrForbidden, Code: "forbidden", Message: "Not allowed"},
+	{Err: ErrNotFound, Code: "not_found", Message: "Not found"},
+	{Err: ErrConflict, Code: "conflict", Message: "The request conflicted with another one, please retry", Retryable: true},
+	{Err: ErrAccountDeleted, Code: "account_deleted", Message: "Account has been deleted"},
+	{Err: ErrQuotaExceeded, Code: "quota_exceeded", Message: "Daily message quota exceeded", Retryable: true},
+	{Err: ErrRateLimited, Code: "rate_limited", Message: "Too many requests", Retryable: true},
+	{Err: ErrUnavailable, Code: "unavailable", Message: "Service unavailable, please retry", Retryable: true},
+}
+
+// KindOf returns the kind of the Error err wraps, or ErrorKindInternal if it
+// wraps none. The errors of the catalog returned by dependencies, such as the
+// ErrNotFound of an upstream 404, say nothing about the request, so only the
+// kinds set deliberately with an Error are reported.
+func KindOf(err error) ErrorKind {
+	var domainErr *Error
+	if !errors.As(err, &domainErr) {
+		return ErrorKindInternal
+	}
+	for _, kind := range ErrorKinds {
+		if errors.Is(domainErr.Kind, kind.Err) {
+			return kind
+		}
+	}
+	return ErrorKindInternal
+}
+
+// Error is an error of a kind of the catalog with a message that is safe to
+// show to users, apart from the cause of the error which is only logged.
+type Error struct {
+	// Kind is one of the errors of the catalog, such as ErrNotFound.
+	Kind error
+	// Message is shown to users. The message of the kind is shown when empty.
+	Message string
+	// Cause is the underlying error, if any.
+	Cause error
+}
+
+// NewError creates an error of the given kind with a user-safe message.
+func NewError(kind error, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// WrapError creates an error of the given kind with a user-safe message,
+// caused by err.
+func WrapError(kind error, message string, err error) *Error {
+	return &Error{Kind: kind, Message: message, Cause: err}
+}
+
+// WrapUnavailable returns err as an Error of kind ErrUnavailable if a
+// dependency reported that it can't serve the request right now, so that the
+// client is told to retry. Errors that already wrap an Error, and the other
+// failures, are returned unchanged. Services call it on the failures of their
+// dependencies before returning them.
+func WrapUnavailable(err error) error {
+	var domainErr *Error
+	if errors.Is(err, ErrUnavailable) && !errors.As(err, &domainErr) {
+		return WrapError(ErrUnavailable, "", err)
+	}
+	return err
+}
+
+func (e *Error) Error() string {
+	msg := e.Kind.Error()
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Cause != nil {
+		msg += ": " + e.Cause.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Cause == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Cause}
+}
+
+// UserMessage returns the message of err that is safe to show to users: the
+// message of the Error it wraps, or the message of its kind.
+func UserMessage(err error) string {
+	var domainErr *Error
+	if errors.As(err, &domainErr) && domainErr.Message != "" {
+		return domainErr.Message
+	}
+	return KindOf(err).Message
+}
@@ -0,0 +1,34 @@
+package domain
+
+import "time"
+
+// ExportStatus is the state of the generation of a data export.
+type ExportStatus string
+
+const (
+	ExportStatusPending ExportStatus = "pending"
+	ExportStatusReady   ExportStatus = "ready"
+	ExportStatusFailed  ExportStatus = "failed"
+)
+
+// DataExport is an archive with everything stored about a user, generated on
+// their request.
+type DataExport struct {
+	ID          string       `json:"id"`
+	UserID      string       `json:"userId"`
+	Status      ExportStatus `json:"status"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	CompletedAt *time.Time   `json:"completedAt,omitempty"`
+	Error       string       `json:"error,omitempty"`
+	// Archive is the zip file with the exported data, only set once ready.
+	Archive []byte `json:"-"`
+}
+
+// UserDataExport is the content of a data export.
+type UserDataExport struct {
+	UserID      string          `json:"userId"`
+	GeneratedAt time.Time       `json:"generatedAt"`
+	UserData    *UserData       `json:"userData"`
+	Messages    []ThreadMessage `json:"messages"`
+	AuditEvents []AuditEvent    `json:"auditEvents"`
+}
@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// Statuses of a dependency.
+const (
+	DependencyStatusUp   = "up"
+	DependencyStatusDown = "down"
+)
+
+// DependencyStatus is the outcome of the last check of a dependency.
+type DependencyStatus struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	LatencyMs int64     `json:"latencyMs"`
+	CheckedAt time.Time `json:"checkedAt"`
+	// Error is why the check failed. It may contain internal details, so it
+	// is only logged and not served.
+	Error error `json:"-"`
+}
+
+// Up reports whether the dependency passed its last check.
+func (s DependencyStatus) Up() bool {
+	return s.Status == DependencyStatusUp
+}
+
+// SystemStatus describes the running instance of the service.
+type SystemStatus struct {
+	Version       string             `json:"version"`
+	Commit        string             `json:"commit"`
+	StartedAt     time.Time          `json:"startedAt"`
+	UptimeSeconds int64              `json:"uptimeSeconds"`
+	Ready         bool               `json:"ready"`
+	Dependencies  []DependencyStatus `json:"dependencies"`
+}
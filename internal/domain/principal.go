@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// Principal is the authenticated caller of the API, as asserted by a verified
+// access token.
+type Principal struct {
+	UserID string
+	Email  string
+	// Role is the database role of the token, e.g. "authenticated".
+	Role string
+	// AppRoles are the application roles granted to the user, e.g. "admin".
+	AppRoles    []string
+	SessionID   string
+	IsAnonymous bool
+	ExpiresAt   time.Time
+	// QuotaKey is the key the messages of the principal are counted under.
+	// Guest sessions created by the same client share it, so that creating
+	// sessions doesn't reset the quota. Empty means the UserID.
+	QuotaKey string
+}
+
+// QuotaID returns the key the messages of the principal are counted under.
+func (p *Principal) QuotaID() string {
+	if p.QuotaKey != "" {
+		return p.QuotaKey
+	}
+	return p.UserID
+}
+
+// HasRole reports whether the principal has been granted the application role.
+func (p *Principal) HasRole(role string) bool {
+	return p != nil && role != "" && slices.Contains(p.AppRoles, role)
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// QuotaStatus describes the message quota of a user for the current period.
+// A Limit of -1 means the user has no limit.
+type QuotaStatus struct {
+	Plan      Plan      `json:"plan"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"resetAt"`
+}
+
+// Unlimited reports whether the quota has no upper bound.
+func (q *QuotaStatus) Unlimited() bool {
+	return q.Limit < 0
+}
+
+// QuotaExceededError is returned when a user tries to send a message after
+// having used up their quota for the current period.
+type QuotaExceededError struct {
+	Status QuotaStatus
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded for plan %s: %d messages per day", e.Status.Plan, e.Status.Limit)
+}
+
+func (e *QuotaExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// UsageSummary aggregates the messages sent by all users on a given day.
+type UsageSummary struct {
+	Day            string       `json:"day"`
+	TotalMessages  int          `json:"totalMessages"`
+	ActiveUsers    int          `json:"activeUsers"`
+	MessagesByPlan map[Plan]int `json:"messagesByPlan"`
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit allows Limit requests per Period. Requests are allowed in bursts of
+// up to Limit, with capacity being refilled evenly over the Period.
+type RateLimit struct {
+	Limit  int
+	Period time.Duration
+}
+
+// ParseRateLimit parses a rate limit in the form "<limit>/<period>", e.g.
+// "10/1m" for ten requests per minute.
+func ParseRateLimit(s string) (RateLimit, error) {
+	limit, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: expected <limit>/<period>", s)
+	}
+	l, err := strconv.Atoi(strings.TrimSpace(limit))
+	if err != nil || l <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: limit must be a positive integer", s)
+	}
+	p, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || p <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: period must be a positive duration", s)
+	}
+	return RateLimit{Limit: l, Period: p}, nil
+}
+
+// RateLimitResult is the outcome of taking a token from a rate limit bucket.
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next request would be allowed. It is
+	// only set when the request was not allowed.
+	RetryAfter time.Duration
+}
+
+// UnmarshalText parses a rate limit in the form accepted by ParseRateLimit.
+func (r *RateLimit) UnmarshalText(text []byte) error {
+	limit, err := ParseRateLimit(string(text))
+	if err != nil {
+		return err
+	}
+	*r = limit
+	return nil
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// PurgeReport summarizes a run of the data retention purge.
+type PurgeReport struct {
+	DryRun    bool      `json:"dryRun"`
+	StartedAt time.Time `json:"startedAt"`
+	Cutoff    time.Time `json:"cutoff"`
+	// Scanned is the number of inactive conversations found.
+	Scanned int `json:"scanned"`
+	Purged  int `json:"purged"`
+	// Retained is the number of conversations kept because of a per-user
+	// retention override.
+	Retained int `json:"retained"`
+	// Skipped is the number of conversations left alone because they changed
+	// since they were found, e.g. the user became active again.
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
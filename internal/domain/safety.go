@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// SafetyEvent is raised when a conversation needs the attention of a human,
+// e.g. because the user may be at risk.
+type SafetyEvent struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	ThreadID  string    `json:"threadId,omitempty"`
+	Category  string    `json:"category"`
+	Details   string    `json:"details,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// The categories of the safety events raised on messages.
+const (
+	SafetyCategorySelfHarm     = "self_harm"
+	SafetyCategoryHarmToOthers = "harm_to_others"
+)
+
+// SafetyFlag is the outcome of classifying a message that needs the attention
+// of a human.
+type SafetyFlag struct {
+	Category string
+	// Reason describes why the message was flagged, without quoting it.
+	Reason string
+}
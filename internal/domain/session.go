@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// RoleGuest is the role of principals authenticated with a guest session token.
+const RoleGuest = "guest"
+
+// GuestSession is a temporary identity that lets users chat before signing up.
+type GuestSession struct {
+	UserID    string    `json:"userId"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
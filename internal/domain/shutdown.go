@@ -0,0 +1,24 @@
+package domain
+
+// RunRef identifies a run of the AI service.
+type RunRef struct {
+	ThreadID string `json:"threadId"`
+	RunID    string `json:"runId"`
+}
+
+// ShutdownReport describes the work a shutdown did not let finish.
+type ShutdownReport struct {
+	// AbandonedMessages is how many messages were still being processed when
+	// the drain deadline passed.
+	AbandonedMessages int
+	// CancelledRuns are the runs of those messages that were cancelled on the
+	// AI service.
+	CancelledRuns []RunRef
+	// Errors are the steps of the shutdown that failed.
+	Errors []error
+}
+
+// Clean reports whether all the work was let finish.
+func (r *ShutdownReport) Clean() bool {
+	return r.AbandonedMessages == 0 && len(r.CancelledRuns) == 0 && len(r.Errors) == 0
+}
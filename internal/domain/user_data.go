@@ -1,6 +1,89 @@
 package domain
 
+import "time"
+
+// Plan is the subscription tier of a user. It determines how many messages
+// the user is allowed to send.
+type Plan string
+
+const (
+	PlanFree    Plan = "free"
+	PlanPremium Plan = "premium"
+)
+
+func (p Plan) Valid() bool {
+	return p == PlanFree || p == PlanPremium
+}
+
 type UserData struct {
-	UserID   string  `json:"user_id"`
-	ThreadID *string `json:"thread_id"`
+	UserID       string     `json:"user_id"`
+	ThreadID     *string    `json:"thread_id"`
+	Plan         Plan       `json:"plan,omitempty"`
+	LastActiveAt *time.Time `json:"last_active_at,omitempty"`
+	// RetentionDays overrides the default retention of the conversation for
+	// users who opted into keeping it longer.
+	RetentionDays *int `json:"retention_days"`
+	// KeyID identifies the key the sensitive fields of the entry are
+	// encrypted with, if any. It is always sent, so that updating an entry
+	// with a plaintext thread id clears it.
+	KeyID *string `json:"key_id"`
+}
+
+// UserDataPatch changes some of the fields of a user_data entry. Nil fields
+// are left untouched, so a patch doesn't overwrite the fields changed
+// concurrently by another writer.
+type UserDataPatch struct {
+	ThreadID *string
+	// ClearThreadID unlinks the thread of the entry, clearing its key id too.
+	// It takes precedence over ThreadID.
+	ClearThreadID bool
+	LastActiveAt  *time.Time
+	Plan          *Plan
+	RetentionDays *int
+	// KeyID is written along with ThreadID, and cleared when nil. It is set by
+	// the storage encrypting the thread id.
+	KeyID *string
+	// If makes the patch conditional, so that a change based on an entry read
+	// earlier doesn't overwrite the changes made to it since. The patch
+	// applies unconditionally when nil.
+	If *UserDataCondition
+}
+
+// UserDataCondition holds the values a user_data entry must still hold for a
+// conditional patch to apply to it.
+type UserDataCondition struct {
+	// ThreadID is the thread id the entry must hold. It is compared in
+	// plaintext through the storage encrypting the thread id.
+	ThreadID string
+	// KeyID is the key the thread id must be encrypted with, nil meaning it
+	// must be stored in plaintext. It is set by the storage encrypting the
+	// thread id.
+	KeyID *string
+	// InactiveBefore requires the entry not to have been active since the
+	// given time, as for UserDataFilter. Not checked when zero.
+	InactiveBefore time.Time
+}
+
+// UserDataFilter selects user_data entries when listing them. Empty fields are
+// not filtered on.
+type UserDataFilter struct {
+	// InactiveBefore selects the entries with a conversation thread that has
+	// not been active since the given time.
+	InactiveBefore time.Time
+	// AfterUserID selects the entries whose user id sorts after it, for
+	// paginating through results ordered by user id.
+	AfterUserID string
+	// NotEncryptedWith selects the entries with a conversation thread that is
+	// not encrypted with the given key id.
+	NotEncryptedWith string
+	Limit            int
+}
+
+// EffectivePlan returns the plan of the user, defaulting to PlanFree for users
+// without one assigned.
+func (u *UserData) EffectivePlan() Plan {
+	if u == nil || u.Plan == "" {
+		return PlanFree
+	}
+	return u.Plan
 }
@@ -0,0 +1,32 @@
+package ports
+
+import (
+	"context"
+	"stress-relief-ai-chat-back/internal/domain"
+)
+
+// AccountService erases users and all their data.
+type AccountService interface {
+	// DeleteAccount erases all the data of the user, including their identity
+	// in the identity provider.
+	DeleteAccount(ctx context.Context, userID string) (*domain.DeletionReceipt, error)
+	// PurgeUserData erases all the data of a user whose identity has already
+	// been deleted from the identity provider.
+	PurgeUserData(ctx context.Context, userID string) (*domain.DeletionReceipt, error)
+	// VerifyReceipt reports whether the receipt was issued by this service.
+	VerifyReceipt(ctx context.Context, receipt *domain.DeletionReceipt) bool
+}
+
+// TombstoneRepository remembers deleted users, so requests that were in flight
+// during a deletion can't bring their data back.
+type TombstoneRepository interface {
+	Add(ctx context.Context, key string) error
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// IdentityProvider manages the identities users authenticate with.
+type IdentityProvider interface {
+	// DeleteUser deletes the identity of the user. It returns an error
+	// wrapping domain.ErrNotFound if there is no such user.
+	DeleteUser(ctx context.Context, userID string) error
+}
@@ -0,0 +1,27 @@
+package ports
+
+import (
+	"context"
+	"stress-relief-ai-chat-back/internal/domain"
+)
+
+// AdminService exposes the operations used to run the service without touching
+// the database directly.
+type AdminService interface {
+	GetUser(ctx context.Context, userID string) (*domain.UserData, error)
+	// ResetThread deletes the conversation thread of the user, so the next
+	// message starts a new one.
+	ResetThread(ctx context.Context, userID string) (*domain.UserData, error)
+	SetPlan(ctx context.Context, userID string, plan domain.Plan) (*domain.UserData, error)
+	ResetUsage(ctx context.Context, userID string) error
+	Usage(ctx context.Context) (*domain.UsageSummary, error)
+	SafetyEvents(ctx context.Context, limit int) ([]domain.SafetyEvent, error)
+}
+
+// SafetyEventRepository stores the safety events raised on conversations.
+type SafetyEventRepository interface {
+	Record(ctx context.Context, event *domain.SafetyEvent) error
+	// List returns the most recent events first.
+	List(ctx context.Context, limit int) ([]domain.SafetyEvent, error)
+	DeleteByUser(ctx context.Context, userID string) error
+}
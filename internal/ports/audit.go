@@ -0,0 +1,16 @@
+package ports
+
+import (
+	"context"
+	"stress-relief-ai-chat-back/internal/domain"
+)
+
+// AuditLogger is an append-only store of the sensitive operations performed on
+// user data.
+type AuditLogger interface {
+	// Record appends an event to the audit log, setting its ID and CreatedAt
+	// if empty.
+	Record(ctx context.Context, event *domain.AuditEvent) error
+	// Query returns the events matching q, most recent first.
+	Query(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEvent, error)
+}
@@ -1,7 +1,14 @@
 package ports
 
-import "stress-relief-ai-chat-back/internal/domain"
+import (
+	"context"
+	"stress-relief-ai-chat-back/internal/domain"
+)
 
+// AuthPort verifies access tokens presented by API clients.
 type AuthPort interface {
-	Authenticate(token string) (*domain.UserData, error)
+	// Authenticate verifies token and returns the principal it was issued to.
+	// It returns an error wrapping domain.ErrUnauthorized if the token is not
+	// valid.
+	Authenticate(ctx context.Context, token string) (*domain.Principal, error)
 }
@@ -0,0 +1,25 @@
+package ports
+
+import (
+	"context"
+	"stress-relief-ai-chat-back/internal/domain"
+	"time"
+)
+
+// Cache stores values for a limited time. Implementations backed by a shared
+// store keep instances consistent with each other, as an invalidation on one
+// instance is seen by all of them. Caches keep their own copy of the values,
+// so callers are free to modify the slices they pass and get.
+type Cache interface {
+	// Get returns the value stored at key, and whether there was one.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value at key for ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the value stored at key, if any.
+	Delete(ctx context.Context, key string) error
+}
+
+// CacheStatsReporter reports how well a cache is doing.
+type CacheStatsReporter interface {
+	CacheStats() domain.CacheStats
+}
@@ -7,10 +7,31 @@ import (
 
 // ChatService exposes the services provided by this application around chat.
 type ChatService interface {
-	ProcessMessage(ctx context.Context, message *domain.ChatMessage, userID string) (*domain.ChatResponse, error)
+	ProcessMessage(ctx context.Context, message *domain.ChatMessage, principal *domain.Principal) (*domain.ChatResponse, error)
+	// Drain makes ProcessMessage reject new messages with an error wrapping
+	// domain.ErrUnavailable, and waits for the messages being processed to be
+	// answered, until ctx is done. It returns how many were still being
+	// processed.
+	Drain(ctx context.Context) int
 }
 
 // ChatHandler is an interface for handling chat messages against an AI service.
 type ChatHandler interface {
 	ProcessMessage(ctx context.Context, message *domain.ChatMessage, threadID *string) (*domain.ChatResponse, error)
+	// ListMessages returns all the messages of a thread, oldest first.
+	ListMessages(ctx context.Context, threadID string) ([]domain.ThreadMessage, error)
+	// AppendMessages adds messages to a thread without running the assistant.
+	AppendMessages(ctx context.Context, threadID string, messages []domain.ThreadMessage) error
+	// DeleteThread deletes a thread and all its messages. It returns an error
+	// wrapping domain.ErrNotFound if the thread does not exist.
+	DeleteThread(ctx context.Context, threadID string) error
+}
+
+// RunCanceller is implemented by the chat handlers whose answers are generated
+// by runs on the AI service, which go on when the request waiting for them is
+// abandoned.
+type RunCanceller interface {
+	// CancelActiveRuns cancels the runs in progress. It returns the runs that
+	// were cancelled, and an error for those that could not be.
+	CancelActiveRuns(ctx context.Context) ([]domain.RunRef, error)
 }
@@ -0,0 +1,40 @@
+package ports
+
+import (
+	"context"
+	"stress-relief-ai-chat-back/internal/domain"
+	"time"
+)
+
+// ExportService generates archives with all the data stored about a user.
+type ExportService interface {
+	// Start begins generating an export for the user and waits up to wait for
+	// it to complete, so small exports can be returned right away. The export
+	// is returned in whatever state it is in once wait has elapsed.
+	Start(ctx context.Context, userID string, wait time.Duration) (*domain.DataExport, error)
+	// Get returns an export previously started by the user. It returns
+	// domain.ErrNotFound if there is no such export or it has expired.
+	Get(ctx context.Context, userID, exportID string) (*domain.DataExport, error)
+	// Discard drops all the exports of the user.
+	Discard(ctx context.Context, userID string) error
+}
+
+// ExportRepository stores the data exports, so that any instance sharing the
+// store can serve the exports started on another one.
+type ExportRepository interface {
+	Insert(ctx context.Context, export *domain.DataExport) error
+	// Update replaces the stored export with the same id. It returns an error
+	// wrapping domain.ErrNotFound if there is none, e.g. because the export
+	// was discarded while it was generated.
+	Update(ctx context.Context, export *domain.DataExport) error
+	// Get returns the export with its archive. It returns an error wrapping
+	// domain.ErrNotFound if there is none.
+	Get(ctx context.Context, exportID string) (*domain.DataExport, error)
+	// ListByUser returns the exports of the user, oldest first, without their
+	// archives.
+	ListByUser(ctx context.Context, userID string) ([]domain.DataExport, error)
+	Delete(ctx context.Context, exportID string) error
+	DeleteByUser(ctx context.Context, userID string) error
+	// DeleteCreatedBefore drops the exports created before t.
+	DeleteCreatedBefore(ctx context.Context, t time.Time) error
+}
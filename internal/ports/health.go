@@ -0,0 +1,27 @@
+package ports
+
+import (
+	"context"
+	"stress-relief-ai-chat-back/internal/domain"
+)
+
+// HealthChecker checks that a dependency of the service can be used, such as
+// a database or the LLM provider.
+type HealthChecker interface {
+	// Name identifies the dependency in the status of the service.
+	Name() string
+	// Check returns an error if the dependency can't be used.
+	Check(ctx context.Context) error
+}
+
+// HealthService reports whether the service is able to serve requests.
+type HealthService interface {
+	// Ready reports whether the service is not shutting down and all its
+	// dependencies are up.
+	Ready(ctx context.Context) bool
+	// Status describes the running instance and its dependencies.
+	Status(ctx context.Context) *domain.SystemStatus
+	// ShutDown makes the service report that it is not ready anymore, so
+	// that no new traffic is routed to it.
+	ShutDown()
+}
@@ -11,3 +11,12 @@ type Logger interface {
 	Error(ctx context.Context, msg string, args ...interface{})
 	Fatal(ctx context.Context, msg string, args ...interface{})
 }
+
+// LogLevelController changes how verbose a logger is at runtime.
+type LogLevelController interface {
+	// Level returns the minimum level currently logged.
+	Level() string
+	// SetLevel changes the minimum level logged. It returns an error if the
+	// level is unknown.
+	SetLevel(level string) error
+}
@@ -0,0 +1,26 @@
+package ports
+
+import "time"
+
+// Metrics records measurements of the application. Implementations must be
+// safe for concurrent use.
+type Metrics interface {
+	// ObserveHTTPRequest records a served request. route is the route
+	// template, such as /api/admin/users/:userID, to keep the cardinality low.
+	ObserveHTTPRequest(method, route string, status int, duration time.Duration)
+	// ObserveMessage records the outcome of a chat message, such as processed
+	// or quota_exceeded.
+	ObserveMessage(outcome string)
+	// ObserveLLMCall records a call to the LLM provider, by operation such as
+	// create_thread, create_message, create_run, retrieve_run or list_messages.
+	ObserveLLMCall(operation string, duration time.Duration, err error)
+	// ObserveRun records a finished assistant run: its terminal status and how
+	// many times it was polled.
+	ObserveRun(status string, polls int)
+	// AddTokens records the tokens used by the LLM, by kind: prompt or
+	// completion.
+	AddTokens(kind string, tokens int)
+	// ObserveStorageCall records a call to a storage backend, by backend such
+	// as supabase and operation.
+	ObserveStorageCall(backend, operation string, duration time.Duration, err error)
+}
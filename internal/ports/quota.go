@@ -0,0 +1,40 @@
+package ports
+
+import (
+	"context"
+	"stress-relief-ai-chat-back/internal/domain"
+)
+
+// QuotaService keeps track of how many messages each user has sent and
+// enforces the limits of their subscription plan.
+type QuotaService interface {
+	// Consume counts one message against the quota of the user. It returns a
+	// *domain.QuotaExceededError if the user has no messages left.
+	Consume(ctx context.Context, userID string, plan domain.Plan) (*domain.QuotaStatus, error)
+	// Refund gives back a message previously taken with Consume, e.g. when the
+	// message could not be processed.
+	Refund(ctx context.Context, userID string)
+	// ResetUsage clears the messages counted for the user in the current period.
+	ResetUsage(ctx context.Context, userID string) error
+	// Usage returns the aggregate usage of all users in the current period.
+	Usage(ctx context.Context) (*domain.UsageSummary, error)
+	// SetPlan changes the subscription plan of the user.
+	SetPlan(ctx context.Context, userID string, plan domain.Plan) (*domain.UserData, error)
+}
+
+// UsageRepository stores how many messages each user sent on their last active
+// day, so that quotas hold across restarts and replicas. Days are UTC dates
+// in the form 2006-01-02.
+type UsageRepository interface {
+	// Increment counts one message of the user on day, unless limit messages
+	// were already counted on that day. Counts of previous days are dropped. A
+	// negative limit means no limit. It returns the count after the message
+	// and whether the message was counted.
+	Increment(ctx context.Context, userID, day string, plan domain.Plan, limit int) (int, bool, error)
+	// Decrement uncounts one message of the user on day, if any.
+	Decrement(ctx context.Context, userID, day string) error
+	// Delete drops the count of the user.
+	Delete(ctx context.Context, userID string) error
+	// Summary aggregates the messages counted on day.
+	Summary(ctx context.Context, day string) (*domain.UsageSummary, error)
+}
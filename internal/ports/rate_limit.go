@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+	"stress-relief-ai-chat-back/internal/domain"
+)
+
+// RateLimitStore keeps the token buckets used for rate limiting. Implementations
+// backed by a shared store allow the limits to be enforced across instances.
+type RateLimitStore interface {
+	// Take removes a token from the bucket identified by key, creating it
+	// according to limit if it does not exist yet.
+	Take(ctx context.Context, key string, limit domain.RateLimit) (*domain.RateLimitResult, error)
+}
@@ -0,0 +1,19 @@
+package ports
+
+import (
+	"context"
+	"stress-relief-ai-chat-back/internal/domain"
+)
+
+// RetentionService enforces how long conversations are kept after the user
+// stops using them.
+type RetentionService interface {
+	// Run purges expired conversations periodically until ctx is done.
+	Run(ctx context.Context)
+	// Purge deletes the conversations that have been inactive for longer than
+	// their retention period.
+	Purge(ctx context.Context) (*domain.PurgeReport, error)
+	// SetUserRetention lets a user keep their conversation for longer than
+	// the default retention.
+	SetUserRetention(ctx context.Context, userID string, days int) (*domain.UserData, error)
+}
@@ -0,0 +1,13 @@
+package ports
+
+import (
+	"context"
+	"stress-relief-ai-chat-back/internal/domain"
+)
+
+// SafetyClassifier flags the messages that need the attention of a human.
+type SafetyClassifier interface {
+	// Classify returns the flag of the message, or nil if it raises no
+	// concern.
+	Classify(ctx context.Context, message string) (*domain.SafetyFlag, error)
+}
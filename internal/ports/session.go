@@ -0,0 +1,27 @@
+package ports
+
+import (
+	"context"
+	"stress-relief-ai-chat-back/internal/domain"
+	"time"
+)
+
+// SessionService manages guest sessions, which let users chat before signing up.
+type SessionService interface {
+	// CreateGuest starts a new guest session with a temporary user id, for
+	// the client identified by clientKey, such as its IP address. The guest
+	// sessions of a client share their message quota.
+	CreateGuest(ctx context.Context, clientKey string) (*domain.GuestSession, error)
+	// LinkGuest moves the conversation of the guest session identified by
+	// guestToken into the account of principal. The guest token is revoked.
+	LinkGuest(ctx context.Context, principal *domain.Principal, guestToken string) (*domain.UserData, error)
+}
+
+// GuestTokenProvider issues and verifies the tokens of guest sessions.
+type GuestTokenProvider interface {
+	AuthPort
+	// Issue issues a token for the guest userID, created by the client
+	// identified by clientKey. The principals of the tokens issued to the same
+	// client share their QuotaKey.
+	Issue(ctx context.Context, userID, clientKey string) (token string, expiresAt time.Time, err error)
+}
@@ -0,0 +1,21 @@
+package ports
+
+import (
+	"context"
+	"stress-relief-ai-chat-back/internal/domain"
+)
+
+// Server accepts the requests to the service.
+type Server interface {
+	// ShutdownWithContext stops accepting requests and waits for the ones in
+	// progress to be answered, until ctx is done.
+	ShutdownWithContext(ctx context.Context) error
+}
+
+// ShutdownService stops the service without dropping the work in progress
+// when it can be helped.
+type ShutdownService interface {
+	// Shutdown stops the service and reports the work that was abandoned.
+	// The service must not be used once it returns.
+	Shutdown(ctx context.Context) *domain.ShutdownReport
+}
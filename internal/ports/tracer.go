@@ -0,0 +1,37 @@
+package ports
+
+import "context"
+
+// Tracer records spans of the work done to serve a request. Implementations
+// must be safe for concurrent use.
+type Tracer interface {
+	// Start starts a span as a child of the span carried by ctx, if any, and
+	// returns a context carrying the new span. attrs are key value pairs, as
+	// in the Logger methods.
+	Start(ctx context.Context, name string, attrs ...any) (context.Context, Span)
+	// Extract returns a context carrying the remote span described by the
+	// trace context headers of carrier, if any.
+	Extract(ctx context.Context, carrier TraceCarrier) context.Context
+	// Inject writes the trace context headers of the span carried by ctx to
+	// carrier, so that the span can be continued by another service.
+	Inject(ctx context.Context, carrier TraceCarrier)
+}
+
+// Span is a unit of work recorded by a Tracer.
+type Span interface {
+	// SetName replaces the name the span was started with, for when a better
+	// one is only known once the work is done.
+	SetName(name string)
+	// SetAttributes adds key value pairs to the span.
+	SetAttributes(attrs ...any)
+	// End ends the span, marking it as failed when err is not nil.
+	End(err error)
+}
+
+// TraceCarrier holds the headers trace context is propagated in, such as the
+// headers of an HTTP request.
+type TraceCarrier interface {
+	Get(key string) string
+	Set(key, value string)
+	Keys() []string
+}
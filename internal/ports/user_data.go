@@ -8,4 +8,12 @@ import (
 type UserDataAPIHandler interface {
 	GetByID(ctx context.Context, userID string) (*domain.UserData, error)
 	Insert(ctx context.Context, userID string, userData *domain.UserData) error
+	Update(ctx context.Context, userID string, userData *domain.UserData) error
+	// Patch changes the fields set in patch, leaving the others untouched. It
+	// returns an error wrapping domain.ErrNotFound if there is no entry for
+	// the user, or none meeting the condition of the patch.
+	Patch(ctx context.Context, userID string, patch domain.UserDataPatch) error
+	Delete(ctx context.Context, userID string) error
+	// List returns the entries matching filter, ordered by user id.
+	List(ctx context.Context, filter domain.UserDataFilter) ([]domain.UserData, error)
 }
@@ -0,0 +1,51 @@
+-- Daily message counters of the quota service, see the usage adapter in
+-- internal/adapters/supabase/usage.
+create table if not exists message_usage (
+    user_id text primary key,
+    day     text not null,
+    plan    text not null,
+    count   integer not null
+);
+
+create index if not exists message_usage_day_idx on message_usage (day);
+
+-- Only the service key reads and writes the counters
+alter table message_usage enable row level security;
+
+-- Counts a message, restarting the count on a new day. Returns the count
+-- after the message, or null when the limit is reached. A negative limit
+-- means no limit.
+create or replace function consume_message(p_user_id text, p_day text, p_plan text, p_limit integer)
+returns integer
+language sql
+as $$
+    insert into message_usage (user_id, day, plan, count) values (p_user_id, p_day, p_plan, 1)
+    on conflict (user_id) do update set
+        count = case when message_usage.day = excluded.day then message_usage.count + 1 else 1 end,
+        day = excluded.day,
+        plan = excluded.plan
+    where message_usage.day <> excluded.day or p_limit < 0 or message_usage.count < p_limit
+    returning count;
+$$;
+
+create or replace function refund_message(p_user_id text, p_day text)
+returns void
+language sql
+as $$
+    update message_usage set count = count - 1
+    where user_id = p_user_id and day = p_day and count > 0;
+$$;
+
+create or replace function message_usage_summary(p_day text)
+returns table (plan text, users integer, messages integer)
+language sql
+stable
+as $$
+    select plan, count(*)::integer, sum(count)::integer from message_usage
+    where day = p_day and count > 0
+    group by plan;
+$$;
+
+revoke execute on function consume_message(text, text, text, integer) from public, anon, authenticated;
+revoke execute on function refund_message(text, text) from public, anon, authenticated;
+revoke execute on function message_usage_summary(text) from public, anon, authenticated;
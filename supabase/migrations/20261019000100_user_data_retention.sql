@@ -0,0 +1,15 @@
+-- Columns of user_data used by the retention of conversations and the
+-- encryption of thread ids, see the users adapter in
+-- internal/adapters/supabase/users.
+alter table user_data
+    add column if not exists last_active_at timestamptz default now(),
+    add column if not exists retention_days integer,
+    add column if not exists key_id text;
+
+-- Entries without a last activity were never selected for retention. Their
+-- retention starts now.
+update user_data set last_active_at = now() where last_active_at is null;
+
+create index if not exists user_data_last_active_at_idx
+    on user_data (last_active_at)
+    where thread_id is not null;
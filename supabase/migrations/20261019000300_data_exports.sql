@@ -0,0 +1,17 @@
+-- Data exports of the users, see the exports adapter in
+-- internal/adapters/supabase/exports. The archive is stored in base64.
+create table if not exists data_exports (
+    id           uuid primary key,
+    user_id      text not null,
+    status       text not null,
+    error        text,
+    archive      text,
+    created_at   timestamptz not null,
+    completed_at timestamptz
+);
+
+create index if not exists data_exports_user_id_idx on data_exports (user_id, created_at);
+create index if not exists data_exports_created_at_idx on data_exports (created_at);
+
+-- Only the service key reads and writes the exports
+alter table data_exports enable row level security;